@@ -0,0 +1,538 @@
+package duit
+
+import (
+	"image"
+	"sort"
+	"unicode"
+
+	"9fans.net/go/draw"
+)
+
+// Bidirectional text, for mixing right-to-left scripts like Hebrew and Arabic with left-to-right text.
+// This is a simplified version of the Unicode Bidirectional Algorithm (UAX #9): explicit embeddings, overrides and isolates are not supported.
+// Text is reordered per line, after line breaking, as the algorithm prescribes.
+
+type bidiClass byte
+
+const (
+	bidiL   bidiClass = iota // Left-to-right letter.
+	bidiR                    // Right-to-left letter, e.g. Hebrew.
+	bidiAL                   // Arabic letter.
+	bidiEN                   // European number.
+	bidiES                   // European separator, plus and minus.
+	bidiET                   // European terminator, e.g. currency and percent.
+	bidiAN                   // Arabic number.
+	bidiCS                   // Common separator, e.g. comma, period and colon.
+	bidiNSM                  // Nonspacing mark.
+	bidiWS                   // Whitespace.
+	bidiON                   // Other neutral.
+)
+
+var (
+	bidiRTables  = []*unicode.RangeTable{unicode.Hebrew, unicode.Samaritan, unicode.Mandaic, unicode.Nko}
+	bidiALTables = []*unicode.RangeTable{unicode.Arabic, unicode.Syriac, unicode.Thaana}
+)
+
+// bidiBrackets maps opening brackets to their closing brackets.
+var bidiBrackets = map[rune]rune{
+	'(': ')',
+	'[': ']',
+	'{': '}',
+}
+
+// bidiMirrors holds characters that are drawn mirrored in right-to-left runs.
+var bidiMirrors = map[rune]rune{
+	'(': ')', ')': '(',
+	'[': ']', ']': '[',
+	'{': '}', '}': '{',
+	'<': '>', '>': '<',
+	'«': '»', '»': '«',
+	'‹': '›', '›': '‹',
+}
+
+func bidiClassOf(c rune) bidiClass {
+	switch {
+	case c >= '0' && c <= '9', c >= 0x06f0 && c <= 0x06f9:
+		return bidiEN
+	case c >= 0x0660 && c <= 0x0669, c == 0x066b, c == 0x066c:
+		return bidiAN
+	case c == '+', c == '-':
+		return bidiES
+	case c == ',', c == '.', c == ':', c == '/', c == 0xa0, c == 0x060c:
+		return bidiCS
+	case c == '#', c == '%', c == 0xb0, unicode.Is(unicode.Sc, c):
+		return bidiET
+	case c == 0x200e:
+		return bidiL
+	case c == 0x200f:
+		return bidiR
+	case unicode.In(c, unicode.Mn, unicode.Me):
+		return bidiNSM
+	case c == ' ', c == '\t', unicode.Is(unicode.Zs, c):
+		return bidiWS
+	case unicode.In(c, bidiALTables...):
+		return bidiAL
+	case unicode.In(c, bidiRTables...):
+		return bidiR
+	case unicode.IsLetter(c), unicode.IsDigit(c), unicode.Is(unicode.Mc, c):
+		return bidiL
+	}
+	return bidiON
+}
+
+// bidiRTL returns whether the first character of s with a strong direction is right-to-left.
+// If s has no such characters, def is returned.
+func bidiRTL(s string, def bool) bool {
+	for _, c := range s {
+		switch bidiClassOf(c) {
+		case bidiL:
+			return false
+		case bidiR, bidiAL:
+			return true
+		}
+	}
+	return def
+}
+
+// bidiLevels returns the resolved embedding level for each of runes, in a paragraph with direction rtl.
+func bidiLevels(runes []rune, rtl bool) []byte {
+	n := len(runes)
+	cl := make([]bidiClass, n)
+	for i, c := range runes {
+		cl[i] = bidiClassOf(c)
+	}
+	base := byte(0)
+	sos := bidiL
+	if rtl {
+		base = 1
+		sos = bidiR
+	}
+
+	// W1, nonspacing marks get the class of the preceding character.
+	prev := sos
+	for i, c := range cl {
+		if c == bidiNSM {
+			cl[i] = prev
+		}
+		prev = cl[i]
+	}
+	// Remember whitespace for L1, before it is resolved.
+	ws := make([]bool, n)
+	for i, c := range cl {
+		ws[i] = c == bidiWS
+	}
+
+	// W2, european numbers after arabic letters are arabic numbers. W3, arabic letters are right-to-left.
+	strong := sos
+	for i, c := range cl {
+		switch c {
+		case bidiL, bidiR, bidiAL:
+			strong = c
+		case bidiEN:
+			if strong == bidiAL {
+				cl[i] = bidiAN
+			}
+		}
+	}
+	for i, c := range cl {
+		if c == bidiAL {
+			cl[i] = bidiR
+		}
+	}
+
+	// W4, a single separator between numbers of the same type gets that type.
+	for i := 1; i+1 < n; i++ {
+		if cl[i-1] == bidiEN && cl[i+1] == bidiEN && (cl[i] == bidiES || cl[i] == bidiCS) {
+			cl[i] = bidiEN
+		} else if cl[i-1] == bidiAN && cl[i+1] == bidiAN && cl[i] == bidiCS {
+			cl[i] = bidiAN
+		}
+	}
+
+	// W5, terminators next to european numbers are european numbers.
+	for i := 0; i < n; {
+		if cl[i] != bidiET {
+			i++
+			continue
+		}
+		j := i
+		for j < n && cl[j] == bidiET {
+			j++
+		}
+		if i > 0 && cl[i-1] == bidiEN || j < n && cl[j] == bidiEN {
+			for k := i; k < j; k++ {
+				cl[k] = bidiEN
+			}
+		}
+		i = j
+	}
+
+	// W6, remaining separators and terminators are neutral.
+	for i, c := range cl {
+		if c == bidiES || c == bidiET || c == bidiCS {
+			cl[i] = bidiON
+		}
+	}
+
+	// W7, european numbers after left-to-right text are left-to-right.
+	strong = sos
+	for i, c := range cl {
+		switch c {
+		case bidiL, bidiR:
+			strong = c
+		case bidiEN:
+			if strong == bidiL {
+				cl[i] = bidiL
+			}
+		}
+	}
+
+	dir := func(c bidiClass) bidiClass {
+		if c == bidiEN || c == bidiAN {
+			return bidiR
+		}
+		return c
+	}
+
+	// N0, paired brackets get the paragraph direction if text inside has that direction.
+	// Otherwise, they get the opposite direction if text inside and the text before the brackets have it.
+	var open []int
+	for i, c := range runes {
+		if cl[i] != bidiON {
+			continue
+		}
+		if _, ok := bidiBrackets[c]; ok {
+			open = append(open, i)
+			continue
+		}
+		for j := len(open) - 1; j >= 0; j-- {
+			o := open[j]
+			if bidiBrackets[runes[o]] != c {
+				continue
+			}
+			open = open[:j]
+			var inside bidiClass = bidiON
+			for k := o + 1; k < i; k++ {
+				d := dir(cl[k])
+				if d == sos {
+					inside = sos
+					break
+				} else if d == bidiL || d == bidiR {
+					inside = d
+				}
+			}
+			if inside == bidiON {
+				break
+			}
+			if inside != sos {
+				before := sos
+				for k := o - 1; k >= 0; k-- {
+					if d := dir(cl[k]); d == bidiL || d == bidiR {
+						before = d
+						break
+					}
+				}
+				if before != inside {
+					inside = sos
+				}
+			}
+			cl[o] = inside
+			cl[i] = inside
+			break
+		}
+	}
+
+	// N1 and N2, neutrals get the direction of the surrounding text if both sides agree, and the paragraph direction otherwise.
+	// Numbers count as right-to-left.
+	neutral := func(c bidiClass) bool {
+		return c == bidiWS || c == bidiON
+	}
+	for i := 0; i < n; {
+		if !neutral(cl[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < n && neutral(cl[j]) {
+			j++
+		}
+		before, after := sos, sos
+		if i > 0 {
+			before = dir(cl[i-1])
+		}
+		if j < n {
+			after = dir(cl[j])
+		}
+		c := sos
+		if before == after {
+			c = before
+		}
+		for k := i; k < j; k++ {
+			cl[k] = c
+		}
+		i = j
+	}
+
+	// I1 and I2, resolve levels.
+	levels := make([]byte, n)
+	for i, c := range cl {
+		l := base
+		switch {
+		case base == 0 && c == bidiR:
+			l = 1
+		case base == 0 && (c == bidiEN || c == bidiAN):
+			l = 2
+		case base == 1 && c != bidiR:
+			l = 2
+		}
+		levels[i] = l
+	}
+
+	// L1, trailing whitespace gets the paragraph level.
+	for i := n - 1; i >= 0 && ws[i]; i-- {
+		levels[i] = base
+	}
+	return levels
+}
+
+// bidiOrder returns the indices of runes with levels in visual order, from left to right.
+func bidiOrder(levels []byte) []int {
+	n := len(levels)
+	order := make([]int, n)
+	var max byte
+	for i, l := range levels {
+		order[i] = i
+		if l > max {
+			max = l
+		}
+	}
+	// L2, from the highest level down to 1, reverse each sequence of characters at that level or higher.
+	for l := max; l >= 1; l-- {
+		for i := 0; i < n; {
+			if levels[order[i]] < l {
+				i++
+				continue
+			}
+			j := i
+			for j < n && levels[order[j]] >= l {
+				j++
+			}
+			for a, b := i, j-1; a < b; a, b = a+1, b-1 {
+				order[a], order[b] = order[b], order[a]
+			}
+			i = j
+		}
+	}
+	return order
+}
+
+// bidiText is a single line of text, prepared for drawing in visual order.
+// Offsets are byte offsets into the logical text.
+type bidiText struct {
+	text   string
+	rtlPar bool // Paragraph direction is right-to-left.
+
+	runes   []rune
+	offsets []int  // Byte offset of each rune, and len(text) at the end.
+	levels  []byte // Odd levels are right-to-left.
+	order   []int  // Rune indices in visual order.
+	xs      []int  // Left x of each rune, by rune index.
+	widths  []int  // Width of each rune, by rune index.
+	width   int
+}
+
+// newBidiText prepares a line of text s in a paragraph with direction rtl.
+func newBidiText(font *draw.Font, s string, rtl bool) *bidiText {
+	b := &bidiText{text: s, rtlPar: rtl}
+	b.split()
+	b.levels = bidiLevels(b.runes, rtl)
+	b.order = bidiOrder(b.levels)
+	b.measure(font)
+	return b
+}
+
+// newLTRText prepares a line of text s without right-to-left text, see bidiMaybeRTL, in a left-to-right paragraph.
+// The bidi algorithm is skipped, all runes are at level 0, in logical order.
+func newLTRText(font *draw.Font, s string) *bidiText {
+	b := &bidiText{text: s}
+	b.split()
+	b.levels = make([]byte, len(b.runes))
+	b.order = make([]int, len(b.runes))
+	for i := range b.order {
+		b.order[i] = i
+	}
+	b.measure(font)
+	return b
+}
+
+// split sets the runes of the text and their offsets.
+func (b *bidiText) split() {
+	for o, c := range b.text {
+		b.runes = append(b.runes, c)
+		b.offsets = append(b.offsets, o)
+	}
+	b.offsets = append(b.offsets, len(b.text))
+}
+
+// measure sets the position and width of each rune, in visual order.
+func (b *bidiText) measure(font *draw.Font) {
+	n := len(b.runes)
+	b.xs = make([]int, n)
+	b.widths = make([]int, n)
+	for _, i := range b.order {
+		b.xs[i] = b.width
+		b.widths[i] = font.StringWidth(string(b.runes[i]))
+		b.width += b.widths[i]
+	}
+}
+
+// align returns the offset for drawing the text in width, right-aligned for right-to-left text.
+func (b *bidiText) align(width int) int {
+	if b.rtlPar {
+		return width - b.width
+	}
+	return 0
+}
+
+func (b *bidiText) rtl(i int) bool {
+	return b.levels[i]%2 == 1
+}
+
+func (b *bidiText) visualRune(i int) rune {
+	c := b.runes[i]
+	if b.rtl(i) {
+		if m, ok := bidiMirrors[c]; ok {
+			return m
+		}
+	}
+	return c
+}
+
+// runeIndex returns the index of the rune at byte offset o.
+func (b *bidiText) runeIndex(o int) int {
+	return sort.SearchInts(b.offsets, o)
+}
+
+// draw draws the text in visual order at p, in color fg.
+// Bytes selStart to selEnd are drawn in selFg, the caller is responsible for drawing their background, see selectionRects.
+// The point after the text is returned.
+func (b *bidiText) draw(img *draw.Image, p image.Point, font *draw.Font, fg *draw.Image, selStart, selEnd int, selFg *draw.Image) image.Point {
+	selected := func(i int) bool {
+		o := b.offsets[i]
+		return o >= selStart && o < selEnd
+	}
+	for i := 0; i < len(b.order); {
+		sel := selected(b.order[i])
+		var s []rune
+		j := i
+		for ; j < len(b.order) && selected(b.order[j]) == sel; j++ {
+			s = append(s, b.visualRune(b.order[j]))
+		}
+		color := fg
+		if sel {
+			color = selFg
+		}
+		p = img.String(p, color, image.ZP, font, string(s))
+		i = j
+	}
+	return p
+}
+
+// selectionRects returns the horizontal ranges, as rectangles with zero height, covered by bytes selStart to selEnd, relative to the start of the text.
+// A logical selection can be split in multiple visual ranges.
+func (b *bidiText) selectionRects(selStart, selEnd int) []image.Rectangle {
+	var l []image.Rectangle
+	for _, i := range b.order {
+		o := b.offsets[i]
+		if o < selStart || o >= selEnd {
+			continue
+		}
+		x0, x1 := b.xs[i], b.xs[i]+b.widths[i]
+		if n := len(l); n > 0 && l[n-1].Max.X == x0 {
+			l[n-1].Max.X = x1
+		} else {
+			l = append(l, image.Rect(x0, 0, x1, 0))
+		}
+	}
+	return l
+}
+
+// cursorX returns the horizontal position of the cursor at byte offset o, relative to the start of the text.
+// The cursor is drawn after the character before it, in the direction of that character.
+func (b *bidiText) cursorX(o int) int {
+	i := b.runeIndex(o)
+	if i > 0 {
+		i--
+		if b.rtl(i) {
+			return b.xs[i]
+		}
+		return b.xs[i] + b.widths[i]
+	}
+	if len(b.runes) == 0 {
+		return 0
+	}
+	if b.rtl(0) {
+		return b.xs[0] + b.widths[0]
+	}
+	return b.xs[0]
+}
+
+// offsetAt returns the byte offset for the cursor closest to horizontal position x, relative to the start of the text.
+func (b *bidiText) offsetAt(x int) int {
+	for _, i := range b.order {
+		if x >= b.xs[i]+b.widths[i] {
+			continue
+		}
+		before := x < b.xs[i]+b.widths[i]/2
+		if before != b.rtl(i) {
+			return b.offsets[i]
+		}
+		return b.offsets[i+1]
+	}
+	n := len(b.order)
+	if n == 0 {
+		return 0
+	}
+	i := b.order[n-1]
+	if b.rtl(i) {
+		return b.offsets[i]
+	}
+	return b.offsets[i+1]
+}
+
+// move returns the byte offset of the cursor position visually left (dir < 0) or right (dir > 0) of byte offset o.
+// If there is no such position, false is returned.
+func (b *bidiText) move(o, dir int) (int, bool) {
+	x := b.cursorX(o)
+	best := -1
+	bestX := 0
+	for _, oo := range b.offsets {
+		xx := b.cursorX(oo)
+		if dir < 0 && xx < x && (best < 0 || xx > bestX) || dir > 0 && xx > x && (best < 0 || xx < bestX) {
+			best = oo
+			bestX = xx
+		}
+	}
+	return best, best >= 0
+}
+
+// bidiMaybeRTL returns whether s has characters of right-to-left scripts.
+func bidiMaybeRTL(s string) bool {
+	for _, c := range s {
+		if c >= 0x0590 && bidiClassOf(c) != bidiL {
+			return true
+		}
+	}
+	return false
+}
+
+// drawBidiString draws a single line s at p in visual order, right-aligned in width if s is right-to-left text.
+// The paragraph direction is that of the first character with a strong direction, or dui.RTL.
+func drawBidiString(dui *DUI, img *draw.Image, p image.Point, width int, color *draw.Image, font *draw.Font, s string) image.Point {
+	if !dui.RTL && !bidiMaybeRTL(s) {
+		return img.String(p, color, image.ZP, font, s)
+	}
+	b := newBidiText(font, s, bidiRTL(s, dui.RTL))
+	p.X += maximum(0, b.align(width))
+	return b.draw(img, p, font, color, 0, 0, nil)
+}
@@ -0,0 +1,77 @@
+package duit
+
+import (
+	"testing"
+)
+
+// bidiVisual returns s as drawn in a paragraph with direction rtl: in visual order, from left to right, with mirrored characters in right-to-left runs.
+func bidiVisual(s string, rtl bool) string {
+	runes := []rune(s)
+	levels := bidiLevels(runes, rtl)
+	var r []rune
+	for _, i := range bidiOrder(levels) {
+		c := runes[i]
+		if m, ok := bidiMirrors[c]; ok && levels[i]%2 == 1 {
+			c = m
+		}
+		r = append(r, c)
+	}
+	return string(r)
+}
+
+func TestBidiVisual(t *testing.T) {
+	tests := []struct {
+		text   string
+		rtl    bool
+		visual string
+	}{
+		{"abc def", false, "abc def"},
+		{"abc def", true, "abc def"},
+		{"אבג", false, "גבא"},
+		{"אבג 123", false, "123 גבא"},
+		{"אבג 123", true, "123 גבא"},
+		{"abc 123", true, "abc 123"},
+		{"abc אבג def", false, "abc גבא def"},
+		{"אבג abc דהו", true, "והד abc גבא"},
+		{"אבג, דהו", false, "והד ,גבא"},
+		{"אבג (abc)", true, "(abc) גבא"},
+		{"אבג (דהו)", false, "(והד) גבא"},
+		{"abc (אבג)", false, "abc (גבא)"},
+		{"1 + 2", false, "1 + 2"},
+		{"مرحبا 12", false, "12 ابحرم"},
+		{"", true, ""},
+	}
+	for _, test := range tests {
+		if v := bidiVisual(test.text, test.rtl); v != test.visual {
+			t.Errorf("%q, rtl %v: got %q, expected %q", test.text, test.rtl, v, test.visual)
+		}
+	}
+}
+
+func TestBidiRTL(t *testing.T) {
+	tests := []struct {
+		text string
+		def  bool
+		rtl  bool
+	}{
+		{"abc אבג", true, false},
+		{"אבג abc", false, true},
+		{"123 אבג", false, true},
+		{"(123)", true, true},
+		{"(123)", false, false},
+	}
+	for _, test := range tests {
+		if rtl := bidiRTL(test.text, test.def); rtl != test.rtl {
+			t.Errorf("%q, default %v: got %v, expected %v", test.text, test.def, rtl, test.rtl)
+		}
+	}
+}
+
+func TestBidiMaybeRTL(t *testing.T) {
+	if bidiMaybeRTL("plain text, 1-2 (é)") {
+		t.Errorf("left-to-right text reported as possibly right-to-left")
+	}
+	if !bidiMaybeRTL("text אבג") || !bidiMaybeRTL("١٢٣") {
+		t.Errorf("right-to-left text not detected")
+	}
+}
@@ -25,6 +25,7 @@ func NewReverseBox(uis ...UI) *Box {
 }
 
 // Box keeps elements on a line as long as they fit, then moves on to the next line.
+// With DUI.RTL set, lines are filled from right to left.
 type Box struct {
 	Kids       []*Kid      // Kids and UIs in this box.
 	Reverse    bool        // Lay out children from bottom to top. First kid will be at the bottom.
@@ -64,7 +65,8 @@ func (ui *Box) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 	padding := dui.ScaleSpace(ui.Padding)
 	margin := scalePt(dui.Display, ui.Margin)
 	sizeAvail = sizeAvail.Sub(padding.Size())
-	nx := 0 // number on current line
+	nx := 0    // number on current line
+	start := 0 // index of first kid on current line
 
 	// variables below are about box contents not offset for padding
 	cur := image.ZP
@@ -76,6 +78,9 @@ func (ui *Box) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 			return
 		}
 		for _, k := range kids {
+			if k.Hidden {
+				continue
+			}
 			switch ui.Valign {
 			case ValignTop:
 			case ValignMiddle:
@@ -87,20 +92,29 @@ func (ui *Box) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 	}
 
 	for i, k := range ui.Kids {
-		k.UI.Layout(dui, k, sizeAvail.Sub(image.Pt(0, cur.Y+lineY)), true)
-		childSize := k.R.Size()
+		if k.Hidden {
+			continue
+		}
+		// kids that fit on the current line get the remaining width of the line, others a new line
+		kidAvail := sizeAvail.Sub(image.Pt(0, cur.Y+lineY))
+		pref := KidMeasure(dui, k, kidAvail).Pref
 		var kr image.Rectangle
-		if nx == 0 || cur.X+childSize.X <= sizeAvail.X {
+		if nx == 0 || cur.X+pref.X <= sizeAvail.X {
+			k.UI.Layout(dui, k, kidAvail.Sub(image.Pt(cur.X, 0)), true)
+			childSize := k.R.Size()
 			kr = rect(childSize).Add(cur).Add(padding.Topleft())
 			cur.X += childSize.X + margin.X
 			lineY = maximum(lineY, childSize.Y)
 			nx += 1
 		} else {
 			if nx > 0 {
-				fixValign(ui.Kids[i-nx : i])
+				fixValign(ui.Kids[start:i])
 				cur.X = 0
 				cur.Y += lineY + margin.Y
 			}
+			start = i
+			k.UI.Layout(dui, k, sizeAvail.Sub(image.Pt(0, cur.Y)), true)
+			childSize := k.R.Size()
 			kr = rect(childSize).Add(cur).Add(padding.Topleft())
 			nx = 1
 			cur.X = childSize.X + margin.X
@@ -111,12 +125,15 @@ func (ui *Box) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 			xmax = cur.X
 		}
 	}
-	fixValign(ui.Kids[len(ui.Kids)-nx : len(ui.Kids)])
+	fixValign(ui.Kids[start:])
 	cur.Y += lineY
 
 	if ui.Reverse {
 		bottomY := cur.Y + padding.Dy()
 		for _, k := range ui.Kids {
+			if k.Hidden {
+				continue
+			}
 			y1 := bottomY - k.R.Min.Y
 			y0 := y1 - k.R.Dy()
 			k.R = image.Rect(k.R.Min.X, y0, k.R.Max.X, y1)
@@ -130,9 +147,103 @@ func (ui *Box) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 	if ui.Height < 0 && ui.size.Y < osize.Y {
 		ui.size.Y = osize.Y
 	}
+	if dui.RTL {
+		kidsMirror(ui.Kids, ui.size.X)
+	}
 	self.R = rect(ui.size)
 }
 
+// Measure lines up the preferred sizes of the kids like Layout does.
+// The minimum size has each kid at its minimum size on a line of its own, the maximum size has all kids at their maximum size on a single line.
+func (ui *Box) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	osize := sizeAvail
+	if ui.Width > 0 && dui.Scale(ui.Width) < sizeAvail.X {
+		sizeAvail.X = dui.Scale(ui.Width)
+	} else if ui.MaxWidth > 0 && dui.Scale(ui.MaxWidth) < sizeAvail.X {
+		sizeAvail.X = dui.Scale(ui.MaxWidth)
+	}
+	if ui.Height > 0 {
+		sizeAvail.Y = dui.Scale(ui.Height)
+	}
+	padding := dui.ScaleSpace(ui.Padding)
+	margin := scalePt(dui.Display, ui.Margin)
+	sizeAvail = sizeAvail.Sub(padding.Size())
+
+	cur := image.ZP
+	xmax := 0
+	lineY := 0
+	nx := 0
+	var min, max image.Point
+	for _, k := range ui.Kids {
+		if k.Hidden {
+			continue
+		}
+		s := KidMeasure(dui, k, sizeAvail.Sub(image.Pt(0, cur.Y+lineY)))
+		if nx == 0 || cur.X+s.Pref.X <= sizeAvail.X {
+			cur.X += s.Pref.X + margin.X
+			lineY = maximum(lineY, s.Pref.Y)
+		} else {
+			cur.Y += lineY + margin.Y
+			cur.X = s.Pref.X + margin.X
+			lineY = s.Pref.Y
+		}
+		nx++
+		xmax = maximum(xmax, cur.X)
+		min.X = maximum(min.X, s.Min.X)
+		min.Y += s.Min.Y + margin.Y
+		max.X += s.Max.X + margin.X
+		max.Y = maximum(max.Y, s.Max.Y)
+	}
+	cur.Y += lineY
+	if nx > 0 {
+		xmax -= margin.X
+		min.Y -= margin.Y
+		max.X -= margin.X
+	}
+
+	pref := image.Pt(xmax, cur.Y).Add(padding.Size())
+	min = min.Add(padding.Size())
+	max = max.Add(padding.Size())
+	if ui.Width > 0 {
+		max.X = minimum(max.X, dui.Scale(ui.Width))
+	} else if ui.MaxWidth > 0 {
+		max.X = minimum(max.X, dui.Scale(ui.MaxWidth))
+	}
+	if ui.Width < 0 {
+		pref.X = osize.X
+	}
+	if ui.Height < 0 && pref.Y < osize.Y {
+		pref.Y = osize.Y
+	}
+	min = image.Pt(minimum(min.X, pref.X), minimum(min.Y, pref.Y))
+	max = image.Pt(maximum(max.X, pref.X), maximum(max.Y, pref.Y))
+	return Sizes{min, pref, max}
+}
+
+// Insert adds uis to Kids at index, and marks the box for layout. Index -1 means at the end.
+func (ui *Box) Insert(dui *DUI, index int, uis ...UI) {
+	ui.Kids = kidsInsert(ui.Kids, index, uis...)
+	dui.MarkLayout(ui)
+}
+
+// Remove removes the kid at index, and marks the box for layout.
+func (ui *Box) Remove(dui *DUI, index int) {
+	ui.Kids = kidsRemove(ui.Kids, index, 1)
+	dui.MarkLayout(ui)
+}
+
+// Move moves the kid at index from to index to, and marks the box for layout.
+func (ui *Box) Move(dui *DUI, from, to int) {
+	kidsMove(ui.Kids, from, to, 1)
+	dui.MarkLayout(ui)
+}
+
+// Replace replaces the kid at index with a new kid for newUI, and marks the box for layout.
+func (ui *Box) Replace(dui *DUI, index int, newUI UI) {
+	ui.Kids[index] = &Kid{UI: newUI}
+	dui.MarkLayout(ui)
+}
+
 func (ui *Box) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	KidsDraw(dui, self, ui.Kids, ui.size, ui.Background, img, orig, m, force)
 }
@@ -34,16 +34,24 @@ func (ui *Button) padding(dui *DUI) image.Point {
 	return image.Pt(fontHeight/2, fontHeight/4)
 }
 
-func (ui *Button) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
-	dui.debugLayout(self)
-
+func (ui *Button) measure(dui *DUI) image.Point {
 	size := ui.font(dui).StringSize(ui.Text).Add(ui.space(dui).Mul(2))
 	if ui.Icon.Font != nil {
 		size.X += ui.Icon.Font.StringSize(string(ui.Icon.Rune)).X
 		size.X += ui.font(dui).StringSize("  ").X
 	}
-	ui.size = size
-	self.R = rect(size)
+	return size
+}
+
+func (ui *Button) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+
+	ui.size = ui.measure(dui)
+	self.R = rect(ui.size)
+}
+
+func (ui *Button) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	return fixedSizes(ui.measure(dui))
 }
 
 func (ui *Button) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
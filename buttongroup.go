@@ -7,19 +7,35 @@ import (
 )
 
 // Buttongroup shows multiple joined buttons, with one of them active.
+// Buttons are shown next to each other, or stacked when Vertical is set.
+// If the buttons do not fit in the available space, arrows are shown on the sides to scroll through the buttons, as does the mouse wheel.
 type Buttongroup struct {
-	Texts    []string                  // Texts to display on the buttons.
-	Selected int                       // Index in Text of the currently selected button.
-	Disabled bool                      // If disabled, the duit.Disabled colors are used and clicks have no effect.
-	Font     *draw.Font                `json:"-"` // Used for drawing Texts.
-	Changed  func(index int) (e Event) `json:"-"` // Called on click on a different button in the group then previously selected.
+	Texts    []string                     // Texts to display on the buttons.
+	Icons    []Icon                       `json:"-"` // Optional icons, displayed before the texts. If not nil, must have the same length as Texts.
+	Selected int                          // Index in Text of the currently selected button.
+	Disabled bool                         // If disabled, the duit.Disabled colors are used and clicks have no effect.
+	Vertical bool                         // If set, buttons are stacked vertically and the group takes the full available height, e.g. for a sidebar.
+	Closable bool                         // If set, each button has a close mark. A click on it calls Close.
+	Movable  bool                         // If set, buttons can be reordered by dragging them with button 1.
+	Font     *draw.Font                   `json:"-"` // Used for drawing Texts.
+	Changed  func(index int) (e Event)    `json:"-"` // Called on click on a different button in the group then previously selected.
+	Close    func(index int) (e Event)    `json:"-"` // Called on click on the close mark of the button at index. Removing the button is up to the callback.
+	Moved    func(from, to int) (e Event) `json:"-"` // Called after a button was dragged from index from to index to. Texts, Icons and Selected have already been updated.
 
-	m    draw.Mouse
-	size image.Point
+	m        draw.Mouse
+	size     image.Point // visible size
+	length   int         // size of all buttons along the main axis, can be larger than visible
+	overflow bool        // whether not all buttons fit, and arrows are shown
+	offset   int         // scroll offset into all buttons, when overflowing
+	dragging int         // 1 + index of button being dragged
+	closing  int         // 1 + index of button whose close mark was pressed
+	img      *draw.Image // scratch image for drawing all buttons when overflowing
 }
 
 var _ UI = &Buttongroup{}
 
+const buttongroupClose = " ×"
+
 func (ui *Buttongroup) font(dui *DUI) *draw.Font {
 	return dui.Font(ui.Font)
 }
@@ -29,22 +45,124 @@ func (ui *Buttongroup) padding(dui *DUI) image.Point {
 	return image.Pt(fontHeight/2, fontHeight/4)
 }
 
-func (ui *Buttongroup) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
-	dui.debugLayout(self)
-	pad2 := ui.padding(dui).Mul(2)
-	size := image.Pt(2*BorderSize, 2*BorderSize+pad2.Y+ui.font(dui).Height)
+// dim returns the coordinate of p along the main axis.
+func (ui *Buttongroup) dim(p image.Point) int {
+	if ui.Vertical {
+		return p.Y
+	}
+	return p.X
+}
+
+// along returns p with its coordinate along the main axis replaced by v.
+func (ui *Buttongroup) along(p image.Point, v int) image.Point {
+	if ui.Vertical {
+		p.Y = v
+	} else {
+		p.X = v
+	}
+	return p
+}
+
+func (ui *Buttongroup) arrowLength(dui *DUI) int {
+	return ui.font(dui).Height
+}
+
+func (ui *Buttongroup) icon(i int) (icon Icon, ok bool) {
+	if i >= len(ui.Icons) || ui.Icons[i].Font == nil {
+		return
+	}
+	return ui.Icons[i], true
+}
+
+func (ui *Buttongroup) iconWidth(dui *DUI, i int) int {
+	icon, ok := ui.icon(i)
+	if !ok {
+		return 0
+	}
+	return icon.Font.StringWidth(string(icon.Rune)) + ui.font(dui).StringWidth("  ")
+}
+
+// contentWidth returns the width of the icon, text and close mark of button i, without padding.
+func (ui *Buttongroup) contentWidth(dui *DUI, i int) int {
 	font := ui.font(dui)
-	for i, t := range ui.Texts {
-		size.X += font.StringSize(t).X + pad2.X
-		if i > 0 {
-			size.X += BorderSize
+	dx := ui.iconWidth(dui, i) + font.StringWidth(ui.Texts[i])
+	if ui.Closable {
+		dx += font.StringWidth(buttongroupClose)
+	}
+	return dx
+}
+
+// buttonLength returns the size of button i along the main axis, including padding and the border after it.
+func (ui *Buttongroup) buttonLength(dui *DUI, i int) int {
+	pad := ui.padding(dui)
+	if ui.Vertical {
+		return ui.font(dui).Height + 2*pad.Y + BorderSize
+	}
+	return ui.contentWidth(dui, i) + 2*pad.X + BorderSize
+}
+
+// buttonStart returns the offset along the main axis of button index.
+func (ui *Buttongroup) buttonStart(dui *DUI, index int) int {
+	start := 0
+	for i := 0; i < index; i++ {
+		start += ui.buttonLength(dui, i)
+	}
+	return start
+}
+
+// groupSize returns the size of all buttons, including parts that are not visible when overflowing.
+func (ui *Buttongroup) groupSize() image.Point {
+	if !ui.overflow {
+		return ui.size
+	}
+	return ui.along(ui.size, ui.length)
+}
+
+// measure returns the length of all buttons, the size without overflow, and whether the buttons overflow sizeAvail.
+func (ui *Buttongroup) measure(dui *DUI, sizeAvail image.Point) (length int, size image.Point, overflow bool) {
+	pad2 := ui.padding(dui).Mul(2)
+	length = BorderSize
+	for i := range ui.Texts {
+		length += ui.buttonLength(dui, i)
+	}
+	if ui.Vertical {
+		dx := 0
+		for i := range ui.Texts {
+			dx = maximum(dx, ui.contentWidth(dui, i))
 		}
+		size = image.Pt(2*BorderSize+pad2.X+dx, maximum(length, sizeAvail.Y))
+	} else {
+		size = image.Pt(length, 2*BorderSize+pad2.Y+ui.font(dui).Height)
+	}
+	avail := ui.dim(sizeAvail)
+	overflow = length > avail && avail > 4*ui.arrowLength(dui)
+	return
+}
+
+func (ui *Buttongroup) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+	var size image.Point
+	ui.length, size, ui.overflow = ui.measure(dui, sizeAvail)
+	if ui.overflow {
+		size = ui.along(size, ui.dim(sizeAvail))
 	}
 	ui.size = size
+	ui.scroll(dui, 0)
 	self.R = rect(size)
 	return
 }
 
+// Measure returns as minimum the size with scroll arrows, and as maximum the size without overflow.
+func (ui *Buttongroup) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	length, size, overflow := ui.measure(dui, sizeAvail)
+	pref := size
+	if overflow {
+		pref = ui.along(size, ui.dim(sizeAvail))
+	}
+	min := ui.along(size, minimum(length, 4*ui.arrowLength(dui)+1))
+	return Sizes{min, pref, size}
+}
+
 func (ui *Buttongroup) selected() int {
 	if ui.Selected < 0 || ui.Selected >= len(ui.Texts) {
 		return 0
@@ -52,6 +170,67 @@ func (ui *Buttongroup) selected() int {
 	return ui.Selected
 }
 
+// visible returns the visible size along the main axis between the arrows.
+func (ui *Buttongroup) visible(dui *DUI) int {
+	return ui.dim(ui.size) - 2*ui.arrowLength(dui)
+}
+
+// scroll moves the visible part of the buttons by delta pixels, returning whether anything changed.
+func (ui *Buttongroup) scroll(dui *DUI, delta int) bool {
+	o := ui.offset
+	if !ui.overflow {
+		ui.offset = 0
+		return o != ui.offset
+	}
+	ui.offset = maximum(0, minimum(ui.offset+delta, ui.length-ui.visible(dui)))
+	return o != ui.offset
+}
+
+// scrollVisible scrolls the button at index into view.
+func (ui *Buttongroup) scrollVisible(dui *DUI, index int) bool {
+	if !ui.overflow || index < 0 || index >= len(ui.Texts) {
+		return false
+	}
+	start := ui.buttonStart(dui, index)
+	end := start + ui.buttonLength(dui, index) + BorderSize
+	if start < ui.offset {
+		return ui.scroll(dui, start-ui.offset)
+	} else if end > ui.offset+ui.visible(dui) {
+		return ui.scroll(dui, end-ui.offset-ui.visible(dui))
+	}
+	return false
+}
+
+// groupPoint turns a point within the visible UI into a point within all buttons.
+func (ui *Buttongroup) groupPoint(dui *DUI, p image.Point) image.Point {
+	if !ui.overflow {
+		return p
+	}
+	return ui.along(p, ui.dim(p)-ui.arrowLength(dui)+ui.offset)
+}
+
+// uiPoint is the inverse of groupPoint.
+func (ui *Buttongroup) uiPoint(dui *DUI, p image.Point) image.Point {
+	if !ui.overflow {
+		return p
+	}
+	return ui.along(p, ui.dim(p)+ui.arrowLength(dui)-ui.offset)
+}
+
+// arrow returns -1 or 1 if p is on the scroll arrow at the start or end, and 0 otherwise.
+func (ui *Buttongroup) arrow(dui *DUI, p image.Point) int {
+	if !ui.overflow || !p.In(rect(ui.size)) {
+		return 0
+	}
+	if ui.dim(p) < ui.arrowLength(dui) {
+		return -1
+	}
+	if ui.dim(p) >= ui.dim(ui.size)-ui.arrowLength(dui) {
+		return 1
+	}
+	return 0
+}
+
 func (ui *Buttongroup) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	dui.debugDraw(self)
 
@@ -59,9 +238,68 @@ func (ui *Buttongroup) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Poi
 		return
 	}
 
-	r := rect(ui.size)
+	hover := m.In(rect(ui.size))
+	if !ui.overflow {
+		ui.drawButtons(dui, img, orig, m, hover)
+		return
+	}
+
+	size := ui.groupSize()
+	if ui.img == nil || !ui.img.R.Size().Eq(size) {
+		if ui.img != nil {
+			ui.img.Free()
+			ui.img = nil
+		}
+		var err error
+		ui.img, err = dui.Display.AllocImage(rect(size), draw.ARGB32, false, dui.BackgroundColor)
+		if dui.error(err, "allocimage") {
+			return
+		}
+	}
+	ui.img.Draw(ui.img.R, dui.Background, nil, image.ZP)
+	gm := m
+	gm.Point = ui.groupPoint(dui, m.Point)
+	if ui.arrow(dui, m.Point) != 0 {
+		gm.Point = image.Pt(-1, -1)
+	}
+	ui.drawButtons(dui, ui.img, image.ZP, gm, hover)
+
+	al := ui.arrowLength(dui)
+	r := rect(ui.size).Add(orig)
+	visR := r
+	visR.Min = ui.along(visR.Min, ui.dim(visR.Min)+al)
+	visR.Max = ui.along(visR.Max, ui.dim(visR.Max)-al)
+	img.Draw(visR, ui.img, nil, ui.along(image.ZP, ui.offset))
+
+	font := ui.font(dui)
+	drawArrow := func(ar image.Rectangle, s string, dir int) {
+		colors := dui.Regular.Normal
+		if ui.Disabled {
+			colors = dui.Disabled
+		} else if ui.arrow(dui, m.Point) == dir {
+			colors = dui.Regular.Hover
+		}
+		img.Draw(ar, colors.Background, nil, image.ZP)
+		p := ar.Min.Add(image.Pt((ar.Dx()-font.StringWidth(s))/2, (ar.Dy()-font.Height)/2))
+		img.String(p, colors.Text, image.ZP, font, s)
+	}
+	startR := r
+	startR.Max = ui.along(startR.Max, ui.dim(r.Min)+al)
+	endR := r
+	endR.Min = ui.along(endR.Min, ui.dim(r.Max)-al)
+	if ui.Vertical {
+		drawArrow(startR, "▲", -1)
+		drawArrow(endR, "▼", 1)
+	} else {
+		drawArrow(startR, "‹", -1)
+		drawArrow(endR, "›", 1)
+	}
+}
+
+// drawButtons draws all buttons, with orig the top left of the first button. m is relative to orig.
+func (ui *Buttongroup) drawButtons(dui *DUI, img *draw.Image, orig image.Point, m draw.Mouse, hover bool) {
+	r := rect(ui.groupSize())
 
-	hover := m.In(r)
 	colors := dui.Regular.Normal
 	if ui.Disabled {
 		colors = dui.Disabled
@@ -75,38 +313,62 @@ func (ui *Buttongroup) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Poi
 	sel := ui.selected()
 	font := ui.font(dui)
 	pad := ui.padding(dui)
-	pad2 := pad.Mul(2)
-	p := r.Min.Add(pt(BorderSize)).Add(pad)
+	closeDx := font.StringWidth(buttongroupClose)
+	o := BorderSize
 	for i, t := range ui.Texts {
-		dx := font.StringWidth(t)
-		selR := image.Rect(p.X-pad.X, r.Min.Y+BorderSize, p.X+dx+pad.X, r.Max.Y-BorderSize)
+		n := ui.buttonLength(dui, i) - BorderSize
+		var selR image.Rectangle
+		if ui.Vertical {
+			selR = image.Rect(r.Min.X+BorderSize, r.Min.Y+o, r.Max.X-BorderSize, r.Min.Y+o+n)
+		} else {
+			selR = image.Rect(r.Min.X+o, r.Min.Y+BorderSize, r.Min.X+o+n, r.Max.Y-BorderSize)
+		}
 		col := colors
 		if i == sel {
 			col = dui.Primary.Normal
 			img.Draw(selR, col.Background, nil, image.ZP)
 		}
 		if i > 0 {
-			p0 := image.Pt(p.X-pad.X-BorderSize, r.Min.Y+BorderSize)
-			p1 := p0.Add(image.Pt(0, r.Dy()-2*BorderSize))
+			var p0, p1 image.Point
+			if ui.Vertical {
+				p0 = image.Pt(selR.Min.X, selR.Min.Y-BorderSize)
+				p1 = image.Pt(selR.Max.X-1, p0.Y)
+			} else {
+				p0 = image.Pt(selR.Min.X-BorderSize, selR.Min.Y)
+				p1 = image.Pt(p0.X, selR.Max.Y-1)
+			}
 			img.Line(p0, p1, 0, 0, 0, col.Border, image.ZP)
 		}
-		pp := p
+		p := selR.Min.Add(pad)
 		if !ui.Disabled && m.Buttons == Button1 && m.Add(orig).In(selR) {
-			pp = pp.Add(image.Pt(0, 1))
+			p = p.Add(image.Pt(0, 1))
 		}
-		p0 := img.String(pp, col.Text, image.ZP, font, t)
-		p.X = p0.X + pad2.X + BorderSize
+		if icon, ok := ui.icon(i); ok {
+			iconSize := icon.Font.StringSize(string(icon.Rune))
+			dy := (iconSize.Y - font.Height) / 2
+			img.String(p.Sub(image.Pt(0, dy)), col.Text, image.ZP, icon.Font, string(icon.Rune))
+			p.X += ui.iconWidth(dui, i)
+		}
+		img.String(p, col.Text, image.ZP, font, t)
+		if ui.Closable {
+			closeCol := col.Text
+			closeR := image.Rect(selR.Max.X-pad.X-closeDx, selR.Min.Y, selR.Max.X-pad.X, selR.Max.Y)
+			if !ui.Disabled && m.Add(orig).In(closeR) {
+				closeCol = dui.Danger.Normal.Background
+			}
+			img.String(image.Pt(closeR.Min.X, p.Y), closeCol, image.ZP, font, buttongroupClose)
+		}
+		o += n + BorderSize
 	}
 }
 
-// findIndex returns the index of the text under the mouse, and the start and end X of the text
+// findIndex returns the index of the button under the mouse, and the start and end of the button along the main axis, all in group coordinates.
 func (ui *Buttongroup) findIndex(dui *DUI, m draw.Mouse) (int, int, int) {
 	offset := 0
-	pad2 := ui.padding(dui).Mul(2)
-	font := ui.font(dui)
-	for i, t := range ui.Texts {
-		end := offset + font.StringSize(t).X + pad2.X + BorderSize
-		if m.X >= offset && m.X < end {
+	v := ui.dim(m.Point)
+	for i := range ui.Texts {
+		end := offset + ui.buttonLength(dui, i)
+		if v >= offset && v < end {
 			return i, offset, end
 		}
 		offset = end
@@ -114,13 +376,122 @@ func (ui *Buttongroup) findIndex(dui *DUI, m draw.Mouse) (int, int, int) {
 	return -1, 0, 0
 }
 
+// onClose returns whether p, in group coordinates, is on the close mark of button index that starts at start.
+func (ui *Buttongroup) onClose(dui *DUI, index, start int, p image.Point) bool {
+	if !ui.Closable {
+		return false
+	}
+	pad := ui.padding(dui)
+	closeDx := ui.font(dui).StringWidth(buttongroupClose)
+	var x int
+	if ui.Vertical {
+		x = p.X - (ui.size.X - BorderSize - pad.X - closeDx)
+	} else {
+		x = p.X - (start + BorderSize + pad.X + ui.contentWidth(dui, index) - closeDx)
+	}
+	return x >= 0 && x < closeDx
+}
+
+// move moves the button at from to index to, keeping the selection on the same button.
+func (ui *Buttongroup) move(from, to int) {
+	t := ui.Texts[from]
+	if from < to {
+		copy(ui.Texts[from:to], ui.Texts[from+1:to+1])
+	} else {
+		copy(ui.Texts[to+1:from+1], ui.Texts[to:from])
+	}
+	ui.Texts[to] = t
+
+	if ui.Icons != nil {
+		icon := ui.Icons[from]
+		if from < to {
+			copy(ui.Icons[from:to], ui.Icons[from+1:to+1])
+		} else {
+			copy(ui.Icons[to+1:from+1], ui.Icons[to:from])
+		}
+		ui.Icons[to] = icon
+	}
+
+	switch sel := ui.Selected; {
+	case sel == from:
+		ui.Selected = to
+	case from < sel && sel <= to:
+		ui.Selected--
+	case to <= sel && sel < from:
+		ui.Selected++
+	}
+}
+
 func (ui *Buttongroup) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
 	if ui.m.Buttons^m.Buttons != 0 {
 		self.Draw = Dirty
 	}
-	if ui.m.Buttons == Button1 && m.Buttons == 0 && !ui.Disabled {
-		index, _, _ := ui.findIndex(dui, m)
-		if index >= 0 {
+	prevM := ui.m
+	ui.m = m
+	if ui.Disabled {
+		return
+	}
+
+	if ui.overflow {
+		var delta int
+		switch m.Buttons {
+		case Button4:
+			delta = -ui.dim(ui.size) / 4
+		case Button5:
+			delta = ui.dim(ui.size) / 4
+		}
+		if prevM.Buttons == 0 && m.Buttons == Button1 {
+			delta = ui.arrow(dui, m.Point) * ui.dim(ui.size) / 2
+		}
+		if delta != 0 && ui.scroll(dui, delta) {
+			self.Draw = Dirty
+		}
+		if delta != 0 || ui.arrow(dui, origM.Point) != 0 {
+			r.Consumed = true
+			return
+		}
+	}
+
+	gm := m
+	gm.Point = ui.groupPoint(dui, m.Point)
+	if prevM.Buttons == 0 && m.Buttons == Button1 {
+		index, start, _ := ui.findIndex(dui, gm)
+		ui.closing = 0
+		if index >= 0 && ui.onClose(dui, index, start, gm.Point) {
+			ui.closing = 1 + index
+		} else if ui.Movable && index >= 0 {
+			ui.dragging = 1 + index
+		}
+	} else if ui.dragging > 0 && m.Buttons == Button1 {
+		from := ui.dragging - 1
+		to, start, end := ui.findIndex(dui, gm)
+		// only move when the dragged button would be under the mouse after the move, to prevent flipping between buttons of different sizes
+		n := ui.buttonLength(dui, from)
+		v := ui.dim(gm.Point)
+		if to >= 0 && to != from && (to > from && v >= end-n || to < from && v < start+n) {
+			ui.move(from, to)
+			ui.dragging = 1 + to
+			if ui.Moved != nil {
+				e := ui.Moved(from, to)
+				propagateEvent(self, &r, e)
+			}
+			self.Draw = Dirty
+			r.Consumed = true
+		}
+	} else if prevM.Buttons == Button1 && m.Buttons == 0 {
+		closing := ui.closing
+		ui.dragging = 0
+		ui.closing = 0
+		index, start, _ := ui.findIndex(dui, gm)
+		if closing > 0 {
+			// only a release on the close mark that was pressed closes, a release elsewhere cancels
+			if index == closing-1 && ui.onClose(dui, index, start, gm.Point) && ui.Close != nil {
+				e := ui.Close(index)
+				propagateEvent(self, &r, e)
+			}
+			self.Draw = Dirty
+			r.Consumed = true
+		} else if index >= 0 {
 			ui.Selected = index
 			if ui.Changed != nil {
 				e := ui.Changed(ui.Selected)
@@ -130,7 +501,6 @@ func (ui *Buttongroup) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse
 			r.Consumed = true
 		}
 	}
-	ui.m = m
 	return
 }
 
@@ -138,9 +508,11 @@ func (ui *Buttongroup) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image
 	if ui.Disabled {
 		return
 	}
+	gm := m
+	gm.Point = ui.groupPoint(dui, m.Point)
 	switch k {
 	case ' ', '\n':
-		index, _, _ := ui.findIndex(dui, m)
+		index, _, _ := ui.findIndex(dui, gm)
 		if index < 0 {
 			break
 		}
@@ -152,13 +524,15 @@ func (ui *Buttongroup) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image
 			propagateEvent(self, &r, e)
 		}
 	case '\t':
-		index, _, end := ui.findIndex(dui, m)
+		index, _, _ := ui.findIndex(dui, gm)
 		if index < 0 {
 			break
 		}
 		index++
 		if index < len(ui.Texts) {
-			p := orig.Add(image.Pt(end+BorderSize*2+ui.padding(dui).X, m.Y))
+			ui.scrollVisible(dui, index)
+			start := ui.buttonStart(dui, index) + BorderSize*2 + ui.dim(ui.padding(dui))
+			p := orig.Add(ui.uiPoint(dui, ui.along(m.Point, start)))
 			r.Warp = &p
 			r.Consumed = true
 			self.Draw = Dirty
@@ -169,6 +543,9 @@ func (ui *Buttongroup) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image
 
 func (ui *Buttongroup) FirstFocus(dui *DUI, self *Kid) *image.Point {
 	p := ui.padding(dui)
+	if ui.overflow {
+		p = ui.along(p, ui.dim(p)+ui.arrowLength(dui))
+	}
 	// todo: move to active item
 	return &p
 }
@@ -42,6 +42,11 @@ func (ui *Checkbox) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force boo
 	return
 }
 
+func (ui *Checkbox) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	hit := image.Point{0, 1}
+	return fixedSizes(ui.size(dui).Add(hit))
+}
+
 func (ui *Checkbox) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	dui.debugDraw(self)
 
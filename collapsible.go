@@ -0,0 +1,408 @@
+package duit
+
+import (
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// Collapsible is a section with a clickable header with a title and disclosure triangle, that shows or hides UI below it.
+// If the Kid holding the Collapsible has an ID, the open state is stored and restored on next load.
+//
+// Keys on the header:
+//	space or \n, open or close the section
+type Collapsible struct {
+	Title   string                    // Shown in the header.
+	Open    bool                      // Whether UI is shown. Call MarkLayout after changing.
+	UI      UI                        // Shown below the header when open.
+	Font    *draw.Font                `json:"-"` // Used for drawing Title.
+	Changed func(open bool) (e Event) `json:"-"` // Called after the user opened or closed the section.
+
+	kids         []*Kid // header, and UI if not nil
+	settingsRead bool
+	size         image.Point
+}
+
+var _ UI = &Collapsible{}
+
+// collapsibleHeader is the clickable header of a Collapsible.
+type collapsibleHeader struct {
+	c    *Collapsible
+	size image.Point
+	m    draw.Mouse
+}
+
+var _ UI = &collapsibleHeader{}
+
+func (ui *Collapsible) font(dui *DUI) *draw.Font {
+	return dui.Font(ui.Font)
+}
+
+func (ui *Collapsible) ensure() {
+	if ui.kids == nil {
+		ui.kids = NewKids(&collapsibleHeader{c: ui})
+	}
+	if ui.UI == nil {
+		ui.kids = ui.kids[:1]
+	} else if len(ui.kids) == 1 {
+		ui.kids = append(ui.kids, &Kid{UI: ui.UI})
+	} else if ui.kids[1].UI != ui.UI {
+		ui.kids[1] = &Kid{UI: ui.UI}
+	}
+}
+
+// visibleKids returns the header, and UI if open.
+func (ui *Collapsible) visibleKids() []*Kid {
+	if ui.Open {
+		return ui.kids
+	}
+	return ui.kids[:1]
+}
+
+// toggle opens or closes the section on behalf of the user, and calls Changed.
+func (ui *Collapsible) toggle(self *Kid, r *Result) {
+	ui.Open = !ui.Open
+	if ui.Changed != nil {
+		e := ui.Changed(ui.Open)
+		propagateEvent(self, r, e)
+	}
+}
+
+// afterInput stores a changed open state and marks the Collapsible for layout.
+func (ui *Collapsible) afterInput(dui *DUI, self *Kid, open bool) {
+	if ui.Open == open {
+		return
+	}
+	dui.WriteSettings(self, ui.Open)
+	self.Layout = Dirty
+}
+
+func (ui *Collapsible) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+	ui.ensure()
+	if ui.readSettings(dui, self) {
+		force = true
+	}
+	kids := ui.visibleKids()
+	if KidsLayout(dui, self, kids, force) {
+		return
+	}
+
+	header := kids[0]
+	header.UI.Layout(dui, header, sizeAvail, true)
+	ui.size = header.R.Size()
+	if len(kids) > 1 {
+		k := kids[1]
+		k.UI.Layout(dui, k, image.Pt(sizeAvail.X, sizeAvail.Y-ui.size.Y), true)
+		k.R = rect(k.R.Size()).Add(image.Pt(0, ui.size.Y))
+		ui.size.X = maximum(ui.size.X, k.R.Dx())
+		ui.size.Y += k.R.Dy()
+	}
+	self.R = rect(ui.size)
+}
+
+// readSettings restores the open state the first time it is called, and returns whether it was the first call.
+func (ui *Collapsible) readSettings(dui *DUI, self *Kid) bool {
+	if ui.settingsRead {
+		return false
+	}
+	ui.settingsRead = true
+	dui.ReadSettings(self, &ui.Open)
+	return true
+}
+
+func (ui *Collapsible) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	ui.ensure()
+	ui.readSettings(dui, self)
+	kids := ui.visibleKids()
+	s := KidMeasure(dui, kids[0], sizeAvail)
+	if len(kids) > 1 {
+		s = s.below(KidMeasure(dui, kids[1], image.Pt(sizeAvail.X, sizeAvail.Y-s.Pref.Y)))
+	}
+	return s
+}
+
+func (ui *Collapsible) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	KidsDraw(dui, self, ui.visibleKids(), ui.size, nil, img, orig, m, force)
+}
+
+func (ui *Collapsible) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	open := ui.Open
+	r = KidsMouse(dui, self, ui.visibleKids(), m, origM, orig)
+	ui.afterInput(dui, self, open)
+	return
+}
+
+func (ui *Collapsible) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	open := ui.Open
+	r = KidsKey(dui, self, ui.visibleKids(), k, m, orig)
+	ui.afterInput(dui, self, open)
+	return
+}
+
+func (ui *Collapsible) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	return KidsFirstFocus(dui, self, ui.visibleKids())
+}
+
+func (ui *Collapsible) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	return KidsFocus(dui, self, ui.visibleKids(), o)
+}
+
+func (ui *Collapsible) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	ui.ensure()
+	return KidsMark(self, ui.kids, o, forLayout)
+}
+
+func (ui *Collapsible) Print(self *Kid, indent int) {
+	PrintUI("Collapsible", self, indent)
+	KidsPrint(ui.visibleKids(), indent+1)
+}
+
+func (ui *collapsibleHeader) padding(dui *DUI) image.Point {
+	fontHeight := ui.c.font(dui).Height
+	return image.Pt(fontHeight/2, fontHeight/4)
+}
+
+// measure returns the minimum size, and the size for sizeAvail.
+func (ui *collapsibleHeader) measure(dui *DUI, sizeAvail image.Point) (image.Point, image.Point) {
+	font := ui.c.font(dui)
+	pad := ui.padding(dui)
+	min := image.Pt(font.StringWidth("▾ "+ui.c.Title)+2*pad.X, font.Height+2*pad.Y+BorderSize)
+	return min, image.Pt(maximum(min.X, sizeAvail.X), min.Y)
+}
+
+func (ui *collapsibleHeader) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+	_, ui.size = ui.measure(dui, sizeAvail)
+	self.R = rect(ui.size)
+}
+
+func (ui *collapsibleHeader) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	min, size := ui.measure(dui, sizeAvail)
+	return Sizes{min, size, size}
+}
+
+func (ui *collapsibleHeader) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+
+	r := rect(ui.size).Add(orig)
+	colors := dui.Regular.Normal
+	if m.In(rect(ui.size)) {
+		colors = dui.Regular.Hover
+	}
+	img.Draw(r, colors.Background, nil, image.ZP)
+	img.Line(image.Pt(r.Min.X, r.Max.Y-1), image.Pt(r.Max.X-1, r.Max.Y-1), 0, 0, 0, dui.Regular.Normal.Border, image.ZP)
+
+	s := "▸ "
+	if ui.c.Open {
+		s = "▾ "
+	}
+	p := r.Min.Add(ui.padding(dui))
+	img.String(p, colors.Text, image.ZP, ui.c.font(dui), s+ui.c.Title)
+}
+
+func (ui *collapsibleHeader) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	if ui.m.In(rect(ui.size)) != m.In(rect(ui.size)) {
+		self.Draw = Dirty
+	}
+	if ui.m.Buttons == Button1 && m.Buttons == 0 && m.In(rect(ui.size)) {
+		ui.c.toggle(self, &r)
+		self.Draw = Dirty
+		r.Consumed = true
+	}
+	ui.m = m
+	return
+}
+
+func (ui *collapsibleHeader) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	switch k {
+	case ' ', '\n':
+		ui.c.toggle(self, &r)
+		self.Draw = Dirty
+		r.Consumed = true
+	}
+	return
+}
+
+func (ui *collapsibleHeader) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	p := ui.padding(dui)
+	return &p
+}
+
+func (ui *collapsibleHeader) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if o != ui {
+		return nil
+	}
+	return ui.FirstFocus(dui, self)
+}
+
+func (ui *collapsibleHeader) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return self.Mark(o, forLayout)
+}
+
+func (ui *collapsibleHeader) Print(self *Kid, indent int) {
+	PrintUI("collapsibleHeader", self, indent)
+}
+
+// NewAccordion returns an accordion with sections as its Kids.
+func NewAccordion(sections ...*Collapsible) *Accordion {
+	kids := make([]*Kid, len(sections))
+	for i, c := range sections {
+		kids[i] = &Kid{UI: c}
+	}
+	return &Accordion{Kids: kids}
+}
+
+// Accordion stacks Collapsible sections vertically, of which at most one is open.
+// When the user opens a section, the other sections are closed.
+type Accordion struct {
+	Kids       []*Kid      // Kids holding a *Collapsible.
+	Background *draw.Image `json:"-"` // Background for this accordion, instead of default duit background.
+
+	size image.Point
+}
+
+var _ UI = &Accordion{}
+
+func (ui *Accordion) section(k *Kid) *Collapsible {
+	return k.UI.(*Collapsible)
+}
+
+func (ui *Accordion) openStates() []bool {
+	open := make([]bool, len(ui.Kids))
+	for i, k := range ui.Kids {
+		open[i] = ui.section(k).Open
+	}
+	return open
+}
+
+// closeOthers closes all sections except a section that was opened since wasOpen was gathered.
+func (ui *Accordion) closeOthers(dui *DUI, self *Kid, wasOpen []bool, r *Result) {
+	opened := -1
+	for i, k := range ui.Kids {
+		if ui.section(k).Open && !wasOpen[i] {
+			opened = i
+			break
+		}
+	}
+	if opened < 0 {
+		return
+	}
+	for i, k := range ui.Kids {
+		c := ui.section(k)
+		if i == opened || !c.Open {
+			continue
+		}
+		c.Open = false
+		k.Layout = Dirty
+		dui.WriteSettings(k, c.Open)
+		if c.Changed != nil {
+			e := c.Changed(c.Open)
+			propagateEvent(self, r, e)
+		}
+	}
+	self.Layout = Dirty
+}
+
+func sectionUIs(sections []*Collapsible) []UI {
+	uis := make([]UI, len(sections))
+	for i, c := range sections {
+		uis[i] = c
+	}
+	return uis
+}
+
+// Insert adds sections to Kids at index, and marks the accordion for layout. Index -1 means at the end.
+func (ui *Accordion) Insert(dui *DUI, index int, sections ...*Collapsible) {
+	ui.Kids = kidsInsert(ui.Kids, index, sectionUIs(sections)...)
+	dui.MarkLayout(ui)
+}
+
+// Remove removes the section at index, and marks the accordion for layout.
+func (ui *Accordion) Remove(dui *DUI, index int) {
+	ui.Kids = kidsRemove(ui.Kids, index, 1)
+	dui.MarkLayout(ui)
+}
+
+// Move moves the section at index from to index to, and marks the accordion for layout.
+func (ui *Accordion) Move(dui *DUI, from, to int) {
+	kidsMove(ui.Kids, from, to, 1)
+	dui.MarkLayout(ui)
+}
+
+// Replace replaces the section at index with a new kid for section, and marks the accordion for layout.
+func (ui *Accordion) Replace(dui *DUI, index int, section *Collapsible) {
+	ui.Kids[index] = &Kid{UI: section}
+	dui.MarkLayout(ui)
+}
+
+func (ui *Accordion) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+	if KidsLayout(dui, self, ui.Kids, force) {
+		return
+	}
+
+	ui.size = image.ZP
+	open := false
+	for _, k := range ui.Kids {
+		if k.Hidden {
+			continue
+		}
+		k.UI.Layout(dui, k, image.Pt(sizeAvail.X, sizeAvail.Y-ui.size.Y), true)
+		// sections can be restored as open from their settings, only keep the first open
+		if c := ui.section(k); c.Open && open {
+			c.Open = false
+			dui.WriteSettings(k, c.Open)
+			k.UI.Layout(dui, k, image.Pt(sizeAvail.X, sizeAvail.Y-ui.size.Y), true)
+		} else if c.Open {
+			open = true
+		}
+		k.R = rect(k.R.Size()).Add(image.Pt(0, ui.size.Y))
+		ui.size.X = maximum(ui.size.X, k.R.Dx())
+		ui.size.Y += k.R.Dy()
+	}
+	self.R = rect(ui.size)
+}
+
+func (ui *Accordion) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	var s Sizes
+	for _, k := range ui.Kids {
+		s = s.below(KidMeasure(dui, k, image.Pt(sizeAvail.X, sizeAvail.Y-s.Pref.Y)))
+	}
+	return s
+}
+
+func (ui *Accordion) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	KidsDraw(dui, self, ui.Kids, ui.size, ui.Background, img, orig, m, force)
+}
+
+func (ui *Accordion) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	open := ui.openStates()
+	r = KidsMouse(dui, self, ui.Kids, m, origM, orig)
+	ui.closeOthers(dui, self, open, &r)
+	return
+}
+
+func (ui *Accordion) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	open := ui.openStates()
+	r = KidsKey(dui, self, ui.Kids, k, m, orig)
+	ui.closeOthers(dui, self, open, &r)
+	return
+}
+
+func (ui *Accordion) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	return KidsFirstFocus(dui, self, ui.Kids)
+}
+
+func (ui *Accordion) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	return KidsFocus(dui, self, ui.Kids, o)
+}
+
+func (ui *Accordion) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return KidsMark(self, ui.Kids, o, forLayout)
+}
+
+func (ui *Accordion) Print(self *Kid, indent int) {
+	PrintUI("Accordion", self, indent)
+	KidsPrint(ui.Kids, indent+1)
+}
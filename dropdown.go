@@ -0,0 +1,517 @@
+package duit
+
+import (
+	"image"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"9fans.net/go/draw"
+)
+
+// dropdownRows is the number of values shown at a time in the list of a Dropdown. The list scrolls for more values.
+const dropdownRows = 10
+
+// Dropdown shows the selected value of a list of values, and opens the list for selecting another value on click or enter.
+// The list is shown in an overlay, and scrolls if there are many values.
+// Typing the start of a value selects it, or highlights it in the open list. Typing the same first letter again moves to the next value starting with it.
+//
+// With Editable set, the dropdown is a combobox: a field for typing any text, with a button to open the list.
+// Picking a value puts its text in the field. Typed text selects the value with exactly that text, if any.
+//
+// Keys:
+//	enter, open the list, or select the highlighted value and close the list
+//	escape, close the list
+//	arrow up, select or highlight the previous value
+//	arrow down, select or highlight the next value
+//	page up and page down, move the highlight by a list's height
+//	home and end, highlight the first or last value
+type Dropdown struct {
+	Values      []*ListValue                                 // Values to choose from. Their Selected field is not used, see Selected.
+	Selected    int                                          // Index of the selected value in Values, or -1 for none. Call MarkDraw after changing.
+	Editable    bool                                         // Whether any text can be typed, making this a combobox.
+	Text        string                                       // Text in the field of an editable dropdown. Set to the text of a selected value. Call MarkDraw after changing.
+	Placeholder string                                       // Shown in lighter color when no value is selected, or when Text is empty for an editable dropdown.
+	Disabled    bool                                         // If disabled, mouse and key input have no effect.
+	Font        *draw.Font                                   `json:"-"` // For drawing the values.
+	Changed     func(index int, value interface{}) (e Event) `json:"-"` // Called after a value was selected, with its index and ListValue.Value. For an editable dropdown, also called after typing, with index -1 and a nil value if the text is not one of the values.
+
+	size      image.Point
+	buttonR   image.Rectangle // For opening the list.
+	anchor    image.Rectangle // Window coordinates, for placing the list.
+	m         draw.Mouse
+	field     Field // For an editable dropdown.
+	fieldKid  Kid
+	fieldText string // Text in field, to detect changes to Text.
+	open      bool
+	overlay   Overlay
+	scroll    Scroll
+	list      List
+	highlight int       // Index of the value highlighted in the open list, or -1.
+	typed     string    // Recently typed text, for selecting a value by typing.
+	typedTime time.Time // When typed was last extended.
+}
+
+var _ UI = &Dropdown{}
+
+func (ui *Dropdown) font(dui *DUI) *draw.Font {
+	return dui.Font(ui.Font)
+}
+
+// space returns the padding and border around the text, as in a Field.
+func (ui *Dropdown) space(dui *DUI) image.Point {
+	return pt(ui.font(dui).Height/4 + 1)
+}
+
+func (ui *Dropdown) buttonWidth(dui *DUI) int {
+	return ui.font(dui).Height
+}
+
+// selectedText returns the text of the selected value, or the empty string.
+func (ui *Dropdown) selectedText() string {
+	if ui.Selected >= 0 && ui.Selected < len(ui.Values) {
+		return ui.Values[ui.Selected].Text
+	}
+	return ""
+}
+
+// ensureField sets up the field of an editable dropdown, and puts a changed Text in it.
+func (ui *Dropdown) ensureField() {
+	if ui.fieldKid.UI == nil {
+		ui.fieldKid.UI = &ui.field
+		ui.field.Changed = ui.fieldChanged
+		ui.fieldText = ui.Text
+		ui.field.Text = ui.Text
+	}
+	ui.field.Font = ui.Font
+	ui.field.Disabled = ui.Disabled
+	ui.field.Placeholder = ui.Placeholder
+	if ui.Text != ui.fieldText {
+		ui.field.Text = ui.Text
+		ui.fieldText = ui.Text
+	}
+}
+
+// fieldChanged updates Text and Selected after typing in an editable dropdown, and calls Changed.
+func (ui *Dropdown) fieldChanged(text string) (e Event) {
+	ui.Text = text
+	ui.fieldText = text
+	ui.Selected = -1
+	for i, v := range ui.Values {
+		if v.Text == text {
+			ui.Selected = i
+			break
+		}
+	}
+	if ui.Changed != nil {
+		var value interface{}
+		if ui.Selected >= 0 {
+			value = ui.Values[ui.Selected].Value
+		}
+		e = ui.Changed(ui.Selected, value)
+	}
+	return
+}
+
+func (ui *Dropdown) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+
+	width := minimum(sizeAvail.X, ui.Measure(dui, self, sizeAvail).Pref.X)
+	bw := ui.buttonWidth(dui)
+	if ui.Editable {
+		ui.ensureField()
+		ui.field.Layout(dui, &ui.fieldKid, image.Pt(width-bw, sizeAvail.Y), force)
+		ui.size = image.Pt(width, ui.fieldKid.R.Dy())
+		if dui.RTL {
+			ui.fieldKid.R = ui.fieldKid.R.Add(image.Pt(bw, 0))
+		}
+	} else {
+		ui.size = image.Pt(width, ui.font(dui).Height+2*ui.space(dui).Y)
+	}
+	ui.buttonR = image.Rect(width-bw, 0, width, ui.size.Y)
+	if dui.RTL {
+		ui.buttonR = image.Rect(0, 0, bw, ui.size.Y)
+	}
+	self.R = rect(ui.size)
+}
+
+// Measure returns room for the widest value and the placeholder as preferred and maximum size, and a few characters as minimum.
+func (ui *Dropdown) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	font := ui.font(dui)
+	space := ui.space(dui)
+	dx := font.StringWidth(ui.Placeholder)
+	for _, v := range ui.Values {
+		dx = maximum(dx, font.StringWidth(v.Text))
+	}
+	extra := 2*space.X + ui.buttonWidth(dui)
+	if ui.Editable {
+		// room for the cursor
+		extra += font.StringWidth("0")
+	}
+	dy := font.Height + 2*space.Y
+	min := image.Pt(font.StringWidth("mmm")+extra, dy)
+	size := image.Pt(maximum(min.X, dx+extra), dy)
+	return Sizes{min, size, size}
+}
+
+func (ui *Dropdown) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+
+	if ui.size.X <= 0 || ui.size.Y <= 0 {
+		return
+	}
+	font := ui.font(dui)
+	r := rect(ui.size)
+	colors := dui.Regular.Normal
+	if ui.Disabled {
+		colors = dui.Disabled
+	} else if m.In(r) || ui.open {
+		colors = dui.Regular.Hover
+	}
+
+	if ui.Editable {
+		ui.ensureField()
+		mm := m
+		mm.Point = mm.Point.Sub(ui.fieldKid.R.Min)
+		ui.field.Draw(dui, &ui.fieldKid, img, orig.Add(ui.fieldKid.R.Min), mm, true)
+		ui.fieldKid.Draw = Clean
+		r = ui.buttonR
+	}
+
+	r = r.Add(orig)
+	img.Draw(r.Inset(1), colors.Background, nil, image.ZP)
+	drawRoundedBorder(img, r, colors.Border)
+
+	buttonR := ui.buttonR.Add(orig)
+	s := "▾"
+	p := buttonR.Min.Add(image.Pt((buttonR.Dx()-font.StringWidth(s))/2, (buttonR.Dy()-font.Height)/2))
+	img.String(p, colors.Text, image.ZP, font, s)
+
+	if ui.Editable {
+		return
+	}
+	text := ui.selectedText()
+	textColor := colors.Text
+	if text == "" {
+		text = ui.Placeholder
+		textColor = dui.Placeholder.Text
+	}
+	space := ui.space(dui)
+	textR := rect(ui.size).Add(orig).Inset(1)
+	if dui.RTL {
+		textR.Min.X = buttonR.Max.X
+	} else {
+		textR.Max.X = buttonR.Min.X
+	}
+	p = textR.Min.Add(image.Pt(space.X-1, space.Y-1))
+	drawBidiString(dui, img, p, textR.Dx()-2*(space.X-1), textColor, font, text)
+}
+
+// openList shows the values in an overlay below the dropdown, with the selected value highlighted.
+func (ui *Dropdown) openList(dui *DUI) {
+	if len(ui.Values) == 0 {
+		return
+	}
+	if ui.overlay.UI == nil {
+		ui.list.Click = func(index int, m draw.Mouse) (e Event) {
+			if m.Buttons == Button1 {
+				e = ui.choose(dui, index)
+				ui.markSelf(dui, e)
+				e.NeedLayout = false
+				e.NeedDraw = false
+			}
+			e.Consumed = true
+			return
+		}
+		ui.list.Keys = func(k rune, m draw.Mouse) (e Event) {
+			e = ui.listKey(dui, k)
+			ui.markSelf(dui, e)
+			e.NeedLayout = false
+			e.NeedDraw = false
+			return
+		}
+		ui.scroll.Kid = Kid{UI: &ui.list}
+		ui.overlay.UI = &ui.scroll
+		ui.overlay.Dismiss = func() {
+			ui.closeList(dui)
+			dui.MarkDraw(ui)
+		}
+	}
+	ui.list.Font = ui.Font
+	ui.list.Values = make([]*ListValue, len(ui.Values))
+	for i, v := range ui.Values {
+		ui.list.Values[i] = &ListValue{Text: v.Text, Value: v.Value}
+	}
+	// Scroll.Height is in lowDPI pixels
+	scale := dui.Scale(1)
+	ui.scroll.Height = (minimum(dropdownRows, len(ui.Values))*ui.list.rowHeight(dui) + scale - 1) / scale
+	ui.scroll.offset = 0
+	ui.open = true
+	ui.overlay.Anchor = ui.anchor
+	dui.OpenOverlay(&ui.overlay)
+	ui.setHighlight(dui, ui.Selected)
+}
+
+// closeList closes the list, if it is open.
+func (ui *Dropdown) closeList(dui *DUI) {
+	if !ui.open {
+		return
+	}
+	ui.open = false
+	dui.CloseOverlay(&ui.overlay)
+}
+
+// markSelf marks the dropdown for layout or draw, as requested by e.
+func (ui *Dropdown) markSelf(dui *DUI, e Event) {
+	if e.NeedLayout {
+		dui.MarkLayout(ui)
+	} else if e.NeedDraw {
+		dui.MarkDraw(ui)
+	}
+}
+
+// setHighlight highlights the value at index in the open list, and scrolls it into view.
+func (ui *Dropdown) setHighlight(dui *DUI, index int) {
+	if index < -1 || index >= len(ui.list.Values) {
+		return
+	}
+	if ui.highlight >= 0 && ui.highlight < len(ui.list.Values) {
+		ui.list.Values[ui.highlight].Selected = false
+	}
+	ui.highlight = index
+	if index < 0 {
+		dui.MarkDraw(&ui.list)
+		return
+	}
+	ui.list.Values[index].Selected = true
+	rowHeight := ui.list.rowHeight(dui)
+	y := index * rowHeight
+	height := ui.scroll.childR.Dy()
+	if height == 0 {
+		// not yet laid out
+		height = minimum(dropdownRows, len(ui.Values)) * rowHeight
+	}
+	if y < ui.scroll.offset {
+		ui.scroll.offset = y
+	} else if y+rowHeight > ui.scroll.offset+height {
+		ui.scroll.offset = y + rowHeight - height
+	}
+	dui.MarkDraw(&ui.scroll)
+	ui.scroll.Kid.Draw = Dirty
+}
+
+// typeSelect adds k to the recently typed text, and returns the index of the first value starting with that text, searching from index from, or -1.
+// If only one character was typed, the search starts after from, for moving to the next value starting with that character.
+func (ui *Dropdown) typeSelect(k rune, from int) int {
+	now := time.Now()
+	if now.Sub(ui.typedTime) > time.Second {
+		ui.typed = ""
+	}
+	ui.typedTime = now
+	ui.typed += string(k)
+	if utf8.RuneCountInString(ui.typed) == 1 || from < 0 {
+		from++
+	}
+	prefix := strings.ToLower(ui.typed)
+	n := len(ui.Values)
+	for i := 0; i < n; i++ {
+		j := (from + i) % n
+		if strings.HasPrefix(strings.ToLower(ui.Values[j].Text), prefix) {
+			return j
+		}
+	}
+	return -1
+}
+
+// listKey handles a key while the list is open. The returned event is consumed if the key was handled.
+func (ui *Dropdown) listKey(dui *DUI, k rune) (e Event) {
+	n := len(ui.list.Values)
+	index := ui.highlight
+	switch k {
+	case '\n':
+		if ui.highlight < 0 {
+			ui.closeList(dui)
+			e.NeedDraw = true
+		} else {
+			e = ui.choose(dui, ui.highlight)
+		}
+		e.Consumed = true
+		return
+	case draw.KeyEscape:
+		ui.closeList(dui)
+		e.NeedDraw = true
+		e.Consumed = true
+		return
+	case draw.KeyUp:
+		index = maximum(0, index-1)
+	case draw.KeyDown:
+		index = minimum(index+1, n-1)
+	case draw.KeyPageUp:
+		index = maximum(0, index-dropdownRows)
+	case draw.KeyPageDown:
+		index = minimum(maximum(0, index)+dropdownRows, n-1)
+	case draw.KeyHome:
+		index = 0
+	case draw.KeyEnd:
+		index = n - 1
+	default:
+		if ui.Editable || !unicode.IsPrint(k) {
+			return
+		}
+		if i := ui.typeSelect(k, ui.highlight); i >= 0 {
+			index = i
+		}
+	}
+	ui.setHighlight(dui, index)
+	e.Consumed = true
+	return
+}
+
+// choose selects the value at index, closes the list, and calls Changed.
+func (ui *Dropdown) choose(dui *DUI, index int) (e Event) {
+	ui.closeList(dui)
+	ui.Selected = index
+	if ui.Editable {
+		ui.ensureField()
+		ui.Text = ui.Values[index].Text
+		ui.fieldText = ui.Text
+		ui.field.Text = ui.Text
+		ui.field.Cursor1 = 0
+		ui.field.SelectionStart1 = 0
+	}
+	if ui.Changed != nil {
+		e = ui.Changed(index, ui.Values[index].Value)
+	}
+	e.NeedDraw = true
+	return
+}
+
+// fieldResult propagates the layout and draw state of the field.
+func (ui *Dropdown) fieldResult(self *Kid) {
+	if ui.fieldKid.Layout != Clean {
+		self.Layout = Dirty
+	}
+	if ui.fieldKid.Draw != Clean {
+		self.Draw = Dirty
+	}
+}
+
+// result applies event e from the dropdown's own handling to r.
+func (ui *Dropdown) result(self *Kid, r *Result, e Event) {
+	if e.NeedDraw {
+		self.Draw = Dirty
+	}
+	e.NeedDraw = false
+	propagateEvent(self, r, e)
+}
+
+func (ui *Dropdown) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	if ui.Disabled {
+		return
+	}
+	ui.anchor = rect(ui.size).Add(orig)
+	prevM := ui.m
+	ui.m = m
+	if prevM.In(ui.buttonR) != m.In(ui.buttonR) || prevM.In(rect(ui.size)) != m.In(rect(ui.size)) {
+		self.Draw = Dirty
+	}
+	if ui.Editable && !origM.In(ui.buttonR) {
+		ui.ensureField()
+		m.Point = m.Point.Sub(ui.fieldKid.R.Min)
+		origM.Point = origM.Point.Sub(ui.fieldKid.R.Min)
+		r = ui.field.Mouse(dui, &ui.fieldKid, m, origM, orig.Add(ui.fieldKid.R.Min))
+		ui.fieldResult(self)
+		return
+	}
+	if prevM.Buttons == 0 && m.Buttons == Button1 && m.In(rect(ui.size)) {
+		if ui.open {
+			ui.closeList(dui)
+		} else {
+			ui.openList(dui)
+		}
+		self.Draw = Dirty
+		r.Consumed = true
+	}
+	return
+}
+
+func (ui *Dropdown) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	if ui.Disabled || !m.In(rect(ui.size)) {
+		return
+	}
+	ui.anchor = rect(ui.size).Add(orig)
+	if ui.open {
+		ui.result(self, &r, ui.listKey(dui, k))
+		if r.Consumed {
+			return
+		}
+	} else {
+		index := -1
+		switch k {
+		case '\n':
+			ui.openList(dui)
+			self.Draw = Dirty
+			r.Consumed = true
+			return
+		case draw.KeyUp:
+			index = maximum(0, ui.Selected-1)
+		case draw.KeyDown:
+			index = minimum(ui.Selected+1, len(ui.Values)-1)
+		default:
+			if !ui.Editable && unicode.IsPrint(k) {
+				index = ui.typeSelect(k, ui.Selected)
+				r.Consumed = true
+			}
+		}
+		if index >= 0 && index != ui.Selected {
+			ui.result(self, &r, ui.choose(dui, index))
+			r.Consumed = true
+			return
+		}
+		if r.Consumed {
+			return
+		}
+	}
+	if !ui.Editable {
+		return
+	}
+	ui.ensureField()
+	m.Point = m.Point.Sub(ui.fieldKid.R.Min)
+	if !m.In(rect(ui.field.size)) {
+		// on the button, let the field handle typing anyway
+		m.Point = image.Pt(1, 1)
+	}
+	r = ui.field.Key(dui, &ui.fieldKid, k, m, orig.Add(ui.fieldKid.R.Min))
+	ui.fieldResult(self)
+	return
+}
+
+func (ui *Dropdown) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	if ui.Editable {
+		ui.ensureField()
+		p := ui.field.FirstFocus(dui, &ui.fieldKid)
+		if p == nil {
+			return nil
+		}
+		pp := p.Add(ui.fieldKid.R.Min)
+		return &pp
+	}
+	p := ui.space(dui)
+	return &p
+}
+
+func (ui *Dropdown) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if o != ui {
+		return nil
+	}
+	return ui.FirstFocus(dui, self)
+}
+
+func (ui *Dropdown) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return self.Mark(o, forLayout)
+}
+
+func (ui *Dropdown) Print(self *Kid, indent int) {
+	PrintUI("Dropdown", self, indent)
+}
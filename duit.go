@@ -75,6 +75,13 @@ type Colorset struct {
 	Normal, Hover Colors
 }
 
+// LinkColors are the text colors of links, for example in Link and RichText.
+type LinkColors struct {
+	Normal  *draw.Image `json:"-"` // Link that has not been visited.
+	Hover   *draw.Image `json:"-"` // Link under the mouse.
+	Visited *draw.Image `json:"-"` // Link that has been followed.
+}
+
 // InputType presents the type of an input event.
 type InputType byte
 
@@ -142,6 +149,12 @@ type DUI struct {
 	// Gutter color.
 	Gutter *draw.Image
 
+	// Link colors.
+	Link LinkColors
+
+	// Right-to-left layout. Box and Grid mirror the placement of their kids, and text without characters with a strong direction is right-to-left. Call MarkLayout(nil) after changing.
+	RTL bool
+
 	Debug       bool          // Log errors interesting to developers.
 	DebugDraw   int           // If 1, UIs print each draw they do. If 2, UIs print all calls to their Draw function. Cycle through 0-2 with F7.
 	DebugLayout int           // If 1, UIs print each Layout they do. If 2, UIs print all calls to their Layout function. Cycle through 0-2 with F8.
@@ -160,6 +173,8 @@ type DUI struct {
 	mouse                   draw.Mouse             // Latest mouse event.
 	origMouse               draw.Mouse             // Mouse that determines where new mouse events are delivered. Unchanged while button is pressed.
 	lastMouseUI             UI                     // Where last mouse was delivered
+	cursorUI                UI                     // UI that changed the mouse cursor, nil for the default cursor.
+	cursor                  *draw.Cursor           // Cursor set by cursorUI.
 	logInputs               bool                   // Print all input events. Toggled with F1.
 	logTiming               bool                   // Print timings for layout and draw.
 	drawDebug               bool                   // For draw.Display.SetDebug.
@@ -168,6 +183,9 @@ type DUI struct {
 	name                    string                 // Program name, also used for storing dimensions file.
 	settings                map[string][]byte      // Indexed by Kid.ID, holds JSON. Helps store per-UI state, such as Split sizes.
 	settingsWriters         map[string]*time.Timer // Delayed writes of settings.
+	measureGen              int                    // Incremented on layout and input, invalidates results cached by KidMeasure.
+	overlays                []*Overlay             // Opened with OpenOverlay, drawn above Top in this order.
+	grab                    *Overlay               // Gets mouse events while a button is held and the mouse is not in an overlay, for a menu opened by a button press.
 }
 
 // DUIOpts exist mostly to make it easier to add changes in the future, and keep the NewDUI function signature sane.
@@ -343,6 +361,12 @@ func NewDUI(name string, opts *DUIOpts) (dui *DUI, err error) {
 
 		Gutter: makeColor(0xbbbbbbff),
 
+		Link: LinkColors{
+			Normal:  makeColor(0x007bffff),
+			Hover:   makeColor(0x0056b3ff),
+			Visited: makeColor(0x6f42c1ff),
+		},
+
 		CommandMode: makeColor(0x3272dcff),
 		VisualMode:  makeColor(0x5cb85cff),
 
@@ -400,36 +424,52 @@ func (d *DUI) Render() {
 // Layout the entire UI tree, as necessary.
 // Only UIs marked as requiring a layout are actually layed out.
 // UIs that receive a layout are marked as requiring a draw.
+// Overlays are laid out after the Top UI.
 func (d *DUI) Layout() {
-	if d.Top.Layout == Clean {
-		return
-	}
-	var t0 time.Time
-	if d.logTiming {
-		t0 = time.Now()
+	force := d.Top.Layout == Dirty
+	if d.Top.Layout != Clean {
+		d.measureGen++
+		var t0 time.Time
+		if d.logTiming {
+			t0 = time.Now()
+		}
+		d.Top.UI.Layout(d, &d.Top, d.Display.ScreenImage.R.Size(), force)
+		d.Top.Layout = Clean
+		if d.logTiming {
+			log.Printf("duit: time layout: %d µs\n", time.Now().Sub(t0)/time.Microsecond)
+		}
 	}
-	d.Top.UI.Layout(d, &d.Top, d.Display.ScreenImage.R.Size(), d.Top.Layout == Dirty)
-	d.Top.Layout = Clean
-	if d.logTiming {
-		log.Printf("duit: time layout: %d µs\n", time.Now().Sub(t0)/time.Microsecond)
+	for _, o := range d.overlays {
+		d.layoutOverlay(o, force)
 	}
 }
 
 // Draw the entire UI tree, as necessary.
 // Only UIs marked as requiring a draw are actually drawn, and their children.
+// Overlays are drawn after the Top UI. If any part of the Top UI is drawn, all overlays are drawn again.
 func (d *DUI) Draw() {
-	if d.Top.Draw == Clean {
+	dirty := d.Top.Draw != Clean
+	for _, o := range d.overlays {
+		dirty = dirty || o.Draw != Clean
+	}
+	if !dirty {
 		return
 	}
 	var t0, t1 time.Time
 	if d.logTiming {
 		t0 = time.Now()
 	}
+	force := d.Top.Draw != Clean
 	if d.Top.Draw == Dirty {
 		d.Display.ScreenImage.Draw(d.Display.ScreenImage.R, d.Background, nil, image.ZP)
 	}
-	d.Top.UI.Draw(d, &d.Top, d.Display.ScreenImage, image.ZP, d.mouse, d.Top.Draw == Dirty)
-	d.Top.Draw = Clean
+	if d.Top.Draw != Clean {
+		d.Top.UI.Draw(d, &d.Top, d.Display.ScreenImage, image.ZP, d.mouse, d.Top.Draw == Dirty)
+		d.Top.Draw = Clean
+	}
+	for _, o := range d.overlays {
+		d.drawOverlay(o, force)
+	}
 	if d.logTiming {
 		t1 = time.Now()
 	}
@@ -447,7 +487,7 @@ func (d *DUI) MarkLayout(ui UI) {
 	if ui == nil {
 		d.Top.Layout = Dirty
 	} else {
-		if !d.Top.UI.Mark(&d.Top, ui, true) {
+		if !d.mark(ui, true) {
 			log.Printf("duit: marklayout %T: nothing marked\n", ui)
 		}
 	}
@@ -458,7 +498,7 @@ func (d *DUI) MarkDraw(ui UI) {
 	if ui == nil {
 		d.Top.Draw = Dirty
 	} else {
-		if !d.Top.UI.Mark(&d.Top, ui, false) {
+		if !d.mark(ui, false) {
 			log.Printf("duit: markdraw %T: nothing marked\n", ui)
 		}
 	}
@@ -473,7 +513,7 @@ func (d *DUI) apply(r Result) {
 			d.mouse.Point = *r.Warp
 			d.mouse.Buttons = 0
 			d.origMouse = d.mouse
-			r = d.Top.UI.Mouse(d, &d.Top, d.mouse, d.origMouse, image.ZP)
+			r = d.mouseOverlays(d.mouse, d.origMouse)
 		}
 	}
 	if r.Hit != d.lastMouseUI {
@@ -491,12 +531,19 @@ func (d *DUI) apply(r Result) {
 
 // Mouse delivers a mouse event to the UI tree.
 // Mouse is typically called by Input.
+// A button press outside the overlays dismisses them.
 func (d *DUI) Mouse(m draw.Mouse) {
+	if m.Buttons != 0 && d.origMouse.Buttons == 0 {
+		d.dismissOverlays(m.Point)
+	}
 	if m.Buttons == 0 || d.origMouse.Buttons == 0 {
 		d.origMouse = m
 	}
 	d.mouse = m
-	r := d.Top.UI.Mouse(d, &d.Top, m, d.origMouse, image.ZP)
+	r := d.mouseOverlays(m, d.origMouse)
+	if m.Buttons == 0 {
+		d.grab = nil
+	}
 	d.apply(r)
 }
 
@@ -571,11 +618,24 @@ func (d *DUI) Key(k rune) {
 		}
 		return
 	}
-	r := d.Top.UI.Key(d, &d.Top, k, d.mouse, image.ZP)
+	r := d.keyOverlays(k)
 	if !r.Consumed {
 		switch k {
+		case draw.KeyEscape:
+			if len(d.overlays) > 0 {
+				d.dismiss(d.overlays[len(d.overlays)-1])
+				r.Consumed = true
+			}
 		case '\t':
-			first := d.Top.UI.FirstFocus(d, &d.Top)
+			var first *image.Point
+			if o := d.modal(); o != nil {
+				if p := o.UI.FirstFocus(d, &o.Kid); p != nil {
+					pp := p.Add(o.orig())
+					first = &pp
+				}
+			} else {
+				first = d.Top.UI.FirstFocus(d, &d.Top)
+			}
 			if first != nil {
 				r.Warp = first
 				r.Consumed = true
@@ -594,6 +654,15 @@ func (d *DUI) Key(k rune) {
 func (d *DUI) Focus(ui UI) {
 	d.Render()
 	p := d.Top.UI.Focus(d, &d.Top, ui)
+	for _, o := range d.overlays {
+		if p != nil {
+			break
+		}
+		if p = o.UI.Focus(d, &o.Kid, ui); p != nil {
+			pp := p.Add(o.orig())
+			p = &pp
+		}
+	}
 	if p == nil {
 		log.Printf("duit: focus: no ui found for %T %p\n", ui, ui)
 		return
@@ -606,7 +675,7 @@ func (d *DUI) Focus(ui UI) {
 	d.mouse.Point = *p
 	d.mouse.Buttons = 0
 	d.origMouse = d.mouse
-	r := d.Top.UI.Mouse(d, &d.Top, d.mouse, d.origMouse, image.ZP)
+	r := d.mouseOverlays(d.mouse, d.origMouse)
 	d.apply(r)
 }
 
@@ -667,6 +736,7 @@ func (d *DUI) Scale(n int) int {
 // Func calls the function.
 // Error implies an error from devdraw and terminates the program.
 func (d *DUI) Input(e Input) {
+	d.measureGen++
 	switch e.Type {
 	case InputMouse:
 		if d.logInputs {
@@ -722,6 +792,20 @@ func (d *DUI) Font(font *draw.Font) *draw.Font {
 	return d.Display.DefaultFont
 }
 
+// setCursor changes the mouse cursor on behalf of UI o. A nil cursor restores the default cursor, but only if o changed the current cursor.
+// This lets a UI that the mouse just left restore the cursor during its next draw, without undoing the cursor of a UI the mouse moved to.
+func (d *DUI) setCursor(o UI, c *draw.Cursor) {
+	if c == nil && d.cursorUI != o || c == d.cursor && o == d.cursorUI {
+		return
+	}
+	d.cursorUI = o
+	if c == nil {
+		d.cursorUI = nil
+	}
+	d.cursor = c
+	d.Display.SetCursor(c)
+}
+
 // WriteSnarf writes the snarf buffer and logs an error in case of failure.
 func (d *DUI) WriteSnarf(buf []byte) {
 	err := d.Display.WriteSnarf(buf)
@@ -53,6 +53,8 @@ type Cursor struct {
 // Edit is a text editor inspired by acme, with vi key bindings. An edit has its own scrollbar, unlimited undo. It can read utf-8 encoded files of arbritary length, only reading data when necessary, to display or search.
 //
 // The usual arrow and pageup/pagedown keys can be used for navigation.
+// Lines starting with right-to-left text, such as Hebrew or Arabic, are right-aligned, and the left and right arrow keys move the cursor in visual order.
+// Long lines are wrapped at word boundaries, unless NoWrap is set.
 // Key shortcuts when combined with control:
 //	a, to start of line
 //	e, to end of line
@@ -79,6 +81,7 @@ type Cursor struct {
 // Edit has a vi command and visual mode, entered through the familiar escape key. Not all commands have been implemented yet, Edit does not aim to be feature-complete or a clone of any specific existing vi-clone.
 type Edit struct {
 	NoScrollbar  bool                                       // If set, no scrollbar is shown. Content will still scroll.
+	NoWrap       bool                                       // If set, lines are not wrapped. The text scrolls horizontally to keep the cursor visible.
 	LastSearch   string                                     // If starting with slash, the remainder is interpreted as regexp. used by cmd+[/?] and vi [*nN] commands. Literal text search should start with a space.
 	Error        chan error                                 // If set, errors from Edit (including read errors from underlying files) are sent here. If nil, errors go to dui.Error.
 	Colors       *EditColors                                `json:"-"` // Colors to use for drawing the Edit UI, allows for creating an acme look.
@@ -89,8 +92,13 @@ type Edit struct {
 
 	dui *DUI // Set at beginning of UI interface functions, for not having to pass dui around all the time.
 
-	text   *text  // Wat we are rendering.  Offset & cursors index into this text.
-	offset int64  // Byte offset of first line we draw.
+	singleLine bool                   // For Field, newlines in inserted text are replaced by spaces.
+	accept     func(text []byte) bool // For Field, whether the text after inserting or deleting is allowed. If not, the change is not done and rejected is set.
+	rejected   bool
+
+	text    *text  // Wat we are rendering.  Offset & cursors index into this text.
+	offset  int64  // Byte offset of first line we draw.
+	offsetX int    // Horizontal scroll offset in pixels, with NoWrap.
 	cursor Cursor // Current cursor.
 
 	lastSearchRegexpString string // String used to create lastSearchRegexp.
@@ -186,6 +194,15 @@ func NewEdit(f SeekReaderAt) (ui *Edit, err error) {
 	return
 }
 
+// newTextEdit returns an Edit holding s in memory, for Field and TextArea.
+func newTextEdit(s string) *Edit {
+	ui := &Edit{text: &text{}}
+	if s != "" {
+		ui.text.l = []textPart{stretch([]byte(s))}
+	}
+	return ui
+}
+
 type reverseReader struct {
 	src    io.ReaderAt
 	offset int64 // and going to 0
@@ -446,6 +463,16 @@ func (ui *Edit) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 	self.R = ui.r
 }
 
+// Measure returns the available space as preferred size, and room for a line of a few characters as minimum.
+func (ui *Edit) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	font := dui.Font(ui.Font)
+	min := dui.ScaleSpace(EditPadding).Size().Add(image.Pt(font.StringWidth("mmm"), font.Height))
+	if !ui.NoScrollbar {
+		min.X += dui.Scale(ScrollbarSize)
+	}
+	return Sizes{min, sizeAvail, sizeAvail}
+}
+
 func (ui *Edit) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	ui.dui = dui
 	dui.debugDraw(self)
@@ -468,14 +495,16 @@ func (ui *Edit) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m d
 	img.Draw(ui.textR.Add(orig), colors.Bg, nil, image.ZP)
 
 	font := ui.font()
-	s := ""
-	sdx := 0
 	lineWidth := ui.textR.Dx()
 	line := 0
 
 	size := ui.text.Size()
-	rd := ui.reader(ui.offset, size)
 	lines := ui.textR.Dy() / font.Height
+	if ui.NoWrap {
+		ui.scrollCursorX(lineWidth)
+	} else {
+		ui.offsetX = 0
+	}
 
 	dropNewline := func(s string) string {
 		if s != "" && s[len(s)-1] == '\n' {
@@ -486,16 +515,16 @@ func (ui *Edit) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m d
 
 	c0, c1 := ui.cursor.Ordered()
 	// log.Printf("drawing... c0 %d, c1 %d\n", c0, c1)
-	drawLine := func(offsetEnd int64, eof bool) {
-		origS := s
-
+	drawLine := func(s string, offsetEnd int64, eof bool) {
 		n := len(s)
 		offset := offsetEnd - int64(n)
 		// log.Printf("drawLine, offset %d, offsetEnd %d, n %d\n", offset, offsetEnd, n)
 		p := orig.Add(ui.textR.Min).Add(image.Pt(0, line*font.Height))
-		cursorp := image.Pt(-1, -1)
+		text := dropNewline(s)
+		b := ui.bidi(text)
+		p.X += maximum(0, b.align(lineWidth)) - ui.offsetX
 
-		drawCursor := func(haveSel, cursorAtBegin bool) {
+		drawCursor := func(cursorp image.Point, haveSel, cursorAtBegin bool) {
 			// log.Printf("drawCursor, line %d c0 %d, c1 %d, cursor %d, cursor0 %d, offset %d, offsetEnd %d, s %s, n %d\n", line, c0, c1, ui.cursor, ui.cursor0, offset, offsetEnd, s, n)
 			p0 := cursorp
 			p1 := p0
@@ -515,93 +544,51 @@ func (ui *Edit) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m d
 			}
 		}
 
-		// we draw text before selection
-		if offset < c0 {
-			nn := minimum64(int64(n), c0-offset)
-			// log.Printf("drawing %d before selection\n", nn)
-			pp := img.String(p, colors.Fg, image.ZP, font, dropNewline(s[:nn]))
-			p.X = pp.X
-			s = s[nn:]
-			offset += nn
-		}
-
-		if offset == ui.cursor.Cur && ui.cursor.Cur == c0 && c0 != c1 && offset < offsetEnd {
-			//log.Printf("cursor A, offset %d, ui.cursor %d, c1 %d, offsetEnd %d, size %d\n", offset, ui.cursor.Cur, c1, offsetEnd, size)
-			cursorp = p
-		}
-
-		// then selected text
-		haveSelection := offset >= c0 && offset < c1 && c1-c0 > 0 && offset < offsetEnd
+		// selection, with offsets relative to this line
+		haveSelection := c0 < c1 && c0 < offsetEnd && c1 > offset
+		selStart := int(maximum64(0, c0-offset))
+		selEnd := int(minimum64(int64(n), c1-offset))
 		if haveSelection {
-			nn := minimum64(c1, offsetEnd) - offset
-			// log.Printf("drawing %d as selection\n", nn)
-			sels := s[:nn]
-			toEnd := sels[len(sels)-1] == '\n'
-			if toEnd {
-				sels = sels[:len(sels)-1]
-			}
-			s = s[nn:]
-			offset += nn
-			if !(offset >= c1 && offsetEnd > offset) {
-				toEnd = true
+			for _, selR := range b.selectionRects(selStart, selEnd) {
+				selR.Max.Y = font.Height
+				img.Draw(selR.Add(p), colors.SelBg, nil, image.ZP)
 			}
-			seldx := font.StringWidth(sels)
-			selR := rect(image.Pt(seldx, font.Height)).Add(p)
-			if toEnd {
-				selR.Max.X = ui.textR.Max.X + orig.X
+			// selection continuing on the next line is drawn up to the edge
+			if c1 >= offsetEnd {
+				selR := rect(image.Pt(0, font.Height)).Add(p)
+				if b.rtlPar {
+					selR.Min.X = ui.textR.Min.X + orig.X
+				} else {
+					selR.Min.X += b.width
+					selR.Max.X = ui.textR.Max.X + orig.X
+				}
+				img.Draw(selR, colors.SelBg, nil, image.ZP)
 			}
-			img.Draw(selR, colors.SelBg, nil, image.ZP)
-			pp := img.String(p, colors.SelFg, image.ZP, font, sels)
-			p.X = pp.X
-		}
-		if offset == ui.cursor.Cur && ui.cursor.Cur == c1 && (offset < offsetEnd || (offset == size && eof)) {
-			// log.Printf("cursor B, offset %d, ui.cursor %d, c1 %d, offsetEnd %d, size %d\n", offset, ui.cursor.Cur, c1, offsetEnd, size)
-			cursorp = p
-		}
-		if cursorp.X >= 0 {
-			drawCursor(haveSelection, ui.cursor.Cur < ui.cursor.Start)
 		}
+		b.draw(img, p, font, colors.Fg, selStart, selEnd, colors.SelFg)
 
-		// then text after cursor
-		if offset >= c1 && offsetEnd > offset {
-			nn := int(offsetEnd - offset)
-			// log.Printf("drawing %d after selection\n", nn)
-			pp := img.String(p, colors.Fg, image.ZP, font, dropNewline(s))
-			p.X = pp.X
-			s = s[nn:]
-			offset += int64(nn)
-		}
-		if s != "" || offset != offsetEnd {
-			panic(fmt.Sprintf("bug in drawLine, s %v, offset %d, offsetEnd %d, c0 %d c1 %d, line %d, sdx %d, origS %s", s, offset, offsetEnd, c0, c1, line, sdx, origS))
+		cur := ui.cursor.Cur
+		if cur >= offset && cur < offsetEnd || cur == size && eof {
+			cursorp := p.Add(image.Pt(b.cursorX(int(cur-offset)), 0))
+			drawCursor(cursorp, haveSelection, ui.cursor.Cur < ui.cursor.Start)
 		}
 
-		s = ""
-		sdx = 0
 		line++
 	}
 
+	// text may extend beyond the edges, e.g. spaces at the end of a wrapped line, or lines with NoWrap
+	clipr := img.Clipr
+	img.ReplClipr(img.Repl, clipr.Intersect(ui.textR.Add(orig)))
+	end := ui.offset
 	for line < lines {
-		c, eof := rd.Peek()
+		s, offsetEnd, eof := ui.nextLine(end, lineWidth)
+		end = offsetEnd
+		drawLine(s, end, eof)
 		if eof {
-			drawLine(rd.Offset(), eof)
 			break
 		}
-		if c == '\n' {
-			s += string(rd.Get())
-			drawLine(rd.Offset(), false)
-			continue
-		}
-		dx := font.StringWidth(string(c))
-		if sdx+dx < lineWidth {
-			sdx += dx
-			s += string(rd.Get())
-			continue
-		}
-		drawLine(rd.Offset(), false)
-		rd.Get()
-		s = string(c)
-		sdx = dx
 	}
+	img.ReplClipr(img.Repl, clipr)
 
 	barHover := m.In(ui.barR)
 	bg := colors.ScrollBg
@@ -615,7 +602,7 @@ func (ui *Edit) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m d
 		ui.barActiveR = ui.barR
 	} else {
 		ui.barActiveR.Min.Y = int(int64(ui.barR.Dy()) * ui.offset / size)
-		ui.barActiveR.Max.Y = int(int64(ui.barR.Dy()) * rd.Offset() / size)
+		ui.barActiveR.Max.Y = int(int64(ui.barR.Dy()) * end / size)
 	}
 	if ui.barR.Dx() > 0 {
 		barActiveR := ui.barActiveR.Add(orig)
@@ -846,36 +833,20 @@ func (ui *Edit) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig
 				x += dx
 			}
 		}
-		rd := ui.reader(ui.offset, ui.text.Size())
-		x := 0
-		mX := m.X
-		for {
-			c, eof := rd.Peek()
+		start := ui.offset
+		for ; line > 0; line-- {
+			_, end, eof := ui.nextLine(start, xmax)
 			if eof {
-				break
-			}
-			if c == '\n' {
-				if line == 0 {
-					break
-				}
-				rd.Get()
-				line--
-				x = 0
-				continue
-			}
-			dx := font.StringWidth(string(c))
-			if line == 0 && (x+2*dx/3 > mX || x+dx > xmax) {
-				break
-			}
-			x += dx
-			if x > xmax {
-				line--
-				x = 0
-			} else {
-				rd.Get()
+				return end
 			}
+			start = end
 		}
-		return rd.Offset()
+
+		// find the offset in the visual order of the line
+		s, _, _ := ui.nextLine(start, xmax)
+		s = strings.TrimSuffix(s, "\n")
+		b := ui.bidi(s)
+		return start + int64(b.offsetAt(m.X+ui.offsetX-maximum(0, b.align(xmax))))
 	}
 	if m.Buttons^om.Buttons != 0 && ui.mode != modeInsert {
 		ui.mode = modeInsert
@@ -1146,6 +1117,13 @@ func (ui *Edit) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point)
 		}
 	}
 
+	ui.key(dui, self, k, orig, &r)
+	return
+}
+
+// key handles key k, after Key has checked where the mouse is and called the Keys function.
+// Field uses it for editing its text.
+func (ui *Edit) key(dui *DUI, self *Kid, k rune, orig image.Point, r *Result) {
 	r.Consumed = true
 	self.Draw = Dirty
 
@@ -1155,15 +1133,15 @@ func (ui *Edit) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point)
 	switch ui.mode {
 	case modeCommand:
 		ui.command += string(k)
-		ui.commandKey(dui, &r)
+		ui.commandKey(dui, r)
 		return
 	case modeVisual:
 		ui.visual += string(k)
-		ui.visualKey(dui, false, &r)
+		ui.visualKey(dui, false, r)
 		return
 	case modeVisualLine:
 		ui.visual += string(k)
-		ui.visualKey(dui, true, &r)
+		ui.visualKey(dui, true, r)
 		return
 	}
 
@@ -1184,13 +1162,11 @@ func (ui *Edit) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point)
 	case draw.KeyDown:
 		ui.scroll(lines/5, self)
 	case draw.KeyLeft:
-		br.TryGet()
-		ui.cursor.Cur = br.Offset()
+		ui.cursor.Cur = ui.moveHorizontal(c0, -1)
 		ui.cursor.Start = ui.cursor.Cur
 		ui.ScrollCursor(dui)
 	case draw.KeyRight:
-		fr.TryGet()
-		ui.cursor.Cur = fr.Offset()
+		ui.cursor.Cur = ui.moveHorizontal(c1, 1)
 		ui.cursor.Start = ui.cursor.Cur
 		ui.ScrollCursor(dui)
 	case Ctrl & 'a':
@@ -1317,8 +1293,106 @@ func (ui *Edit) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point)
 		c0 = maximum64(0, c0)
 		c1 = minimum64(c1, ui.text.Size())
 	}
+}
 
-	return
+// nextLine returns the line starting at offset as it is drawn in width, up to and including a newline, or up to where the line is wrapped.
+// Lines are wrapped at word boundaries when possible, spaces at the end of a wrapped line do not count towards its width.
+// Offset is the start of the next line. Eof is set if the line ends at the end of the text, without newline.
+func (ui *Edit) nextLine(offset int64, width int) (s string, end int64, eof bool) {
+	font := ui.font()
+	rd := ui.reader(offset, ui.text.Size())
+	var lb lineBreaker
+	x := 0
+	brk := 0 // last break opportunity in s
+	for {
+		c, eof := rd.Peek()
+		if eof {
+			return s, rd.Offset(), true
+		}
+		if c == '\n' {
+			rd.Get()
+			return s + "\n", rd.Offset(), false
+		}
+		if lb.next(c) && s != "" {
+			brk = len(s)
+		}
+		x += font.StringWidth(string(c))
+		if !ui.NoWrap && c != ' ' && s != "" && x >= width {
+			if brk > 0 {
+				return s[:brk], offset + int64(brk), false
+			}
+			return s, rd.Offset(), false
+		}
+		s += string(rd.Get())
+	}
+}
+
+// lineAround returns the line around offset, without newline, and the offset where it starts.
+// For long lines, at most 1024 characters before and after offset are returned.
+func (ui *Edit) lineAround(offset int64) (int64, string) {
+	const max = 1024
+	br := ui.revReader(offset)
+	n := 0
+	before, _ := br.gather(func(c rune) bool {
+		n++
+		return c != '\n' && n <= max
+	})
+	fr := ui.reader(offset, ui.text.Size())
+	n = 0
+	after, _ := fr.gather(func(c rune) bool {
+		n++
+		return c != '\n' && n <= max
+	})
+	rbefore := []rune(before)
+	for i, j := 0, len(rbefore)-1; i < j; i, j = i+1, j-1 {
+		rbefore[i], rbefore[j] = rbefore[j], rbefore[i]
+	}
+	return br.Offset(), string(rbefore) + after
+}
+
+// bidi returns line s prepared for drawing in visual order.
+// Lines without right-to-left text skip the bidi algorithm, unless DUI.RTL is set.
+func (ui *Edit) bidi(s string) *bidiText {
+	if !ui.dui.RTL && !bidiMaybeRTL(s) {
+		return newLTRText(ui.font(), s)
+	}
+	return newBidiText(ui.font(), s, bidiRTL(s, ui.dui.RTL))
+}
+
+// scrollCursorX adjusts the horizontal scroll offset for NoWrap, so the cursor is visible in width.
+func (ui *Edit) scrollCursorX(width int) {
+	start, s := ui.lineAround(ui.cursor.Cur)
+	b := ui.bidi(s)
+	x := maximum(0, b.align(width)) + b.cursorX(int(ui.cursor.Cur-start))
+	if x < ui.offsetX {
+		ui.offsetX = x
+	} else if x >= ui.offsetX+width {
+		ui.offsetX = x - width + 1
+	}
+}
+
+// moveHorizontal returns the offset of the cursor visually left (dir < 0) or right (dir > 0) of offset.
+// Within lines with right-to-left text, the cursor moves in visual order. At the end of a line, it moves to the previous or next line.
+func (ui *Edit) moveHorizontal(offset int64, dir int) int64 {
+	logical := dir
+	start, s := ui.lineAround(offset)
+	if ui.dui.RTL || bidiMaybeRTL(s) {
+		b := newBidiText(ui.font(), s, bidiRTL(s, ui.dui.RTL))
+		if o, ok := b.move(int(offset-start), dir); ok {
+			return start + int64(o)
+		}
+		if b.rtlPar {
+			logical = -dir
+		}
+	}
+	if logical < 0 {
+		br := ui.revReader(offset)
+		br.TryGet()
+		return br.Offset()
+	}
+	fr := ui.reader(offset, ui.text.Size())
+	fr.TryGet()
+	return fr.Offset()
 }
 
 func (ui *Edit) FirstFocus(dui *DUI, self *Kid) (warp *image.Point) {
@@ -1,6 +1,7 @@
 package duit
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"strings"
@@ -158,6 +159,21 @@ func (t *text) closeHist(ui *Edit) {
 }
 
 func (t *text) Replace(ui *Edit, dirty *bool, c Cursor, buf []byte, open bool) {
+	if ui.singleLine {
+		buf = bytes.Replace(buf, []byte("\n"), []byte(" "), -1)
+	}
+	if s, e := c.Ordered(); ui.accept != nil && (len(buf) > 0 || s != e) {
+		// deletions are checked too, removing a character can make the text invalid
+		cur, err := ui.Text()
+		if ui.error(err, "text") {
+			return
+		}
+		ntext := append(append(append([]byte{}, cur[:s]...), buf...), cur[e:]...)
+		if !ui.accept(ntext) {
+			ui.rejected = true
+			return
+		}
+	}
 	wasOpen := t.open
 	t.open = t.open && open && len(t.future) == 0
 	if wasOpen && !t.open {
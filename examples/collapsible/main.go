@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+
+	"github.com/mjl-/duit"
+)
+
+func check(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s\n", msg, err)
+	}
+}
+
+func main() {
+	dui, err := duit.NewDUI("ex/collapsible", nil)
+	check(err, "new dui")
+
+	section := func(title, text string) *duit.Collapsible {
+		return &duit.Collapsible{
+			Title: title,
+			UI: &duit.Box{
+				Padding: duit.SpaceXY(6, 4),
+				Kids:    duit.NewKids(&duit.Label{Text: text}),
+			},
+		}
+	}
+
+	accordion := duit.NewAccordion(
+		section("general", "general settings"),
+		section("appearance", "appearance settings"),
+		section("advanced", "advanced settings"),
+	)
+	for i, k := range accordion.Kids {
+		k.ID = []string{"general", "appearance", "advanced"}[i]
+	}
+
+	notes := section("notes", "a collapsible section on its own, its open state is remembered")
+	dui.Top.UI = &duit.Box{
+		Kids: []*duit.Kid{
+			{UI: notes, ID: "notes"},
+			{UI: accordion},
+		},
+	}
+	dui.Render()
+
+	for {
+		select {
+		case e := <-dui.Inputs:
+			dui.Input(e)
+
+		case err, ok := <-dui.Error:
+			if !ok {
+				return
+			}
+			log.Printf("duit: %s\n", err)
+		}
+	}
+}
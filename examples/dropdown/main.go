@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+
+	"github.com/mjl-/duit"
+)
+
+func check(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s\n", msg, err)
+	}
+}
+
+func main() {
+	dui, err := duit.NewDUI("ex/dropdown", nil)
+	check(err, "new dui")
+
+	var countries []*duit.ListValue
+	for i, s := range []string{"Argentina", "Australia", "Austria", "Belgium", "Brazil", "Canada", "Chile", "China", "Denmark", "Egypt", "Finland", "France", "Germany", "Greece", "India", "Italy", "Japan", "Mexico", "Netherlands", "Norway", "Portugal", "Spain", "Sweden"} {
+		countries = append(countries, &duit.ListValue{Text: s, Value: i})
+	}
+	sizes := []*duit.ListValue{
+		{Text: "small", Value: 10},
+		{Text: "medium", Value: 12},
+		{Text: "large", Value: 16},
+	}
+
+	status := &duit.Label{Text: "pick a country"}
+	dui.Top.UI = &duit.Box{
+		Padding: duit.SpaceXY(6, 4),
+		Margin:  image.Pt(6, 4),
+		Kids: duit.NewKids(
+			status,
+			&duit.Dropdown{
+				Values:      countries,
+				Selected:    -1,
+				Placeholder: "country",
+				Changed: func(index int, value interface{}) (e duit.Event) {
+					status.Text = fmt.Sprintf("country %d, value %v", index, value)
+					dui.MarkLayout(status)
+					return
+				},
+			},
+			&duit.Dropdown{
+				Values:      sizes,
+				Selected:    1,
+				Editable:    true,
+				Text:        "medium",
+				Placeholder: "font size",
+				Changed: func(index int, value interface{}) (e duit.Event) {
+					status.Text = fmt.Sprintf("size %d, value %v", index, value)
+					dui.MarkLayout(status)
+					return
+				},
+			},
+		),
+	}
+	dui.Render()
+
+	for {
+		select {
+		case e := <-dui.Inputs:
+			dui.Input(e)
+
+		case err, ok := <-dui.Error:
+			if !ok {
+				return
+			}
+			log.Printf("duit: %s\n", err)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"image"
+	"io/ioutil"
 	"log"
+	"path/filepath"
 
 	"github.com/mjl-/duit"
 )
@@ -25,6 +28,28 @@ func main() {
 			&duit.Field{
 				Text: "edit me",
 			},
+			&duit.Field{
+				Placeholder: "path, with completion",
+				Complete: func(ctx context.Context, text string) (l []string) {
+					dir := filepath.Dir(text)
+					files, _ := ioutil.ReadDir(dir)
+					for _, fi := range files {
+						p := filepath.Join(dir, fi.Name())
+						if fi.IsDir() {
+							p += "/"
+						}
+						l = append(l, p)
+					}
+					return
+				},
+			},
+			&duit.Spinbox{
+				Value:     20,
+				Min:       0,
+				Max:       100,
+				Step:      0.5,
+				Precision: 1,
+			},
 		),
 	}
 	dui.Render()
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"image"
+	"log"
+	"strings"
+
+	"github.com/mjl-/duit"
+)
+
+func check(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s\n", msg, err)
+	}
+}
+
+func main() {
+	dui, err := duit.NewDUI("ex/filedialog", nil)
+	check(err, "new dui")
+
+	status := &duit.Label{Text: "no file chosen"}
+	show := func(paths []string) {
+		if paths == nil {
+			status.Text = "cancelled"
+		} else {
+			status.Text = strings.Join(paths, "\n")
+		}
+		dui.MarkLayout(status)
+	}
+
+	open := &duit.FileDialog{
+		Multiple: true,
+		Filters: []duit.FileFilter{
+			{Name: "Go files", Extensions: []string{".go"}},
+			{Name: "Text files", Extensions: []string{".txt", ".md"}},
+			{Name: "All files"},
+		},
+		Done: func(paths []string) (e duit.Event) {
+			show(paths)
+			return
+		},
+	}
+	save := &duit.FileDialog{
+		Save: true,
+		Name: "untitled.txt",
+	}
+
+	dui.Top.UI = &duit.Box{
+		Padding: duit.SpaceXY(6, 4),
+		Margin:  image.Pt(6, 4),
+		Kids: duit.NewKids(
+			&duit.Button{
+				Text: "Open...",
+				Click: func() (e duit.Event) {
+					open.Open(dui)
+					return
+				},
+			},
+			&duit.Button{
+				Text: "Save in new window...",
+				Click: func() (e duit.Event) {
+					paths, err := save.Window("save")
+					if err != nil {
+						log.Printf("file dialog: %s\n", err)
+						return
+					}
+					show(paths)
+					return
+				},
+			},
+			status,
+		),
+	}
+	dui.Render()
+
+	for {
+		select {
+		case e := <-dui.Inputs:
+			dui.Input(e)
+
+		case err, ok := <-dui.Error:
+			if !ok {
+				return
+			}
+			log.Printf("duit: %s\n", err)
+		}
+	}
+}
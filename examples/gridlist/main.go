@@ -30,6 +30,24 @@ func main() {
 			Selected: i%10 == 0,
 			Values:   values,
 		}
+		if i%5 == 0 {
+			for j := 0; j < 3; j++ {
+				child := &duit.Gridrow{
+					Values: []string{
+						fmt.Sprintf("cell 0,%d.%d", i, j),
+						fmt.Sprintf("cell 1,%d.%d", i, j),
+						fmt.Sprintf("cell 2,%d.%d", i, j),
+					},
+				}
+				if j == 0 {
+					child.Children = []*duit.Gridrow{
+						{Values: []string{fmt.Sprintf("cell 0,%d.%d.0", i, j), "nested", "deeper"}},
+					}
+				}
+				row.Children = append(row.Children, child)
+			}
+			row.Expanded = i == 0
+		}
 		rows = append(rows, row)
 	}
 	rows = append([]*duit.Gridrow{{
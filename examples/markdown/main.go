@@ -0,0 +1,61 @@
+package main
+
+import (
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"9fans.net/go/draw"
+	"github.com/mjl-/duit"
+)
+
+func check(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s\n", msg, err)
+	}
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		log.Fatalln("usage: markdown file.md")
+	}
+	path := os.Args[1]
+	buf, err := ioutil.ReadFile(path)
+	check(err, "read file")
+
+	dui, err := duit.NewDUI("ex/markdown", nil)
+	check(err, "new dui")
+
+	md := &duit.Markdown{
+		Text: string(buf),
+		Link: func(url string) (e duit.Event) {
+			log.Printf("link clicked: %s\n", url)
+			return
+		},
+		Image: func(src string) (*draw.Image, error) {
+			// image sources are relative to the document
+			return duit.ReadImagePath(dui.Display, filepath.Join(filepath.Dir(path), src))
+		},
+	}
+	dui.Top.UI = duit.NewScroll(&duit.Box{
+		Padding: duit.SpaceXY(10, 10),
+		Kids:    duit.NewKids(md),
+	})
+	dui.Render()
+
+	for {
+		select {
+		case e := <-dui.Inputs:
+			dui.Input(e)
+
+		case err, ok := <-dui.Error:
+			if !ok {
+				return
+			}
+			log.Printf("duit: %s\n", err)
+		}
+	}
+}
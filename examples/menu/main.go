@@ -0,0 +1,106 @@
+package main
+
+import (
+	"image"
+	"log"
+	"os"
+
+	"9fans.net/go/draw"
+	"github.com/mjl-/duit"
+)
+
+func check(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s\n", msg, err)
+	}
+}
+
+func main() {
+	dui, err := duit.NewDUI("ex/menu", nil)
+	check(err, "new dui")
+
+	status := &duit.Label{Text: "use the menu bar, or button 3 on the text below"}
+	say := func(s string) func() duit.Event {
+		return func() (e duit.Event) {
+			status.Text = s
+			dui.MarkLayout(status)
+			return
+		}
+	}
+
+	wrap := &duit.MenuItem{Text: "Wrap lines", Checked: true}
+	wrap.Click = func() (e duit.Event) {
+		wrap.Checked = !wrap.Checked
+		status.Text = "toggled wrapping"
+		dui.MarkLayout(status)
+		return
+	}
+	zoom := &duit.Menu{
+		Items: []*duit.MenuItem{
+			{Text: "Zoom in", Shortcut: draw.KeyCmd + '+', Click: say("zoomed in")},
+			{Text: "Zoom out", Shortcut: draw.KeyCmd + '-', Click: say("zoomed out")},
+		},
+	}
+	file := &duit.Menu{
+		Title: "File",
+		Items: []*duit.MenuItem{
+			{Text: "New", Shortcut: draw.KeyCmd + 'n', Click: say("new")},
+			{Text: "Open...", Shortcut: draw.KeyCmd + 'o', Click: say("open")},
+			{Text: "Save", Shortcut: draw.KeyCmd + 's', Click: say("save")},
+			{Text: "Revert", Disabled: true},
+			{Separator: true},
+			{Text: "Quit", Shortcut: draw.KeyCmd + 'q', Click: func() (e duit.Event) {
+				os.Exit(0)
+				return
+			}},
+		},
+	}
+	view := &duit.Menu{
+		Title: "View",
+		Items: []*duit.MenuItem{
+			wrap,
+			{Text: "Zoom", Submenu: zoom},
+		},
+	}
+	context := &duit.Menu{
+		Items: []*duit.MenuItem{
+			{Text: "Cut", Click: say("cut")},
+			{Text: "Paste", Click: say("paste")},
+			{Separator: true},
+			{Text: "Look", Click: say("look")},
+		},
+	}
+
+	dui.Top.UI = &duit.MenuBar{
+		Menus: []*duit.Menu{file, view},
+		Kid: &duit.Kid{
+			UI: &duit.Box{
+				Padding: duit.SpaceXY(6, 4),
+				Margin:  image.Pt(6, 4),
+				Kids: []*duit.Kid{
+					{UI: status},
+					{
+						UI: &duit.Label{Text: "button 3 here opens a context menu"},
+						Menu: func(m draw.Mouse) *duit.Menu {
+							return context
+						},
+					},
+				},
+			},
+		},
+	}
+	dui.Render()
+
+	for {
+		select {
+		case e := <-dui.Inputs:
+			dui.Input(e)
+
+		case err, ok := <-dui.Error:
+			if !ok {
+				return
+			}
+			log.Printf("duit: %s\n", err)
+		}
+	}
+}
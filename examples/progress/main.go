@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"time"
+
+	"github.com/mjl-/duit"
+)
+
+func check(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s\n", msg, err)
+	}
+}
+
+func main() {
+	dui, err := duit.NewDUI("ex/progress", nil)
+	check(err, "new dui")
+
+	progress := &duit.Progress{Label: "idle"}
+	spinner := &duit.Spinner{}
+	busy := false
+	var start *duit.Button
+	start = &duit.Button{
+		Text: "start",
+		Click: func() (e duit.Event) {
+			if busy {
+				return
+			}
+			busy = true
+			spinner.Start(dui)
+			go func() {
+				// pretend to work, reporting progress far more often than the screen needs
+				const n = 5000
+				for i := 0; i <= n; i++ {
+					time.Sleep(time.Millisecond)
+					i := i
+					dui.Call <- func() {
+						progress.Set(dui, float64(i)/n, fmt.Sprintf("%d%%", i*100/n))
+					}
+				}
+				dui.Call <- func() {
+					busy = false
+					spinner.Stop(dui)
+					progress.Set(dui, 1, "done")
+				}
+			}()
+			return
+		},
+	}
+
+	dui.Top.UI = &duit.Box{
+		Padding: duit.SpaceXY(6, 4),
+		Margin:  image.Pt(6, 4),
+		Kids: duit.NewKids(
+			&duit.Box{
+				Margin: image.Pt(6, 0),
+				Kids:   duit.NewKids(start, spinner),
+			},
+			progress,
+		),
+	}
+	dui.Render()
+
+	for {
+		select {
+		case e := <-dui.Inputs:
+			dui.Input(e)
+
+		case err, ok := <-dui.Error:
+			if !ok {
+				return
+			}
+			log.Printf("duit: %s\n", err)
+		}
+	}
+}
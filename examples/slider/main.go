@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+
+	"github.com/mjl-/duit"
+)
+
+func check(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s\n", msg, err)
+	}
+}
+
+func main() {
+	dui, err := duit.NewDUI("ex/slider", nil)
+	check(err, "new dui")
+
+	status := &duit.Label{Text: "drag a handle"}
+	dui.Top.UI = &duit.Box{
+		Padding: duit.SpaceXY(6, 4),
+		Margin:  image.Pt(6, 4),
+		Kids: duit.NewKids(
+			status,
+			&duit.Slider{
+				Value: 30,
+				Min:   0,
+				Max:   100,
+				Step:  5,
+				Ticks: 10,
+				Changed: func(value float64, done bool) (e duit.Event) {
+					status.Text = fmt.Sprintf("value %v, done %v", value, done)
+					dui.MarkLayout(status)
+					return
+				},
+			},
+			&duit.RangeSlider{
+				Low:  20,
+				High: 60,
+				Min:  0,
+				Max:  100,
+				Changed: func(low, high float64, done bool) (e duit.Event) {
+					status.Text = fmt.Sprintf("range %.1f-%.1f, done %v", low, high, done)
+					dui.MarkLayout(status)
+					return
+				},
+			},
+			&duit.Slider{
+				Vertical: true,
+				Value:    0.5,
+			},
+		),
+	}
+	dui.Render()
+
+	for {
+		select {
+		case e := <-dui.Inputs:
+			dui.Input(e)
+
+		case err, ok := <-dui.Error:
+			if !ok {
+				return
+			}
+			log.Printf("duit: %s\n", err)
+		}
+	}
+}
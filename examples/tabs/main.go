@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/mjl-/duit"
@@ -16,8 +17,12 @@ func main() {
 	dui, err := duit.NewDUI("ex/tabs", nil)
 	check(err, "new dui")
 
-	dui.Top.UI = &duit.Tabs{
+	var tabs *duit.Tabs
+	n := 5
+	tabs = &duit.Tabs{
 		Buttongroup: &duit.Buttongroup{
+			Closable: true,
+			Movable:  true,
 			Texts: []string{
 				"tab1",
 				"tab2",
@@ -27,13 +32,38 @@ func main() {
 			},
 		},
 		UIs: []duit.UI{
-			&duit.Button{Text: "this is the content of tab1"},
-			&duit.Field{Text: "this is the content of tab2"},
+			&duit.Button{
+				Text: "add a tab",
+				Click: func() (e duit.Event) {
+					n++
+					text := fmt.Sprintf("tab%d", n)
+					tabs.Insert(dui, -1, text, &duit.Label{Text: "this is the content of " + text})
+					tabs.Select(dui, len(tabs.UIs)-1)
+					return
+				},
+			},
+			&duit.Button{
+				Text: "move tabs to next side",
+				Click: func() (e duit.Event) {
+					tabs.Placement = (tabs.Placement + 1) % 4
+					dui.MarkLayout(tabs)
+					return
+				},
+			},
 			&duit.Label{Text: "this is the content of tab3"},
 			&duit.Label{Text: "this is the content of tab4"},
 			&duit.Label{Text: "this is the content of tab5"},
 		},
+		Stack: &duit.Stack{
+			KeepFocus:  true,
+			Transition: duit.StackSlide,
+		},
+		Changed: func(index int) (e duit.Event) {
+			log.Printf("tab %d active\n", index)
+			return
+		},
 	}
+	dui.Top.UI = tabs
 	dui.Render()
 
 	for {
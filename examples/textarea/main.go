@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+
+	"github.com/mjl-/duit"
+)
+
+func check(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s\n", msg, err)
+	}
+}
+
+func main() {
+	dui, err := duit.NewDUI("ex/textarea", nil)
+	check(err, "new dui")
+
+	status := &duit.Label{Text: "0 characters"}
+	dui.Top.UI = &duit.Box{
+		Padding: duit.SpaceXY(6, 4),
+		Margin:  image.Pt(6, 4),
+		Kids: duit.NewKids(
+			&duit.TextArea{
+				Placeholder: "type some lines, the text area grows up to 6 lines",
+				MinLines:    2,
+				MaxLines:    6,
+				Changed: func(text string) (e duit.Event) {
+					status.Text = fmt.Sprintf("%d characters", len(text))
+					dui.MarkLayout(status)
+					return
+				},
+			},
+			status,
+		),
+	}
+	dui.Render()
+
+	for {
+		select {
+		case e := <-dui.Inputs:
+			dui.Input(e)
+
+		case err, ok := <-dui.Error:
+			if !ok {
+				return
+			}
+			log.Printf("duit: %s\n", err)
+		}
+	}
+}
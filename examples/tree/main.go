@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/mjl-/duit"
+)
+
+func check(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s\n", msg, err)
+	}
+}
+
+func main() {
+	dui, err := duit.NewDUI("ex/tree", nil)
+	check(err, "new dui")
+
+	dir, err := os.Getwd()
+	check(err, "getwd")
+
+	status := &duit.Label{Text: dir}
+	tree := &duit.Tree{
+		Nodes: []*duit.TreeNode{
+			{Text: dir, Value: dir, Expanded: true},
+		},
+		Multiple: true,
+		Load: func(node *duit.TreeNode) (children []*duit.TreeNode) {
+			path := node.Value.(string)
+			files, err := ioutil.ReadDir(path)
+			if err != nil {
+				log.Printf("readdir: %s\n", err)
+				return nil
+			}
+			for _, fi := range files {
+				children = append(children, &duit.TreeNode{
+					Text:  fi.Name(),
+					Value: filepath.Join(path, fi.Name()),
+					Leaf:  !fi.IsDir(),
+				})
+			}
+			return
+		},
+		Changed: func(node *duit.TreeNode) (e duit.Event) {
+			status.Text = node.Value.(string)
+			dui.MarkLayout(status)
+			return
+		},
+	}
+
+	dui.Top.UI = &duit.Box{
+		Kids: duit.NewKids(
+			status,
+			duit.NewScroll(tree),
+		),
+	}
+	dui.Render()
+
+	for {
+		select {
+		case e := <-dui.Inputs:
+			dui.Input(e)
+
+		case err, ok := <-dui.Error:
+			if !ok {
+				return
+			}
+			log.Printf("duit: %s\n", err)
+		}
+	}
+}
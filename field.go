@@ -1,8 +1,8 @@
 package duit
 
 import (
+	"context"
 	"image"
-	"strings"
 	"unicode/utf8"
 
 	"9fans.net/go/draw"
@@ -11,23 +11,48 @@ import (
 // Cursor and SelectionStart start at 1 for sane behaviour of an empty Field struct.
 
 // Field is a single line text field. The cursor is always visible, and determines which part of the text is shown.
+// Text starting with right-to-left characters, such as Hebrew or Arabic, is right-aligned. Arrow keys move the cursor visually, through mixed-direction text.
+//
+// Text is edited like in an Edit, with the same control and command key shortcuts, unlimited undo and redo, and vi command and visual mode after escape.
+// Newlines in pasted text are replaced by spaces. Double-clicking selects a word, or the text between brackets or quotes.
+// Changing Text resets the undo history.
+//
+// With Complete or CompleteChan set, suggestions containing the text are shown in a list below the field.
+// Keys, while the list is open:
+//	arrow up and down, select a suggestion
+//	page up and down, select a suggestion a page away
+//	\n, replace the text with the selected suggestion
+//	escape, close the list
 type Field struct {
-	Text            string                               // Current text.
-	Placeholder     string                               // Text displayed in lighter color as example.
-	Disabled        bool                                 // If disabled, mouse and key input have no effect.
-	Cursor1         int                                  // Index in string of cursor in bytes, start at 1, 0 means end of string.
-	SelectionStart1 int                                  // If > 0, 1 beyond the start of the selection in bytes, with Cursor being the end.
-	Password        bool                                 // Render text as bullet items to hide the password (but not length).
-	Font            *draw.Font                           `json:"-"` // Font to use for drawing text.
-	Changed         func(text string) (e Event)          `json:"-"` // Called after contents of field have changed.
-	Keys            func(k rune, m draw.Mouse) (e Event) `json:"-"` // Called before handling key. If you consume the event, Changed will not be called.
-
-	size            image.Point // including space
+	Text            string                                                        // Current text.
+	Placeholder     string                                                        // Text displayed in lighter color as example.
+	Disabled        bool                                                          // If disabled, mouse and key input have no effect.
+	Cursor1         int                                                           // Index in string of cursor in bytes, start at 1, 0 means end of string.
+	SelectionStart1 int                                                           // If > 0, 1 beyond the start of the selection in bytes, with Cursor being the end.
+	Password        bool                                                          // Render text as bullet items to hide the password (but not length).
+	Font            *draw.Font                                                    `json:"-"` // Font to use for drawing text.
+	Changed         func(text string) (e Event)                                   `json:"-"` // Called after contents of field have changed.
+	Keys            func(k rune, m draw.Mouse) (e Event)                          `json:"-"` // Called before handling key. If you consume the event, Changed will not be called.
+	MaxLength       int                                                           // If > 0, the maximum number of characters. Longer input is rejected.
+	Mask            FieldMask                                                     `json:"-"` // If set, typed, pasted and deleted text resulting in text the mask does not allow is rejected, before Changed is called.
+	Validate        func(text string) (msg string)                                `json:"-"` // If set, called when the text changes. A non-empty message marks the text invalid, drawn with a red border.
+	ShowInvalid     bool                                                          // Whether to draw the message from Validate below the field. Room for the message is always reserved.
+	Complete        func(ctx context.Context, text string) (suggestions []string) `json:"-"` // If set, called in a goroutine after each change, for suggestions shown in a list below the field. Ctx is cancelled on the next change, and when the list is dismissed.
+	CompleteChan    func(text string) <-chan []string                             `json:"-"` // Like Complete, but suggestions are read from the returned channel until it is closed, each replacing the previous. Suggestions sent after the next change are discarded, the channel must still be closed. Used if Complete is nil.
+
+	size            image.Point // including space, excluding room for the Validate message
 	m               draw.Mouse
 	prevB1Release   draw.Mouse
 	img             *draw.Image // in case text is too big
 	prevTextOffset  int         // offset for text for previous draw, used to determine whether to realign the cursor
 	lastCursorPoint image.Point // location of last cursor draw, for FirstFocus() and cmd+t
+	edit            *Edit       // Text engine for editing, with history and vi modes.
+	editText        string      // Text in edit, to detect changes to Text.
+	validated       bool        // Whether invalid is the result of Validate for validatedText.
+	validatedText   string
+	invalid         string
+	anchor          image.Rectangle // Window coordinates of the field during the last key, for placing the suggestions.
+	completion      fieldCompletion
 }
 
 var _ UI = &Field{}
@@ -55,6 +80,13 @@ func (ui *Field) cursor0() int {
 	return ui.Cursor1 - 1
 }
 
+// bidi returns the text as it is drawn, in a right-to-left paragraph if it starts with right-to-left text.
+// Passwords are always drawn left-to-right.
+func (ui *Field) bidi(dui *DUI, text string) *bidiText {
+	rtl := !ui.Password && bidiRTL(text, dui.RTL)
+	return newBidiText(ui.font(dui), text, rtl)
+}
+
 // selection with start & end with 0 indices
 func (ui *Field) selection0() (start int, end int, text string) {
 	if ui.SelectionStart1 <= 0 {
@@ -67,24 +99,104 @@ func (ui *Field) selection0() (start int, end int, text string) {
 	return s, e, ui.Text[s:e]
 }
 
-func (ui *Field) removeSelection() {
-	if ui.SelectionStart1 <= 0 {
+// ensureEdit returns the Edit used for editing, holding Text and with the cursor and selection of the Field.
+// If Text was changed since the last edit, the Edit is started anew, without history.
+func (ui *Field) ensureEdit(dui *DUI) *Edit {
+	if ui.edit == nil || ui.editText != ui.Text {
+		ui.edit = newTextEdit(ui.Text)
+		ui.edit.singleLine = true
+		ui.editText = ui.Text
+	}
+	e := ui.edit
+	e.dui = dui
+	e.Font = ui.Font
+	e.accept = ui.accept
+	c := int64(ui.cursor0())
+	e.cursor = Cursor{c, c}
+	if ui.SelectionStart1 > 0 {
+		e.cursor.Start = int64(ui.SelectionStart1 - 1)
+	}
+	return e
+}
+
+// fromEdit sets Text, cursor and selection from the Edit.
+func (ui *Field) fromEdit() {
+	e := ui.edit
+	buf, err := e.Text()
+	if e.error(err, "text") {
 		return
 	}
-	s, e, _ := ui.selection0()
-	ui.Text = ui.Text[:s] + ui.Text[e:]
-	ui.Cursor1 = 1 + s
+	ui.Text = string(buf)
+	ui.editText = ui.Text
+	ui.Cursor1 = 1 + int(e.cursor.Cur)
 	ui.SelectionStart1 = 0
+	if e.cursor.Start != e.cursor.Cur {
+		ui.SelectionStart1 = 1 + int(e.cursor.Start)
+	}
+}
+
+// accept returns whether text is allowed by MaxLength and Mask.
+func (ui *Field) accept(text []byte) bool {
+	if ui.MaxLength > 0 && utf8.RuneCount(text) > ui.MaxLength {
+		return false
+	}
+	return ui.Mask == nil || ui.Mask(string(text))
+}
+
+// Invalid returns the message from Validate for the current text, or the empty string if the text is valid.
+func (ui *Field) Invalid() string {
+	if ui.Validate == nil {
+		return ""
+	}
+	if !ui.validated || ui.validatedText != ui.Text {
+		ui.invalid = ui.Validate(ui.Text)
+		ui.validatedText = ui.Text
+		ui.validated = true
+	}
+	return ui.invalid
+}
+
+// messageHeight returns the height reserved below the field for the Validate message.
+func (ui *Field) messageHeight(dui *DUI) int {
+	if !ui.ShowInvalid {
+		return 0
+	}
+	return ui.font(dui).Height
+}
+
+// cursorMove returns the cursor moved visually left (dir < 0) or right (dir > 0).
+func (ui *Field) cursorMove(dui *DUI, cursor0, dir int) int {
+	if ui.Password || !dui.RTL && !bidiMaybeRTL(ui.Text) {
+		if dir < 0 {
+			_, n := utf8.DecodeLastRuneInString(ui.Text[:cursor0])
+			return cursor0 - n
+		}
+		_, n := utf8.DecodeRuneInString(ui.Text[cursor0:])
+		return cursor0 + n
+	}
+	if o, ok := ui.bidi(dui, ui.Text).move(cursor0, dir); ok {
+		return o
+	}
+	return cursor0
 }
 
 func (ui *Field) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 	dui.debugLayout(self)
 
 	ui.size = image.Point{sizeAvail.X, ui.font(dui).Height + 2*ui.space(dui).Y}
-	self.R = rect(ui.size)
+	self.R = rect(ui.size.Add(image.Pt(0, ui.messageHeight(dui))))
 	return
 }
 
+// Measure returns the available width as preferred size, and room for a few characters as minimum.
+func (ui *Field) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	font := ui.font(dui)
+	space := ui.space(dui)
+	size := image.Pt(sizeAvail.X, font.Height+2*space.Y+ui.messageHeight(dui))
+	min := image.Pt(font.StringWidth("mmm")+2*space.X, size.Y)
+	return Sizes{min, size, size}
+}
+
 func (ui *Field) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	dui.debugDraw(self)
 
@@ -96,7 +208,7 @@ func (ui *Field) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m
 	r = r.Add(orig)
 
 	ui.fixCursor()
-	s, e, sel := ui.selection0()
+	s, e, _ := ui.selection0()
 	f := ui.font(dui)
 
 	colors := dui.Regular.Normal
@@ -120,27 +232,20 @@ func (ui *Field) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m
 	} else if ui.Password {
 		// ugh
 		nt := ""
-		sel = ""
-		inSel := false
 		ns := -1
 		ne := -1
 		nc0 := -1
 		for o := range text {
 			if s == o {
 				ns = len(nt)
-				inSel = true
 			}
 			if e == o {
 				ne = len(nt)
-				inSel = false
 			}
 			if c0 == o {
 				nc0 = len(nt)
 			}
 			nt += "•"
-			if inSel {
-				sel += "•"
-			}
 		}
 		if nc0 < 0 {
 			nc0 = len(nt)
@@ -154,23 +259,39 @@ func (ui *Field) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m
 		text, s, e, c0 = nt, ns, ne, nc0
 	}
 	img.Draw(r, colors.Background, nil, image.ZP)
-	drawRoundedBorder(img, r, colors.Border)
+	border := colors.Border
+	invalid := ui.Invalid()
+	if invalid != "" && !ui.Disabled {
+		border = dui.Danger.Normal.Border
+	}
+	if ui.edit != nil {
+		switch ui.edit.mode {
+		case modeCommand:
+			border = dui.CommandMode
+		case modeVisual, modeVisualLine:
+			border = dui.VisualMode
+		}
+	}
+	drawRoundedBorder(img, r, border)
+	if ui.ShowInvalid {
+		msgR := image.Rect(r.Min.X, r.Max.Y, r.Max.X, r.Max.Y+f.Height)
+		img.Draw(msgR, dui.Background, nil, image.ZP)
+		if invalid != "" {
+			drawBidiString(dui, img, msgR.Min, msgR.Dx(), dui.Danger.Normal.Background, f, invalid)
+		}
+	}
 
 	space := ui.space(dui)
+	b := ui.bidi(dui, text)
 
 	drawString := func(i *draw.Image, p, cp image.Point) {
 		p = p.Add(space)
-		if sel == "" {
-			i.String(p, colors.Text, image.ZP, f, text)
-		} else {
-			before := text[:s]
-			after := text[e:]
-			p = i.String(p, colors.Text, image.ZP, f, before)
-			selR := outsetPt(rect(f.StringSize(sel)).Add(p), image.Pt(0, space.Y/2))
+		for _, selR := range b.selectionRects(s, e) {
+			selR.Max.Y = f.Height
+			selR = outsetPt(selR.Add(p), image.Pt(0, space.Y/2))
 			i.Draw(selR, selColors.Background, nil, image.ZP)
-			p = i.String(p, selColors.Text, image.ZP, f, sel)
-			i.String(p, colors.Text, image.ZP, f, after)
 		}
+		b.draw(i, p, f, colors.Text, s, e, selColors.Text)
 
 		if hover && !ui.Disabled {
 			// draw cursor
@@ -182,10 +303,11 @@ func (ui *Field) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m
 		}
 	}
 
-	width := f.StringWidth(text)
-	if width <= r.Dx()-2*space.X {
-		cp := r.Min.Add(image.Pt(f.StringWidth(text[:c0]), 0))
-		drawString(img, r.Min, cp)
+	if b.width <= r.Dx()-2*space.X {
+		align := b.align(r.Dx() - 2*space.X)
+		cp := r.Min.Add(image.Pt(align+b.cursorX(c0), 0))
+		drawString(img, r.Min.Add(image.Pt(align, 0)), cp)
+		ui.prevTextOffset = align
 	} else {
 		if ui.img == nil || !ui.img.R.Size().Eq(ui.size) {
 			var err error
@@ -198,7 +320,7 @@ func (ui *Field) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m
 
 		// first, determine cursor given previous draw
 		width := ui.img.R.Dx() - 2*space.X
-		stringWidth := f.StringWidth(text[:c0])
+		stringWidth := b.cursorX(c0)
 		cursorOffset := stringWidth + ui.prevTextOffset
 		var textOffset int
 		if cursorOffset < 0 {
@@ -220,86 +342,6 @@ func (ui *Field) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m
 	}
 }
 
-func expandSelection(t string, i int) (s, e int) {
-	if i == 0 || i == len(t) {
-		return 0, len(t)
-	}
-
-	const (
-		Starts = "[{(<\"'`"
-		Ends   = "]})>\"'`"
-	)
-
-	index := strings.IndexByte(Starts, t[i-1])
-	if index >= 0 {
-		s = i
-		e = s
-		n := len(t)
-		up := Starts[index]
-		down := Ends[index]
-		nested := 1
-		for {
-			if e >= n {
-				return i, i
-			}
-			// note: order of comparison matters, for quotes, down is the same as up
-			if t[e] == down {
-				nested--
-			} else if t[e] == up {
-				nested++
-			}
-			if nested == 0 {
-				return
-			}
-			e++
-		}
-	}
-
-	index = strings.IndexByte(Ends, t[i])
-	if index >= 0 {
-		e = i
-		s = i - 1
-		up := Ends[index]
-		down := Starts[index]
-		nested := 1
-		for {
-			if s == 0 {
-				return i, i
-			}
-			// note: order of comparison matters, for quotes, down is the same as up
-			if t[s] == down {
-				nested--
-			} else if t[s] == up {
-				nested++
-			}
-			if nested == 0 {
-				s++
-				return
-			}
-			s--
-		}
-	}
-
-	s = i
-	e = i
-
-	const Space = " \t\r\n\f"
-	skip := func(isSpace bool) bool {
-		return !isSpace
-	}
-
-	if strings.ContainsAny(t[s-1:s], Space) && strings.ContainsAny(t[e:e+1], Space) {
-		skip = func(isSpace bool) bool {
-			return isSpace
-		}
-	}
-	for ; s > 0 && skip(strings.ContainsAny(t[s-1:s], Space)) && !strings.ContainsAny(t[s-1:s], Starts+Ends); s-- {
-	}
-	for ; e < len(t) && skip(strings.ContainsAny(t[e:e+1], Space)) && !strings.ContainsAny(t[e:e+1], Starts+Ends); e++ {
-	}
-	return
-}
-
 func (ui *Field) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
 	if ui.Disabled {
 		return
@@ -311,12 +353,12 @@ func (ui *Field) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig
 	locateCursor := func() int {
 		f := ui.font(dui)
 		mX := m.X - space.X - ui.prevTextOffset
+		if !ui.Password {
+			return ui.bidi(dui, ui.Text).offsetAt(mX)
+		}
 		x := 0
-		for i, c := range ui.Text {
-			if ui.Password {
-				c = '•'
-			}
-			dx := f.StringWidth(string(c))
+		for i := range ui.Text {
+			dx := f.StringWidth("•")
 			if mX <= x+dx/2 {
 				return i
 			}
@@ -325,7 +367,12 @@ func (ui *Field) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig
 		return len(ui.Text)
 	}
 	if ui.m.Buttons&1 == 0 && m.Buttons&1 == 1 {
-		// b1 down, start selection
+		// b1 down, start selection, and leave vi modes like Edit
+		edit := ui.ensureEdit(dui)
+		edit.text.closeHist(edit)
+		edit.mode = modeInsert
+		edit.command = ""
+		edit.visual = ""
 		ui.Cursor1 = 1 + locateCursor()
 		ui.SelectionStart1 = ui.Cursor1
 		r.Consumed = true
@@ -337,9 +384,10 @@ func (ui *Field) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig
 		self.Draw = Dirty
 		if ui.m.Buttons&1 == 1 && m.Buttons&1 == 0 {
 			if m.Msec-ui.prevB1Release.Msec < 400 {
-				s, e := expandSelection(ui.Text, ui.cursor0())
-				ui.Cursor1 = 1 + s
-				ui.SelectionStart1 = 1 + e
+				edit := ui.ensureEdit(dui)
+				o := edit.cursor.Cur
+				edit.cursor = edit.expand(o, edit.reader(o, edit.text.Size()), edit.revReader(o))
+				ui.fromEdit()
 			}
 			ui.prevB1Release = m
 		}
@@ -378,133 +426,56 @@ func (ui *Field) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point
 	}
 
 	origText := ui.Text
-
-	const Ctrl = 0x1f
 	ui.fixCursor()
-	cursor0 := ui.cursor0()
-
-	cursorPrev := func() int {
-		_, n := utf8.DecodeLastRuneInString(ui.Text[:cursor0])
-		return cursor0 - n
-	}
-	cursorNext := func() int {
-		_, n := utf8.DecodeRuneInString(ui.Text[cursor0:])
-		return cursor0 + n
-	}
-	removeSelection := func() int {
-		ui.removeSelection()
-		ui.fixCursor()
-		return ui.cursor0()
+	origCursor1, origSelectionStart1 := ui.Cursor1, ui.SelectionStart1
+	ui.anchor = rect(ui.size).Add(orig)
+	if e := ui.completeKey(dui, k); e.Consumed {
+		propagateEvent(self, &r, e)
+		return
 	}
+	edit := ui.ensureEdit(dui)
+	edit.rejected = false
+
+	const Ctrl = 0x1f
 	switch k {
-	case draw.KeyPageUp, draw.KeyPageDown, draw.KeyUp, draw.KeyDown, '\t':
+	case draw.KeyPageUp, draw.KeyPageDown, draw.KeyUp, draw.KeyDown, '\t', '\n':
 		return
-	case draw.KeyLeft:
-		cursor0 = cursorPrev()
-		ui.SelectionStart1 = 0
-	case draw.KeyRight:
-		cursor0 = cursorNext()
-		ui.SelectionStart1 = 0
-	case Ctrl & 'a', draw.KeyHome:
-		cursor0 = 0
-		ui.SelectionStart1 = 0
-	case Ctrl & 'e', draw.KeyEnd:
-		cursor0 = len(ui.Text)
-		ui.SelectionStart1 = 0
-
-	case Ctrl & 'h':
-		// remove char before cursor0
-		cursor0 = removeSelection()
-		if cursor0 > 0 {
-			prev := cursorPrev()
-			ui.Text = ui.Text[:cursorPrev()] + ui.Text[cursor0:]
-			cursor0 = prev
-		}
-	case Ctrl & 'w':
-		// remove to start of space+word
-		cursor0 = removeSelection()
-		for cursor0 > 0 && strings.ContainsAny(ui.Text[cursorPrev():cursor0], " \t\r\n") {
-			cursor0 = cursorPrev()
-		}
-		for cursor0 > 0 && !strings.ContainsAny(ui.Text[cursorPrev():cursor0], " \t\r\n") {
-			cursor0 = cursorPrev()
-		}
-		ui.Text = ui.Text[:cursor0]
-	case Ctrl & 'u':
-		// remove entire line
-		cursor0 = removeSelection()
-		ui.Text = ""
-		cursor0 = 0
-	case Ctrl & 'k':
-		// remove to end of line
-		cursor0 = removeSelection()
-		ui.Text = ui.Text[:cursor0]
-
-	case draw.KeyDelete:
-		// remove char after cursor0
-		cursor0 = removeSelection()
-		if cursor0 < len(ui.Text) {
-			ui.Text = ui.Text[:cursor0] + ui.Text[cursorNext():]
-		}
-
-	case draw.KeyCmd + 'a':
-		// select all
-		cursor0 = 0
-		ui.SelectionStart1 = 1 + len(ui.Text)
-
-	case draw.KeyCmd + 'c':
-		_, _, t := ui.selection0()
-		if t != "" {
-			dui.WriteSnarf([]byte(t))
-		}
-
-	case draw.KeyCmd + 'x':
-		s, e, t := ui.selection0()
-		if t != "" {
-			dui.WriteSnarf([]byte(t))
-			ui.Text = ui.Text[:s] + ui.Text[e:]
-			cursor0 = s
-			ui.SelectionStart1 = 0
-		}
-
-	case draw.KeyCmd + 'v':
-		cursor0 = removeSelection()
-		buf, ok := dui.ReadSnarf()
-		if ok {
-			t := string(buf)
-			ui.Text = ui.Text[:cursor0] + t + ui.Text[cursor0:]
-			ui.SelectionStart1 = 1 + cursor0
-			cursor0 = 1 + cursor0 + len(t)
-		}
-
+	case draw.KeyHome:
+		k = Ctrl & 'a'
+	case draw.KeyEnd:
+		k = Ctrl & 'e'
 	case draw.KeyCmd + 'm':
 		p := ui.lastCursorPoint.Add(orig)
 		r.Warp = &p
-
-	case '\n':
+		r.Consumed = true
 		return
-
-	default:
-		if k >= draw.KeyCmd && k < draw.KeyCmd+128 {
+	case draw.KeyLeft, draw.KeyRight:
+		if edit.mode == modeInsert {
+			dir := 1
+			if k == draw.KeyLeft {
+				dir = -1
+			}
+			ui.Cursor1 = 1 + ui.cursorMove(dui, ui.cursor0(), dir)
+			ui.SelectionStart1 = 0
+			r.Consumed = true
+			self.Draw = Dirty
 			return
 		}
-		cursor0 = removeSelection()
-		ks := string(k)
-		if cursor0 >= len(ui.Text) {
-			ui.Text += ks
-		} else {
-			ui.Text = ui.Text[:cursor0] + ks + ui.Text[cursor0:]
-		}
-		cursor0 += len(ks)
 	}
-	ui.Cursor1 = 1 + cursor0
+	edit.key(dui, self, k, orig, &r)
+	ui.fromEdit()
+	if edit.rejected && ui.Text == origText {
+		// cursor was moved as if the text was inserted
+		ui.Cursor1, ui.SelectionStart1 = origCursor1, origSelectionStart1
+	}
 	ui.fixCursor()
-	r.Consumed = true
-	self.Draw = Dirty
 	if ui.Changed != nil && origText != ui.Text {
 		e := ui.Changed(ui.Text)
 		propagateEvent(self, &r, e)
 	}
+	if (ui.Complete != nil || ui.CompleteChan != nil) && origText != ui.Text {
+		ui.complete(dui)
+	}
 	return
 }
 
@@ -0,0 +1,187 @@
+package duit
+
+import (
+	"context"
+	"strings"
+
+	"9fans.net/go/draw"
+)
+
+// fieldCompleteRows is the number of suggestions shown at a time. Arrow keys scroll through the others.
+const fieldCompleteRows = 10
+
+// fieldCompletion holds the suggestions of a Field, and the overlay showing them.
+type fieldCompletion struct {
+	overlay     Overlay
+	list        List
+	suggestions []string // Filtered, all of them.
+	first       int      // Index in suggestions of the first value in list.
+	selected    int      // Index in suggestions, -1 for none.
+	cancel      context.CancelFunc
+}
+
+// complete asks Complete or CompleteChan for suggestions for the current text, cancelling an earlier request.
+func (ui *Field) complete(dui *DUI) {
+	c := &ui.completion
+	if c.cancel != nil {
+		c.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	text := ui.Text
+	deliver := func(l []string) {
+		dui.Call <- func() {
+			if ctx.Err() == nil {
+				ui.suggest(dui, l)
+			}
+		}
+	}
+	if ui.Complete != nil {
+		go func() {
+			deliver(ui.Complete(ctx, text))
+		}()
+		return
+	}
+	sc := ui.CompleteChan(text)
+	go func() {
+		// after cancellation, suggestions are read until the channel is closed, so the provider does not block on a send
+		for l := range sc {
+			if ctx.Err() == nil {
+				deliver(l)
+			}
+		}
+	}()
+}
+
+// suggest shows the suggestions from l that contain the text, ignoring case, or closes the list if there are none.
+func (ui *Field) suggest(dui *DUI, l []string) {
+	c := &ui.completion
+	text := strings.ToLower(ui.Text)
+	c.suggestions = nil
+	for _, s := range l {
+		if strings.Contains(strings.ToLower(s), text) {
+			c.suggestions = append(c.suggestions, s)
+		}
+	}
+	if len(c.suggestions) == 0 {
+		dui.CloseOverlay(&c.overlay)
+		return
+	}
+	c.first = 0
+	c.selected = -1
+	if c.overlay.UI == nil {
+		c.list.Font = ui.Font
+		c.list.Click = func(index int, m draw.Mouse) (e Event) {
+			e.Consumed = true
+			if m.Buttons == Button1 {
+				e := ui.acceptSuggestion(dui, c.first+index)
+				if e.NeedLayout {
+					dui.MarkLayout(ui)
+				} else {
+					dui.MarkDraw(ui)
+				}
+			}
+			return
+		}
+		c.list.Keys = func(k rune, m draw.Mouse) (e Event) {
+			e = ui.completeKey(dui, k)
+			if e.NeedLayout {
+				dui.MarkLayout(ui)
+			} else if e.NeedDraw {
+				dui.MarkDraw(ui)
+			}
+			e.NeedLayout = false
+			e.NeedDraw = false
+			return
+		}
+		c.overlay.UI = &c.list
+		c.overlay.Dismiss = func() {
+			ui.closeCompletion(dui)
+		}
+	}
+	c.overlay.Anchor = ui.anchor
+	ui.showSuggestions(dui)
+}
+
+// showSuggestions puts the suggestions in view in the list, and opens or updates the overlay.
+func (ui *Field) showSuggestions(dui *DUI) {
+	c := &ui.completion
+	if c.selected >= 0 && c.selected < c.first {
+		c.first = c.selected
+	} else if c.selected >= c.first+fieldCompleteRows {
+		c.first = c.selected - fieldCompleteRows + 1
+	}
+	c.list.Values = nil
+	for i := c.first; i < len(c.suggestions) && i < c.first+fieldCompleteRows; i++ {
+		c.list.Values = append(c.list.Values, &ListValue{Text: c.suggestions[i], Selected: i == c.selected})
+	}
+	dui.OpenOverlay(&c.overlay)
+}
+
+// closeCompletion cancels a pending request for suggestions, and closes the list.
+func (ui *Field) closeCompletion(dui *DUI) {
+	c := &ui.completion
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+	c.suggestions = nil
+	dui.CloseOverlay(&c.overlay)
+}
+
+// completeKey handles the keys for navigating the list of suggestions, if it is open. The returned event is consumed if the key was handled.
+// Changed is called for an accepted suggestion, and its event returned.
+func (ui *Field) completeKey(dui *DUI, k rune) (e Event) {
+	c := &ui.completion
+	if len(c.suggestions) == 0 {
+		return
+	}
+	switch k {
+	case draw.KeyDown:
+		c.selected = minimum(c.selected+1, len(c.suggestions)-1)
+	case draw.KeyUp:
+		if c.selected < 0 {
+			c.selected = len(c.suggestions) - 1
+		} else {
+			c.selected = maximum(0, c.selected-1)
+		}
+	case draw.KeyPageDown:
+		c.selected = minimum(c.selected+fieldCompleteRows, len(c.suggestions)-1)
+	case draw.KeyPageUp:
+		c.selected = maximum(0, c.selected-fieldCompleteRows)
+	case '\n':
+		if c.selected < 0 {
+			ui.closeCompletion(dui)
+			return
+		}
+		e = ui.acceptSuggestion(dui, c.selected)
+		e.Consumed = true
+		return
+	case draw.KeyEscape:
+		ui.closeCompletion(dui)
+		e.Consumed = true
+		return
+	default:
+		return
+	}
+	ui.showSuggestions(dui)
+	e.Consumed = true
+	return
+}
+
+// acceptSuggestion replaces the text with the suggestion at index, as a single undoable change, closes the list and calls Changed.
+func (ui *Field) acceptSuggestion(dui *DUI, index int) (e Event) {
+	s := ui.completion.suggestions[index]
+	ui.closeCompletion(dui)
+	edit := ui.ensureEdit(dui)
+	edit.text.closeHist(edit)
+	edit.text.Replace(edit, &edit.dirty, Cursor{0, edit.text.Size()}, []byte(s), false)
+	edit.cursor = Cursor{edit.text.Size(), edit.text.Size()}
+	origText := ui.Text
+	ui.fromEdit()
+	if ui.Changed != nil && origText != ui.Text {
+		e = ui.Changed(ui.Text)
+	}
+	e.NeedDraw = true
+	return
+}
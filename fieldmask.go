@@ -0,0 +1,65 @@
+package duit
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// FieldMask restricts the text that can be entered in a Field.
+// It is called with the text as it would be after typing, pasting or deleting text, and returns whether that text is allowed.
+// Masks must allow partial input, such as the first digits of a date, or the text could never be typed.
+type FieldMask func(text string) bool
+
+// MaskDigits allows only the digits 0 through 9.
+func MaskDigits(text string) bool {
+	for _, c := range text {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MaskPattern returns a mask allowing text that matches the start of pattern, character by character.
+// In pattern, 9 matches a digit, a matches a letter and * matches any character. Other characters must be typed as is.
+// For example, "9999-99-99" allows dates like 2018-03-14, and its partial input.
+func MaskPattern(pattern string) FieldMask {
+	return func(text string) bool {
+		if utf8.RuneCountInString(text) > utf8.RuneCountInString(pattern) {
+			return false
+		}
+		p := []rune(pattern)
+		i := 0
+		for _, c := range text {
+			switch p[i] {
+			case '9':
+				if c < '0' || c > '9' {
+					return false
+				}
+			case 'a':
+				if !unicode.IsLetter(c) {
+					return false
+				}
+			case '*':
+			default:
+				if c != p[i] {
+					return false
+				}
+			}
+			i++
+		}
+		return true
+	}
+}
+
+// MaskAll returns a mask allowing only text that is allowed by all masks.
+func MaskAll(masks ...FieldMask) FieldMask {
+	return func(text string) bool {
+		for _, mask := range masks {
+			if !mask(text) {
+				return false
+			}
+		}
+		return true
+	}
+}
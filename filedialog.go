@@ -0,0 +1,560 @@
+package duit
+
+import (
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"9fans.net/go/draw"
+)
+
+// FileFilter limits the files shown in a FileDialog to those with one of its extensions.
+type FileFilter struct {
+	Name       string   // Shown in the list of filters, e.g. "Images".
+	Extensions []string // Including the dot, e.g. ".png", matched ignoring case. If empty, all files match.
+}
+
+func (f FileFilter) match(name string) bool {
+	if len(f.Extensions) == 0 {
+		return true
+	}
+	name = strings.ToLower(name)
+	for _, ext := range f.Extensions {
+		if strings.HasSuffix(name, strings.ToLower(ext)) {
+			return true
+		}
+	}
+	return false
+}
+
+// FileDialog lets the user choose files to open, or a file name to save to.
+// The directory is shown as a breadcrumb of buttons for the directory and its parents, above a Gridlist of its files with name, size and modification time.
+// Below the list is a field for the file name, which completes names of files while typing.
+// Entering the path of a directory in the field, or double-clicking a directory in the list, shows that directory.
+// Files are limited to those matching the selected filter. Hidden files, starting with a dot, are only shown on request.
+// The "new folder" button creates a directory with the name in the field.
+//
+// Show the dialog in a modal overlay with Open, or in a separate window with Window.
+//
+// Keys:
+//	\n, choose the file in the field or the files selected in the list, or show the directory
+//	escape, cancel, also in the file name field
+type FileDialog struct {
+	Dir      string                         // Directory shown, updated when the user changes directories. Empty means the working directory.
+	Name     string                         // Initial file name in the field, e.g. for a save dialog.
+	Save     bool                           // Whether a single file name is chosen to save to, which need not exist. Otherwise existing files are chosen to open.
+	Multiple bool                           // Whether multiple files can be chosen to open.
+	Filters  []FileFilter                   // Filters to choose from, the first is selected initially. If empty, all files are shown.
+	Hidden   bool                           // Whether hidden files are shown, updated when the user toggles it.
+	Font     *draw.Font                     `json:"-"` // For drawing text.
+	Done     func(paths []string) (e Event) `json:"-"` // Called with the chosen paths, or with nil when the dialog is cancelled.
+
+	kids      []*Kid // Breadcrumb, list of files, controls.
+	size      image.Point
+	crumbs    Box
+	list      Gridlist
+	scroll    Scroll
+	name      Field
+	filter    Dropdown
+	hidden    Checkbox
+	status    Label // For errors.
+	controls  Box
+	overlay   Overlay
+	done      func(paths []string) // Set by Window.
+	prevClick draw.Mouse           // Last button1 click in the list, to detect double clicks.
+	prevIndex int
+}
+
+var _ UI = &FileDialog{}
+
+// ensure creates the UIs of the dialog, and reads the directory.
+func (ui *FileDialog) ensure(dui *DUI) {
+	if ui.kids != nil {
+		return
+	}
+
+	ui.crumbs = Box{Width: -1, Padding: SpaceXY(6, 4), Margin: image.Pt(2, 2)}
+
+	ui.list = Gridlist{
+		Header:   &Gridrow{Values: []string{"Name", "Size", "Modified"}},
+		Multiple: ui.Multiple && !ui.Save,
+		Halign:   []Halign{HalignLeft, HalignRight, HalignLeft},
+		Padding:  SpaceXY(6, 2),
+		Striped:  true,
+		Font:     ui.Font,
+		Changed: func(index int) (e Event) {
+			ui.listChanged(dui)
+			return
+		},
+		Click: func(index int, m draw.Mouse) (e Event) {
+			return ui.listClick(dui, index, m)
+		},
+	}
+	ui.scroll = Scroll{Height: -1, Kid: Kid{UI: &ui.list}}
+
+	ui.name = Field{
+		Text:        ui.Name,
+		Placeholder: "file name",
+		Font:        ui.Font,
+		CompleteChan: func(text string) <-chan []string {
+			c := make(chan []string, 1)
+			dir, hidden, filter := ui.Dir, ui.Hidden, ui.activeFilter()
+			go func() {
+				c <- completeFileName(dir, text, hidden, filter)
+				close(c)
+			}()
+			return c
+		},
+	}
+
+	ui.filter = Dropdown{
+		Selected: -1,
+		Font:     ui.Font,
+		Changed: func(index int, value interface{}) (e Event) {
+			ui.setDir(dui, ui.Dir)
+			return
+		},
+	}
+	for _, f := range ui.Filters {
+		ui.filter.Values = append(ui.filter.Values, &ListValue{Text: f.Name})
+	}
+	if len(ui.Filters) > 0 {
+		ui.filter.Selected = 0
+	}
+
+	ui.hidden = Checkbox{
+		Checked: ui.Hidden,
+		Font:    ui.Font,
+		Changed: func() (e Event) {
+			ui.Hidden = ui.hidden.Checked
+			ui.setDir(dui, ui.Dir)
+			return
+		},
+	}
+	hiddenLabel := &Label{
+		Text: "show hidden files",
+		Font: ui.Font,
+		Click: func() (e Event) {
+			ui.hidden.Checked = !ui.hidden.Checked
+			ui.Hidden = ui.hidden.Checked
+			ui.setDir(dui, ui.Dir)
+			return
+		},
+	}
+
+	ui.status = Label{Font: ui.Font}
+
+	okText := "Open"
+	if ui.Save {
+		okText = "Save"
+	}
+	ui.controls = Box{
+		Width:   -1,
+		Padding: SpaceXY(6, 4),
+		Margin:  image.Pt(6, 4),
+		Valign:  ValignMiddle,
+		Kids: []*Kid{
+			{UI: &ui.name},
+			{UI: &ui.filter, Hidden: len(ui.Filters) == 0},
+			{UI: &ui.hidden},
+			{UI: hiddenLabel},
+			{UI: &Button{Text: "New folder", Font: ui.Font, Click: func() (e Event) {
+				ui.mkdir(dui)
+				return
+			}}},
+			{UI: &Button{Text: "Cancel", Font: ui.Font, Click: func() (e Event) {
+				return ui.finish(dui, nil)
+			}}},
+			{UI: &Button{Text: okText, Font: ui.Font, Colorset: &dui.Primary, Click: func() (e Event) {
+				return ui.accept(dui)
+			}}},
+			{UI: &ui.status},
+		},
+	}
+
+	ui.kids = []*Kid{{UI: &ui.crumbs}, {UI: &ui.scroll}, {UI: &ui.controls}}
+	ui.load(dui)
+}
+
+func (ui *FileDialog) activeFilter() FileFilter {
+	if ui.filter.Selected >= 0 && ui.filter.Selected < len(ui.Filters) {
+		return ui.Filters[ui.filter.Selected]
+	}
+	return FileFilter{}
+}
+
+// path returns the path for name, relative to the directory unless absolute.
+func (ui *FileDialog) path(name string) string {
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+	return filepath.Join(ui.Dir, name)
+}
+
+// load reads the directory into the breadcrumb and list.
+func (ui *FileDialog) load(dui *DUI) {
+	if dir, err := filepath.Abs(ui.Dir); err == nil {
+		ui.Dir = dir
+	}
+
+	var dirs []string
+	for p := ui.Dir; ; p = filepath.Dir(p) {
+		dirs = append([]string{p}, dirs...)
+		if filepath.Dir(p) == p {
+			break
+		}
+	}
+	ui.crumbs.Kids = nil
+	for _, p := range dirs {
+		p := p
+		button := &Button{
+			Text: filepath.Base(p),
+			Font: ui.Font,
+			Click: func() (e Event) {
+				ui.setDir(dui, p)
+				return
+			},
+		}
+		ui.crumbs.Kids = append(ui.crumbs.Kids, &Kid{UI: button})
+	}
+
+	ui.status.Text = ""
+	ui.list.Rows = nil
+	ui.list.colWidths = nil
+	files, err := ioutil.ReadDir(ui.Dir)
+	if err != nil {
+		ui.status.Text = err.Error()
+		return
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].IsDir() && !files[j].IsDir()
+	})
+	filter := ui.activeFilter()
+	for _, fi := range files {
+		name := fi.Name()
+		if !ui.Hidden && strings.HasPrefix(name, ".") || !fi.IsDir() && !filter.match(name) {
+			continue
+		}
+		size := ""
+		if fi.IsDir() {
+			name += string(filepath.Separator)
+		} else {
+			size = formatFileSize(fi.Size())
+		}
+		row := &Gridrow{
+			Values: []string{name, size, fi.ModTime().Format("2006-01-02 15:04")},
+			Value:  fi,
+		}
+		ui.list.Rows = append(ui.list.Rows, row)
+	}
+}
+
+// setDir shows the files of dir.
+func (ui *FileDialog) setDir(dui *DUI, dir string) {
+	ui.Dir = dir
+	ui.load(dui)
+	ui.scroll.offset = 0
+	dui.MarkLayout(ui)
+}
+
+func (ui *FileDialog) setName(dui *DUI, name string) {
+	ui.name.Text = name
+	ui.name.Cursor1 = 0
+	ui.name.SelectionStart1 = 0
+	dui.MarkDraw(&ui.name)
+}
+
+func (ui *FileDialog) fail(dui *DUI, msg string) {
+	ui.status.Text = msg
+	dui.MarkLayout(ui)
+}
+
+// listChanged puts the name of a single selected file in the field.
+func (ui *FileDialog) listChanged(dui *DUI) {
+	sel := ui.list.Selected()
+	if len(sel) > 1 {
+		ui.setName(dui, "")
+	} else if len(sel) == 1 {
+		if fi := ui.list.Rows[sel[0]].Value.(os.FileInfo); !fi.IsDir() {
+			ui.setName(dui, fi.Name())
+		}
+	}
+}
+
+// listClick shows the directory, or chooses the file, that is double-clicked.
+func (ui *FileDialog) listClick(dui *DUI, index int, m draw.Mouse) (e Event) {
+	if m.Buttons != Button1 {
+		return
+	}
+	double := index == ui.prevIndex && m.Msec-ui.prevClick.Msec < 400
+	ui.prevClick, ui.prevIndex = m, index
+	if !double {
+		return
+	}
+	ui.prevClick = draw.Mouse{}
+	fi := ui.list.Rows[index].Value.(os.FileInfo)
+	path := filepath.Join(ui.Dir, fi.Name())
+	if fi.IsDir() {
+		ui.setDir(dui, path)
+	} else {
+		e = ui.finish(dui, []string{path})
+	}
+	e.Consumed = true
+	return
+}
+
+// accept chooses the file in the field, or the files selected in the list.
+// A directory is shown instead of chosen.
+func (ui *FileDialog) accept(dui *DUI) (e Event) {
+	if ui.name.Text != "" {
+		path := ui.path(ui.name.Text)
+		fi, err := os.Stat(path)
+		if err == nil && fi.IsDir() {
+			ui.setName(dui, "")
+			ui.setDir(dui, path)
+			return
+		}
+		if ui.Save {
+			if exts := ui.activeFilter().Extensions; filepath.Ext(path) == "" && len(exts) > 0 {
+				path += exts[0]
+			}
+			return ui.finish(dui, []string{path})
+		}
+		if err != nil {
+			ui.fail(dui, err.Error())
+			return
+		}
+		return ui.finish(dui, []string{path})
+	}
+
+	var paths []string
+	for _, index := range ui.list.Selected() {
+		fi := ui.list.Rows[index].Value.(os.FileInfo)
+		path := filepath.Join(ui.Dir, fi.Name())
+		if fi.IsDir() {
+			if len(ui.list.Selected()) == 1 {
+				ui.setDir(dui, path)
+				return
+			}
+			continue
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 || ui.Save {
+		ui.fail(dui, "type a file name, or select a file")
+		return
+	}
+	return ui.finish(dui, paths)
+}
+
+// mkdir creates a directory with the name in the field, and shows it.
+func (ui *FileDialog) mkdir(dui *DUI) {
+	if ui.name.Text == "" {
+		ui.fail(dui, "type a name for the new folder")
+		return
+	}
+	path := ui.path(ui.name.Text)
+	if err := os.Mkdir(path, os.ModePerm); err != nil {
+		ui.fail(dui, err.Error())
+		return
+	}
+	ui.setName(dui, "")
+	ui.setDir(dui, path)
+}
+
+// finish closes the overlay, and reports the chosen paths, nil for cancel.
+func (ui *FileDialog) finish(dui *DUI, paths []string) (e Event) {
+	dui.CloseOverlay(&ui.overlay)
+	if ui.done != nil {
+		ui.done(paths)
+	}
+	if ui.Done != nil {
+		e = ui.Done(paths)
+	}
+	return
+}
+
+// Open shows the dialog in a modal overlay in the window of dui, with the mouse on the file name field.
+// The overlay is closed before Done is called.
+func (ui *FileDialog) Open(dui *DUI) {
+	ui.kids = nil
+	ui.ensure(dui)
+	ui.overlay = Overlay{
+		Kid:   Kid{UI: ui},
+		Modal: true,
+		Dismiss: func() {
+			ui.finish(dui, nil)
+		},
+	}
+	dui.OpenOverlay(&ui.overlay)
+	dui.Focus(&ui.name)
+}
+
+// Window shows the dialog in a new window, and returns when the user is done with it, like Alert.
+// The chosen paths are returned, or nil if the dialog was cancelled or the window closed. Done, if set, is called too.
+func (ui *FileDialog) Window(title string) (paths []string, err error) {
+	stop := make(chan struct{}, 1)
+
+	dui, err := NewDUI(title, &DUIOpts{Dimensions: "640x480"})
+	if err != nil {
+		return nil, fmt.Errorf("new file dialog window: %s", err)
+	}
+
+	ui.kids = nil
+	ui.done = func(l []string) {
+		paths = l
+		stop <- struct{}{}
+	}
+	defer func() {
+		ui.done = nil
+	}()
+	dui.Top.UI = ui
+	dui.Render()
+	dui.Focus(&ui.name)
+
+	for {
+		select {
+		case e := <-dui.Inputs:
+			dui.Input(e)
+
+		case xerr, ok := <-dui.Error:
+			if !ok {
+				return
+			}
+			dui.Close()
+			return nil, xerr
+
+		case <-stop:
+			dui.Close()
+			return
+		}
+	}
+}
+
+// completeFileName returns the files in dir, or in the directory of text, starting with the name in text.
+// Directories end with a path separator, so completion can continue in them.
+func completeFileName(dir, text string, hidden bool, filter FileFilter) (l []string) {
+	textDir, prefix := filepath.Split(text)
+	readDir := textDir
+	if !filepath.IsAbs(readDir) {
+		readDir = filepath.Join(dir, readDir)
+	}
+	files, err := ioutil.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+	for _, fi := range files {
+		name := fi.Name()
+		if !strings.HasPrefix(name, prefix) || !hidden && strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		if fi.IsDir() {
+			name += string(filepath.Separator)
+		} else if !filter.match(name) {
+			continue
+		}
+		l = append(l, textDir+name)
+	}
+	return
+}
+
+func formatFileSize(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	v := float64(n) / 1024
+	for _, unit := range []string{"KB", "MB", "GB"} {
+		if v < 1024 {
+			return fmt.Sprintf("%.1f %s", v, unit)
+		}
+		v /= 1024
+	}
+	return fmt.Sprintf("%.1f TB", v)
+}
+
+func (ui *FileDialog) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	ui.ensure(dui)
+	dui.debugLayout(self)
+
+	if KidsLayout(dui, self, ui.kids, force) {
+		return
+	}
+
+	// the list gets the height that is left after the breadcrumb and controls
+	crumbs, list, controls := ui.kids[0], ui.kids[1], ui.kids[2]
+	crumbs.UI.Layout(dui, crumbs, sizeAvail, true)
+	controls.UI.Layout(dui, controls, sizeAvail, true)
+	listAvail := image.Pt(sizeAvail.X, maximum(0, sizeAvail.Y-crumbs.R.Dy()-controls.R.Dy()))
+	list.UI.Layout(dui, list, listAvail, true)
+	list.R = list.R.Add(image.Pt(0, crumbs.R.Max.Y))
+	controls.R = controls.R.Add(image.Pt(0, list.R.Max.Y))
+	ui.size = image.Pt(sizeAvail.X, controls.R.Max.Y)
+	self.R = rect(ui.size)
+}
+
+// Measure returns a size fit for a dialog as preferred size, limited to sizeAvail, and room for a few files as minimum.
+func (ui *FileDialog) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	ui.ensure(dui)
+	crumbs := KidMeasure(dui, ui.kids[0], sizeAvail)
+	controls := KidMeasure(dui, ui.kids[2], sizeAvail)
+	min := image.Pt(maximum(crumbs.Min.X, controls.Min.X), crumbs.Min.Y+controls.Min.Y+4*ui.list.rowHeight(dui))
+	pref := image.Pt(minimum(sizeAvail.X, dui.Scale(640)), minimum(sizeAvail.Y, dui.Scale(480)))
+	pref = image.Pt(maximum(min.X, pref.X), maximum(min.Y, pref.Y))
+	return Sizes{min, pref, image.Pt(maximum(pref.X, sizeAvail.X), maximum(pref.Y, sizeAvail.Y))}
+}
+
+func (ui *FileDialog) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	ui.ensure(dui)
+	dui.debugDraw(self)
+	KidsDraw(dui, self, ui.kids, ui.size, nil, img, orig, m, force)
+}
+
+func (ui *FileDialog) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	ui.ensure(dui)
+	return KidsMouse(dui, self, ui.kids, m, origM, orig)
+}
+
+func (ui *FileDialog) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	ui.ensure(dui)
+	// escape cancels before the field can use it for vi mode, unless it closes the suggestions or the filters
+	if k == draw.KeyEscape && len(ui.name.completion.suggestions) == 0 && !ui.filter.open {
+		e := ui.finish(dui, nil)
+		propagateEvent(self, &r, e)
+		r.Consumed = true
+		return
+	}
+	r = KidsKey(dui, self, ui.kids, k, m, orig)
+	if r.Consumed {
+		return
+	}
+	if k == '\n' {
+		e := ui.accept(dui)
+		propagateEvent(self, &r, e)
+		r.Consumed = true
+	}
+	return
+}
+
+// FirstFocus returns the file name field.
+func (ui *FileDialog) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	ui.ensure(dui)
+	return KidsFocus(dui, self, ui.kids, &ui.name)
+}
+
+func (ui *FileDialog) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	ui.ensure(dui)
+	return KidsFocus(dui, self, ui.kids, o)
+}
+
+func (ui *FileDialog) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return KidsMark(self, ui.kids, o, forLayout)
+}
+
+func (ui *FileDialog) Print(self *Kid, indent int) {
+	PrintUI("FileDialog", self, indent)
+	KidsPrint(ui.kids, indent+1)
+}
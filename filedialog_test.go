@@ -0,0 +1,26 @@
+package duit
+
+import (
+	"image"
+	"testing"
+
+	"9fans.net/go/draw"
+)
+
+func TestFileDialogEscape(t *testing.T) {
+	dui := &DUI{}
+	cancelled := false
+	ui := &FileDialog{
+		Done: func(paths []string) (e Event) {
+			cancelled = paths == nil
+			return
+		},
+	}
+	// with kids set, the dialog is not built, only the field is under the mouse
+	ui.name.size = image.Pt(100, 20)
+	ui.kids = []*Kid{{UI: &ui.name, R: image.Rect(0, 0, 100, 20)}}
+	r := ui.Key(dui, &Kid{UI: ui}, draw.KeyEscape, draw.Mouse{Point: image.Pt(10, 10)}, image.ZP)
+	if !r.Consumed || !cancelled {
+		t.Fatalf("escape over the field: consumed %v, cancelled %v, expected both", r.Consumed, cancelled)
+	}
+}
@@ -8,6 +8,7 @@ import (
 )
 
 // Grid lays out other UIs in a table-like grid.
+// With DUI.RTL set, the first column is at the right, and horizontal alignment is mirrored.
 type Grid struct {
 	Kids       []*Kid      // Holds UIs in the grid, per row.
 	Columns    int         // Number of clumns.
@@ -48,32 +49,13 @@ func (ui *Grid) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 		ui.size.X = scaledWidth
 	}
 
-	ui.widths = make([]int, ui.Columns) // widths include padding
-	spaces := make([]Space, ui.Columns)
-	if ui.Padding != nil {
-		for i, pad := range ui.Padding {
-			spaces[i] = dui.ScaleSpace(pad)
-		}
-	}
-	width := 0                       // total width so far
-	x := make([]int, len(ui.widths)) // x offsets per column
-	x[0] = 0
-
 	// first determine the column widths
-	for col := 0; col < ui.Columns; col++ {
-		if col > 0 {
-			x[col] = x[col-1] + ui.widths[col-1]
-		}
-		ui.widths[col] = 0
-		newDx := 0
-		space := spaces[col]
-		for i := col; i < len(ui.Kids); i += ui.Columns {
-			k := ui.Kids[i]
-			k.UI.Layout(dui, k, image.Pt(sizeAvail.X-width-space.Dx(), sizeAvail.Y-space.Dy()), true)
-			newDx = maximum(newDx, k.R.Dx()+space.Dx())
-		}
-		ui.widths[col] = newDx
-		width += ui.widths[col]
+	spaces := ui.spaces(dui)
+	ui.widths = ui.columns(dui, sizeAvail, spaces).Pref
+	width := sum(ui.widths)
+	x := make([]int, len(ui.widths)) // x offsets per column
+	for col := 1; col < len(x); col++ {
+		x[col] = x[col-1] + ui.widths[col-1]
 	}
 	if scaledWidth < 0 && width < sizeAvail.X {
 		leftover := sizeAvail.X - width
@@ -105,6 +87,9 @@ func (ui *Grid) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 		for col := 0; col < ui.Columns; col++ {
 			space := spaces[col]
 			k := ui.Kids[i+col]
+			if k.Hidden {
+				continue
+			}
 			k.UI.Layout(dui, k, image.Pt(ui.widths[col]-space.Dx(), sizeAvail.Y-y[row]-space.Dy()), true)
 			offset := image.Pt(x[col], y[row]).Add(space.Topleft())
 			k.R = k.R.Add(offset) // aligned in top left, fixed for halign/valign later on
@@ -116,6 +101,9 @@ func (ui *Grid) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 
 	// now shift the kids for right valign/halign
 	for i, k := range ui.Kids {
+		if k.Hidden {
+			continue
+		}
 		row := i / ui.Columns
 		col := i % ui.Columns
 		space := spaces[col]
@@ -152,9 +140,121 @@ func (ui *Grid) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 	if ui.Width < 0 && ui.size.X < sizeAvail.X {
 		ui.size.X = sizeAvail.X
 	}
+	if dui.RTL {
+		kidsMirror(ui.Kids, ui.size.X)
+	}
 	self.R = rect(ui.size)
 }
 
+func (ui *Grid) spaces(dui *DUI) []Space {
+	spaces := make([]Space, ui.Columns)
+	if ui.Padding != nil {
+		for i, pad := range ui.Padding {
+			spaces[i] = dui.ScaleSpace(pad)
+		}
+	}
+	return spaces
+}
+
+// columnSizes holds widths per column, including padding.
+type columnSizes struct {
+	Min, Pref, Max []int
+}
+
+func sum(l []int) int {
+	n := 0
+	for _, v := range l {
+		n += v
+	}
+	return n
+}
+
+// columns measures the kids of each column, returning the widths of the columns.
+func (ui *Grid) columns(dui *DUI, sizeAvail image.Point, spaces []Space) (widths columnSizes) {
+	widths = columnSizes{make([]int, ui.Columns), make([]int, ui.Columns), make([]int, ui.Columns)}
+	width := 0 // total preferred width so far
+	for col := 0; col < ui.Columns; col++ {
+		space := spaces[col]
+		for i := col; i < len(ui.Kids); i += ui.Columns {
+			k := ui.Kids[i]
+			if k.Hidden {
+				continue
+			}
+			s := KidMeasure(dui, k, image.Pt(sizeAvail.X-width-space.Dx(), sizeAvail.Y-space.Dy()))
+			widths.Min[col] = maximum(widths.Min[col], s.Min.X+space.Dx())
+			widths.Pref[col] = maximum(widths.Pref[col], s.Pref.X+space.Dx())
+			widths.Max[col] = maximum(widths.Max[col], s.Max.X+space.Dx())
+		}
+		width += widths.Pref[col]
+	}
+	return widths
+}
+
+// Measure determines column widths like Layout, and row heights from the preferred sizes of the kids.
+func (ui *Grid) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	if ui.Columns == 0 || len(ui.Kids)%ui.Columns != 0 {
+		return Sizes{}
+	}
+	spaces := ui.spaces(dui)
+	widths := ui.columns(dui, sizeAvail, spaces)
+	width := sum(widths.Pref)
+	if ui.Width < 0 && width < sizeAvail.X {
+		width = sizeAvail.X
+	}
+	var min, pref image.Point
+	for i := 0; i < len(ui.Kids); i += ui.Columns {
+		var rowMin, rowPref int
+		for col := 0; col < ui.Columns; col++ {
+			k := ui.Kids[i+col]
+			if k.Hidden {
+				continue
+			}
+			space := spaces[col]
+			s := KidMeasure(dui, k, image.Pt(widths.Pref[col]-space.Dx(), sizeAvail.Y-pref.Y-space.Dy()))
+			rowMin = maximum(rowMin, s.Min.Y+space.Dy())
+			rowPref = maximum(rowPref, s.Pref.Y+space.Dy())
+		}
+		min.Y += rowMin
+		pref.Y += rowPref
+	}
+	min.X = minimum(sum(widths.Min), width)
+	pref.X = width
+	max := image.Pt(maximum(sum(widths.Max), width), pref.Y)
+	return Sizes{min, pref, max}
+}
+
+// InsertRow adds a row with uis at row index, and marks the grid for layout. Row -1 means at the end.
+// The number of uis must be equal to Columns.
+func (ui *Grid) InsertRow(dui *DUI, row int, uis ...UI) {
+	if len(uis) != ui.Columns {
+		panic(fmt.Sprintf("len(uis) = %d, should be ui.Columns = %d", len(uis), ui.Columns))
+	}
+	index := -1
+	if row >= 0 {
+		index = row * ui.Columns
+	}
+	ui.Kids = kidsInsert(ui.Kids, index, uis...)
+	dui.MarkLayout(ui)
+}
+
+// RemoveRow removes the kids of row index, and marks the grid for layout.
+func (ui *Grid) RemoveRow(dui *DUI, row int) {
+	ui.Kids = kidsRemove(ui.Kids, row*ui.Columns, ui.Columns)
+	dui.MarkLayout(ui)
+}
+
+// MoveRow moves the row at index from to index to, and marks the grid for layout.
+func (ui *Grid) MoveRow(dui *DUI, from, to int) {
+	kidsMove(ui.Kids, from*ui.Columns, to*ui.Columns, ui.Columns)
+	dui.MarkLayout(ui)
+}
+
+// Replace replaces the kid at index with a new kid for newUI, and marks the grid for layout.
+func (ui *Grid) Replace(dui *DUI, index int, newUI UI) {
+	ui.Kids[index] = &Kid{UI: newUI}
+	dui.MarkLayout(ui)
+}
+
 func (ui *Grid) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	KidsDraw(dui, self, ui.Kids, ui.size, ui.Background, img, orig, m, force)
 }
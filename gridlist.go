@@ -19,6 +19,28 @@ type Gridrow struct {
 	Selected bool        // If currently selected.
 	Values   []string    // Values displayed in the row.
 	Value    interface{} `json:"-"` // Auxiliary data.
+	Children []*Gridrow  // Nested rows, shown below this row when Expanded. Call MarkLayout on the Gridlist after changing.
+	Expanded bool        // Whether Children are shown. Call MarkLayout on the Gridlist after changing.
+}
+
+// gridVisible holds the rows of a Gridlist that are shown, with their nesting level.
+type gridVisible struct {
+	rows   []*Gridrow // Gridlist.Rows, with the children of expanded rows after their parent.
+	depths []int      // Nesting level of each row, 0 for Gridlist.Rows.
+	nested bool       // Whether rows have children, and the first column has room for the triangles.
+}
+
+func (v *gridVisible) add(rows []*Gridrow, depth int) {
+	for _, row := range rows {
+		v.rows = append(v.rows, row)
+		v.depths = append(v.depths, depth)
+		if len(row.Children) > 0 {
+			v.nested = true
+			if row.Expanded {
+				v.add(row.Children, depth+1)
+			}
+		}
+	}
 }
 
 // Gridfit is the layout strategy for a Gridlist.
@@ -33,19 +55,25 @@ const (
 // Currently each cell in each row is drawn as a single-line string.
 // Column widths can be adjusted by dragging the separator in the header.
 //
+// Rows can have nested rows, making a tree-table. Nested rows are indented in the first column, and rows with children get a triangle for expanding and collapsing them.
+// Only rows that are visible, not hidden in a collapsed row, are shown, striped and selectable with keys.
+// Indices passed to Changed and Click, and returned by Selected, are of the visible rows, see VisibleRows. Without nested rows, these are the indices of Rows.
+//
 // Keys:
 // 	arrow up, move selection up
 // 	arrow down, move selection down
 // 	home, move selection to first element
 // 	end, move selection to last element
+// 	arrow left, collapse the selected row, or select its parent
+// 	arrow right, expand the selected row, or select its first nested row
 // 	cmd-n, clear selection
-// 	cmd-a, select all
+// 	cmd-a, select all visible rows
 // 	cmd-c, copy selected rows, as tab-separated values
 type Gridlist struct {
 	Header   *Gridrow   // Optional header to display at the the top.
 	Rows     []*Gridrow // Rows, each holds whether it is selected.
 	Multiple bool       // Whether multiple rows can be selected at a time.
-	Halign   []Halign   // Horizontal alignment for the values.
+	Halign   []Halign   // Horizontal alignment for the values. If nil, values are aligned left, or right for right-to-left text.
 	Padding  Space      // Padding for each cell, in lowDPI pixels.
 	Striped  bool       // If set, odd cells have a slightly contrasting background color.
 	Fit      Gridfit    // Layout strategy, how much space columns receive.
@@ -56,7 +84,8 @@ type Gridlist struct {
 	Keys    func(k rune, m draw.Mouse) (e Event)    `json:"-"` // Called before handling a key event. If consumed, processing stops.
 
 	m                draw.Mouse
-	colWidths        []int // set the first time there are rows
+	visible          gridVisible // Set by layout.
+	colWidths        []int       // set the first time there are rows
 	size             image.Point
 	draggingColStart int         // x offset of column being dragged, so 1 means the first column is being dragged.
 	cellImage        *draw.Image // scratch image to draw cells on if they are too big
@@ -73,6 +102,43 @@ func (ui *Gridlist) rowHeight(dui *DUI) int {
 	return ui.font(dui).Height + dui.ScaleSpace(ui.Padding).Dy()
 }
 
+// levelWidth is the width of the triangle, and of the indentation for each level of nesting.
+func (ui *Gridlist) levelWidth(dui *DUI) int {
+	return ui.font(dui).Height
+}
+
+// visibleRows returns the rows that are shown: Rows, with the children of expanded rows after their parent.
+func (ui *Gridlist) visibleRows() (v gridVisible) {
+	v.add(ui.Rows, 0)
+	return
+}
+
+// indents returns the room before the first value of each row in v, for the triangle and nesting.
+func (ui *Gridlist) indents(dui *DUI, v *gridVisible) []int {
+	l := make([]int, len(v.rows))
+	if v.nested {
+		for i, depth := range v.depths {
+			l[i] = (depth + 1) * ui.levelWidth(dui)
+		}
+	}
+	return l
+}
+
+// cellWidth returns the width needed for value col of row, with indent before the first value.
+func (ui *Gridlist) cellWidth(dui *DUI, row *Gridrow, col, indent int) int {
+	dx := ui.font(dui).StringWidth(row.Values[col])
+	if col == 0 {
+		dx += indent
+	}
+	return dx
+}
+
+// VisibleRows returns the rows that are shown: Rows, with the nested rows of expanded rows after their parent.
+// They are determined from Rows at each call, so they reflect changes not yet laid out.
+func (ui *Gridlist) VisibleRows() []*Gridrow {
+	return ui.visibleRows().rows
+}
+
 func (ui *Gridlist) makeWidthOffsets(dui *DUI, widths []int) []int {
 	offsets := make([]int, len(widths))
 	pad := dui.ScaleSpace(ui.Padding)
@@ -111,37 +177,15 @@ func (ui *Gridlist) columnWidths(dui *DUI, width int) []int {
 	}
 
 	if ui.Fit == FitSlim {
-		row := ui.exampleRow()
-		if row == nil {
-			return nil
-		}
-
-		widths := make([]int, len(row.Values))
-		font := ui.font(dui)
-		updateWidths := func(row *Gridrow) {
-			for i, s := range row.Values {
-				widths[i] = maximum(widths[i], font.StringWidth(s))
-			}
-		}
-
-		if ui.Header != nil {
-			updateWidths(ui.Header)
-		}
-		for _, row := range ui.Rows {
-			updateWidths(row)
-		}
-		left := width
-		for i := range widths {
-			widths[i] = minimum(widths[i], left)
-			left -= widths[i]
-		}
-		if len(ui.Rows) > 0 {
+		widths := ui.slimWidths(dui, width, &ui.visible)
+		if len(ui.visible.rows) > 0 {
 			ui.colWidths = widths
 		}
 		return widths
 	}
 
-	makeWidths := func(rows []*Gridrow) ([]int, bool) {
+	// indents holds the indent of the first value of each row
+	makeWidths := func(rows []*Gridrow, indents []int) ([]int, bool) {
 		if len(rows[0].Values) == 0 {
 			panic("makeWidths on empty rows")
 		}
@@ -155,9 +199,9 @@ func (ui *Gridlist) columnWidths(dui *DUI, width int) []int {
 		max := make([]int, ncol)
 		avg := make([]int, ncol)
 		maxTotal := 0
-		for _, row := range rows {
-			for col, v := range row.Values {
-				dx := font.StringWidth(v)
+		for i, row := range rows {
+			for col := range row.Values {
+				dx := ui.cellWidth(dui, row, col, indents[i])
 				max[col] = maximum(max[col], dx)
 				avg[col] += dx // divided by rows later
 			}
@@ -270,17 +314,18 @@ func (ui *Gridlist) columnWidths(dui *DUI, width int) []int {
 		return widths, fit
 	}
 
-	if len(ui.Rows) == 0 {
+	if len(ui.visible.rows) == 0 {
 		if ui.Header == nil {
 			return nil
 		}
-		widths, _ := makeWidths([]*Gridrow{ui.Header})
+		widths, _ := makeWidths([]*Gridrow{ui.Header}, []int{0})
 		return widths
 	}
 	var fit bool
-	ui.colWidths, fit = makeWidths(ui.Rows)
+	indents := ui.indents(dui, &ui.visible)
+	ui.colWidths, fit = makeWidths(ui.visible.rows, indents)
 	if fit && ui.Header != nil {
-		widths, fit := makeWidths(append([]*Gridrow{ui.Header}, ui.Rows...))
+		widths, fit := makeWidths(append([]*Gridrow{ui.Header}, ui.visible.rows...), append([]int{0}, indents...))
 		if fit {
 			ui.colWidths = widths
 		}
@@ -288,6 +333,47 @@ func (ui *Gridlist) columnWidths(dui *DUI, width int) []int {
 	return ui.colWidths
 }
 
+// slimWidths returns the widths of the columns for FitSlim: the widest value of each column, limited to width.
+func (ui *Gridlist) slimWidths(dui *DUI, width int, v *gridVisible) []int {
+	row := ui.exampleRow()
+	if row == nil {
+		return nil
+	}
+
+	widths := make([]int, len(row.Values))
+	updateWidths := func(row *Gridrow, indent int) {
+		for i := range row.Values {
+			widths[i] = maximum(widths[i], ui.cellWidth(dui, row, i, indent))
+		}
+	}
+
+	if ui.Header != nil {
+		updateWidths(ui.Header, 0)
+	}
+	indents := ui.indents(dui, v)
+	for i, row := range v.rows {
+		updateWidths(row, indents[i])
+	}
+	left := width
+	for i := range widths {
+		widths[i] = minimum(widths[i], left)
+		left -= widths[i]
+	}
+	return widths
+}
+
+// widthsWidth returns the total width of a gridlist with columns of widths, including padding and separators.
+func (ui *Gridlist) widthsWidth(dui *DUI, widths []int) int {
+	if len(widths) == 0 {
+		return 0
+	}
+	dx := (len(widths) - 1) * separatorWidth
+	for _, w := range widths {
+		dx += w + dui.ScaleSpace(ui.Padding).Dx()
+	}
+	return dx
+}
+
 func (ui *Gridlist) exampleRow() *Gridrow {
 	if ui.Header != nil {
 		return ui.Header
@@ -298,8 +384,8 @@ func (ui *Gridlist) exampleRow() *Gridrow {
 	return ui.Rows[0]
 }
 
-func (ui *Gridlist) rowCount() int {
-	n := len(ui.Rows)
+func (ui *Gridlist) rowCount(v *gridVisible) int {
+	n := len(v.rows)
 	if ui.Header != nil {
 		n++
 	}
@@ -309,17 +395,44 @@ func (ui *Gridlist) rowCount() int {
 func (ui *Gridlist) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 	dui.debugLayout(self)
 
+	ui.visible = ui.visibleRows()
 	row := ui.exampleRow()
 	if ui.Halign != nil && row != nil && len(ui.Halign) != len(row.Values) {
 		panic(fmt.Sprintf("len(halign) = %d, should be len(row.Values) = %d", len(ui.Halign), len(row.Values)))
 	}
 
-	n := ui.rowCount()
-	ui.columnWidths(dui, sizeAvail.X) // calculate widths, possibly remembering
+	n := ui.rowCount(&ui.visible)
+	widths := ui.columnWidths(dui, sizeAvail.X) // calculate widths, possibly remembering
 	ui.size = image.Pt(sizeAvail.X, n*ui.rowHeight(dui)+(n-1)*separatorHeight)
+	if ui.Fit == FitSlim {
+		ui.size.X = minimum(sizeAvail.X, ui.widthsWidth(dui, widths))
+	}
 	self.R = rect(ui.size)
 }
 
+// Measure returns the width needed for all values as maximum size.
+// With FitSlim, that is also the preferred size. Otherwise, the available width is preferred, and the minimum gives each column a few characters.
+func (ui *Gridlist) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	visible := ui.visibleRows()
+	n := ui.rowCount(&visible)
+	dy := n*ui.rowHeight(dui) + (n-1)*separatorHeight
+	slim := image.Pt(ui.widthsWidth(dui, ui.slimWidths(dui, maxInt, &visible)), dy)
+	if ui.Fit == FitSlim {
+		size := image.Pt(minimum(sizeAvail.X, slim.X), dy)
+		return Sizes{size, size, slim}
+	}
+	var min image.Point
+	if row := ui.exampleRow(); row != nil {
+		widths := make([]int, len(row.Values))
+		for i := range widths {
+			widths[i] = ui.font(dui).StringWidth("mmm")
+		}
+		min = image.Pt(ui.widthsWidth(dui, widths), dy)
+	}
+	pref := image.Pt(sizeAvail.X, dy)
+	return Sizes{min, pref, image.Pt(maximum(pref.X, slim.X), dy)}
+}
+
 func (ui *Gridlist) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	dui.debugDraw(self)
 
@@ -360,7 +473,8 @@ func (ui *Gridlist) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point,
 		return ui.cellImage
 	}
 
-	drawRow := func(row *Gridrow, odd bool) {
+	// depth is the nesting level of row, -1 for the header
+	drawRow := func(row *Gridrow, depth int, odd bool) {
 		if len(row.Values) != ncol {
 			panic(fmt.Sprintf("row with wrong number of values, expect %d, saw %d", ncol, len(row.Values)))
 		}
@@ -377,10 +491,30 @@ func (ui *Gridlist) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point,
 			cellR.Min.X = lineR.Min.X + x[i] + separatorWidth
 			cellR.Max.X = cellR.Min.X + widths[i] + pad.Dx()
 			cellR = pad.Inset(cellR)
+			width := widths[i]
+			if i == 0 {
+				if ui.visible.nested && depth >= 0 {
+					if len(row.Children) > 0 {
+						s := "▸"
+						if row.Expanded {
+							s = "▾"
+						}
+						p := image.Pt(cellR.Min.X+depth*ui.levelWidth(dui), cellR.Min.Y)
+						img.String(p, colors.Text, image.ZP, font, s)
+					}
+					indent := minimum((depth+1)*ui.levelWidth(dui), width)
+					cellR.Min.X += indent
+					width -= indent
+				}
+			}
 			alignOffset := pt(0)
 			dx := font.StringWidth(s)
-			if ui.Halign != nil {
-				leftover := widths[i] - dx
+			if ui.Halign == nil {
+				if bidiRTL(s, dui.RTL) {
+					alignOffset.X += width - dx
+				}
+			} else {
+				leftover := width - dx
 				switch ui.Halign[i] {
 				case HalignLeft:
 				case HalignMiddle:
@@ -391,23 +525,23 @@ func (ui *Gridlist) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point,
 					panic(fmt.Sprintf("unknown halign %d", ui.Halign[i]))
 				}
 			}
-			if dx > widths[i] {
+			if dx > width {
 				cellImg := ensureCellImage(cellR.Size())
 				if cellImg == nil {
 					return
 				}
 				cellImg.Draw(cellImg.R, colors.Background, nil, image.ZP)
-				cellImg.String(alignOffset, colors.Text, image.ZP, font, s)
+				drawBidiString(dui, cellImg, alignOffset, 0, colors.Text, font, s)
 				img.Draw(cellR, cellImg, nil, image.ZP)
 			} else {
-				img.String(cellR.Min.Add(alignOffset), colors.Text, image.ZP, font, s)
+				drawBidiString(dui, img, cellR.Min.Add(alignOffset), 0, colors.Text, font, s)
 			}
 		}
 		lineR = lineR.Add(image.Pt(0, rowHeight+separatorHeight))
 	}
 
 	if ui.Header != nil {
-		drawRow(ui.Header, false)
+		drawRow(ui.Header, -1, false)
 		// print separators
 		for i := 1; i < ncol; i++ {
 			p0 := image.Pt(x[i], 0).Add(orig).Add(image.Pt(0, pad.Top))
@@ -421,8 +555,8 @@ func (ui *Gridlist) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point,
 		img.Line(lp0, lp1, 0, 0, 0, dui.Regular.Normal.Border, image.ZP)
 	}
 
-	for i, row := range ui.Rows {
-		drawRow(row, i%2 == 1)
+	for i, row := range ui.visible.rows {
+		drawRow(row, ui.visible.depths[i], i%2 == 1)
 	}
 }
 
@@ -490,19 +624,23 @@ func (ui *Gridlist) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, o
 	if ui.Header != nil {
 		index--
 	}
+	if index >= len(ui.visible.rows) {
+		return
+	}
+	if prevM.Buttons == 0 && m.Buttons == Button1 && ui.onTriangle(dui, index, m.X) {
+		ui.toggle(self, ui.visible.rows[index])
+		r.Consumed = true
+		return
+	}
 	if m.Buttons != 0 && prevM.Buttons^m.Buttons != 0 && ui.Click != nil {
 		e := ui.Click(index, m)
 		propagateEvent(self, &r, e)
 	}
 	if !r.Consumed && prevM.Buttons == 0 && m.Buttons == Button1 {
-		row := ui.Rows[index]
+		row := ui.visible.rows[index]
 		row.Selected = !row.Selected
 		if row.Selected && !ui.Multiple {
-			for _, vv := range ui.Rows {
-				if vv != row {
-					vv.Selected = false
-				}
-			}
+			unselectGridrows(ui.Rows, row)
 		}
 		if ui.Changed != nil {
 			e := ui.Changed(index)
@@ -514,8 +652,33 @@ func (ui *Gridlist) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, o
 	return
 }
 
-func (ui *Gridlist) selectedIndices() (l []int) {
-	for i, row := range ui.Rows {
+// onTriangle returns whether x, relative to the gridlist, is on the triangle of the visible row at index.
+func (ui *Gridlist) onTriangle(dui *DUI, index int, x int) bool {
+	if !ui.visible.nested || len(ui.visible.rows[index].Children) == 0 {
+		return false
+	}
+	x0 := separatorWidth + dui.ScaleSpace(ui.Padding).Left + ui.visible.depths[index]*ui.levelWidth(dui)
+	return x >= x0 && x < x0+ui.levelWidth(dui)
+}
+
+// toggle expands or collapses row.
+func (ui *Gridlist) toggle(self *Kid, row *Gridrow) {
+	row.Expanded = !row.Expanded
+	self.Layout = Dirty
+}
+
+// unselectGridrows clears the selection of rows and their nested rows, except for keep.
+func unselectGridrows(rows []*Gridrow, keep *Gridrow) {
+	for _, row := range rows {
+		if row != keep {
+			row.Selected = false
+		}
+		unselectGridrows(row.Children, keep)
+	}
+}
+
+func selectedGridrows(rows []*Gridrow) (l []int) {
+	for i, row := range rows {
 		if row.Selected {
 			l = append(l, i)
 		}
@@ -523,12 +686,13 @@ func (ui *Gridlist) selectedIndices() (l []int) {
 	return
 }
 
+// Selected returns the indices of the selected rows in VisibleRows.
 func (ui *Gridlist) Selected() (indices []int) {
-	return ui.selectedIndices()
+	return selectedGridrows(ui.visibleRows().rows)
 }
 
 func (ui *Gridlist) firstSelected() int {
-	for i, row := range ui.Rows {
+	for i, row := range ui.visibleRows().rows {
 		if row.Selected {
 			return i
 		}
@@ -547,12 +711,11 @@ func (ui *Gridlist) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Po
 			return
 		}
 	}
+	visible := ui.visibleRows()
 	switch k {
 	case draw.KeyCmd + 'n':
 		// clear selection
-		for _, row := range ui.Rows {
-			row.Selected = false
-		}
+		unselectGridrows(ui.Rows, nil)
 		if ui.Changed != nil {
 			ui.Changed(-1)
 		}
@@ -560,7 +723,7 @@ func (ui *Gridlist) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Po
 		self.Draw = Dirty
 	case draw.KeyCmd + 'a':
 		// select all
-		for _, row := range ui.Rows {
+		for _, row := range visible.rows {
 			row.Selected = true
 		}
 		if ui.Changed != nil {
@@ -571,7 +734,7 @@ func (ui *Gridlist) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Po
 	case draw.KeyCmd + 'c':
 		// snarf selection
 		s := ""
-		for _, row := range ui.Rows {
+		for _, row := range visible.rows {
 			if !row.Selected {
 				continue
 			}
@@ -583,11 +746,11 @@ func (ui *Gridlist) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Po
 			self.Draw = Dirty
 		}
 
-	case draw.KeyUp, draw.KeyDown, draw.KeyHome, draw.KeyEnd:
-		if len(ui.Rows) == 0 {
+	case draw.KeyUp, draw.KeyDown, draw.KeyHome, draw.KeyEnd, draw.KeyLeft, draw.KeyRight:
+		if len(visible.rows) == 0 {
 			return
 		}
-		sel := ui.selectedIndices()
+		sel := selectedGridrows(visible.rows)
 		oindex := -1
 		nindex := -1
 		switch k {
@@ -603,19 +766,42 @@ func (ui *Gridlist) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Po
 				nindex = 0
 			} else {
 				oindex = sel[len(sel)-1]
-				nindex = minimum(sel[len(sel)-1]+1, len(ui.Rows)-1)
+				nindex = minimum(sel[len(sel)-1]+1, len(visible.rows)-1)
 			}
 		case draw.KeyHome:
 			nindex = 0
 		case draw.KeyEnd:
-			nindex = len(ui.Rows) - 1
+			nindex = len(visible.rows) - 1
+		case draw.KeyLeft, draw.KeyRight:
+			if len(sel) == 0 {
+				return
+			}
+			oindex = sel[0]
+			nindex = oindex
+			row := visible.rows[oindex]
+			expanded := row.Expanded && len(row.Children) > 0
+			if k == draw.KeyLeft && expanded || k == draw.KeyRight && !expanded && len(row.Children) > 0 {
+				ui.toggle(self, row)
+				r.Consumed = true
+				return
+			}
+			if k == draw.KeyLeft {
+				for i := oindex - 1; i >= 0; i-- {
+					if visible.depths[i] < visible.depths[oindex] {
+						nindex = i
+						break
+					}
+				}
+			} else if expanded {
+				nindex = oindex + 1
+			}
 		}
 		r.Consumed = oindex != nindex
 		if !r.Consumed {
 			return
 		}
 		if oindex >= 0 {
-			ui.Rows[oindex].Selected = false
+			visible.rows[oindex].Selected = false
 			self.Draw = Dirty
 		}
 		if nindex >= 0 {
@@ -623,7 +809,7 @@ func (ui *Gridlist) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Po
 			rowHeight := ui.rowHeight(dui)
 			pad := dui.ScaleSpace(ui.Padding)
 
-			ui.Rows[nindex].Selected = true
+			visible.rows[nindex].Selected = true
 			self.Draw = Dirty
 			if ui.Changed != nil {
 				e := ui.Changed(nindex)
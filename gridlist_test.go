@@ -0,0 +1,26 @@
+package duit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGridlistSelectedBeforeLayout(t *testing.T) {
+	child := &Gridrow{Values: []string{"child"}, Selected: true}
+	parent := &Gridrow{Values: []string{"parent"}, Children: []*Gridrow{child}}
+	ui := &Gridlist{Rows: []*Gridrow{parent, {Values: []string{"other"}}}}
+
+	// rows are visible as soon as they are expanded, without a layout in between
+	parent.Expanded = true
+	if rows := ui.VisibleRows(); len(rows) != 3 || rows[1] != child {
+		t.Fatalf("got %d visible rows, expected parent, child and other", len(rows))
+	}
+	if sel := ui.Selected(); !reflect.DeepEqual(sel, []int{1}) {
+		t.Fatalf("got selected %v, expected [1]", sel)
+	}
+
+	parent.Expanded = false
+	if sel := ui.Selected(); len(sel) != 0 {
+		t.Fatalf("got selected %v for collapsed row, expected none", sel)
+	}
+}
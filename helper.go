@@ -2,6 +2,8 @@ package duit
 
 import "image"
 
+const maxInt = int(^uint(0) >> 1)
+
 func pt(v int) image.Point {
 	return image.Point{v, v}
 }
@@ -23,6 +23,13 @@ func (ui *Image) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool)
 	return
 }
 
+func (ui *Image) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	if ui.Image == nil {
+		return Sizes{}
+	}
+	return fixedSizes(ui.Image.R.Size())
+}
+
 func (ui *Image) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	dui.debugDraw(self)
 	if ui.Image == nil {
@@ -10,11 +10,21 @@ import (
 
 // Kid holds a UI and its layout/draw state.
 type Kid struct {
-	UI     UI              // UI this state is about.
-	R      image.Rectangle // Location and size within this UI.
-	Draw   State           // Whether UI or its children need a draw.
-	Layout State           // Whether UI or its children need a layout.
-	ID     string          // For (re)storing settings with ReadSettings and WriteSettings. If empty, no settings for the UI will be (re)stored.
+	UI     UI                       // UI this state is about.
+	R      image.Rectangle          // Location and size within this UI.
+	Draw   State                    // Whether UI or its children need a draw.
+	Layout State                    // Whether UI or its children need a layout.
+	ID     string                   // For (re)storing settings with ReadSettings and WriteSettings. If empty, no settings for the UI will be (re)stored.
+	Hidden bool                     // If set, the UI takes no space, is not drawn, gets no mouse/keyboard events and is skipped for focus. Call MarkLayout on the UI after changing.
+	Menu   func(m draw.Mouse) *Menu `json:"-"` // If set, called on a button 3 press on the UI that the UI did not consume. A returned menu is opened at the mouse. Used by KidsMouse and for DUI.Top.
+
+	hidden bool // Hidden at time of last layout.
+
+	// cached result of KidMeasure
+	measured     bool
+	measureGen   int
+	measureAvail image.Point
+	sizes        Sizes
 }
 
 // MarshalJSON writes k with an additional field Type containing the name of the UI type.
@@ -42,6 +52,22 @@ func (k *Kid) Mark(o UI, forLayout bool) (marked bool) {
 	return true
 }
 
+// KidMeasure returns the sizes of the UI of k for sizeAvail, see UI.Measure.
+// Results are cached until the next layout or input event. Hidden kids have zero sizes.
+func KidMeasure(dui *DUI, k *Kid, sizeAvail image.Point) Sizes {
+	if k.Hidden {
+		return Sizes{}
+	}
+	if k.measured && k.measureGen == dui.measureGen && k.measureAvail == sizeAvail {
+		return k.sizes
+	}
+	k.sizes = k.UI.Measure(dui, k, sizeAvail)
+	k.measured = true
+	k.measureGen = dui.measureGen
+	k.measureAvail = sizeAvail
+	return k.sizes
+}
+
 // NewKids turns UIs into Kids containing those UIs. Useful for creating UI trees.
 func NewKids(uis ...UI) []*Kid {
 	kids := make([]*Kid, len(uis))
@@ -51,13 +77,55 @@ func NewKids(uis ...UI) []*Kid {
 	return kids
 }
 
+// kidsInsert returns a new slice of kids with new Kids for uis inserted at index. Index -1 means at the end.
+func kidsInsert(kids []*Kid, index int, uis ...UI) []*Kid {
+	if index < 0 {
+		index = len(kids)
+	}
+	nkids := make([]*Kid, 0, len(kids)+len(uis))
+	nkids = append(nkids, kids[:index]...)
+	nkids = append(nkids, NewKids(uis...)...)
+	return append(nkids, kids[index:]...)
+}
+
+// kidsRemove returns a new slice of kids without the n kids starting at index.
+func kidsRemove(kids []*Kid, index, n int) []*Kid {
+	nkids := make([]*Kid, 0, len(kids)-n)
+	nkids = append(nkids, kids[:index]...)
+	return append(nkids, kids[index+n:]...)
+}
+
+// kidsMove moves the n kids starting at index from, so they start at index to.
+func kidsMove(kids []*Kid, from, to, n int) {
+	l := make([]*Kid, n)
+	copy(l, kids[from:from+n])
+	if from < to {
+		copy(kids[from:], kids[from+n:to+n])
+	} else {
+		copy(kids[to+n:], kids[to:from])
+	}
+	copy(kids[to:], l)
+}
+
+// kidsMirror mirrors the horizontal placement of kids in a UI of width, for right-to-left layout.
+func kidsMirror(kids []*Kid, width int) {
+	for _, k := range kids {
+		if k.Hidden {
+			continue
+		}
+		k.R = image.Rect(width-k.R.Max.X, k.R.Min.Y, width-k.R.Min.X, k.R.Max.Y)
+	}
+}
+
 // KidsLayout is called by layout UIs before they do their own layouts.
 // KidsLayout returns whether there is any work left to do, determined by looking at self.Layout.
 // Children will be layed out if necessary. KidsLayout updates layout and draw state of self and kids.
+// Hidden kids are given an empty rectangle when a layout is needed. Changes to Kid.Hidden cause a layout of self.
 func KidsLayout(dui *DUI, self *Kid, kids []*Kid, force bool) (done bool) {
 	if force {
 		self.Layout = Clean
 		self.Draw = Dirty
+		kidsHide(kids)
 		return false
 	}
 	switch self.Layout {
@@ -66,9 +134,22 @@ func KidsLayout(dui *DUI, self *Kid, kids []*Kid, force bool) (done bool) {
 	case Dirty:
 		self.Layout = Clean
 		self.Draw = Dirty
+		kidsHide(kids)
 		return false
 	}
 	for _, k := range kids {
+		if k.Hidden != k.hidden {
+			self.Layout = Dirty
+			self.Draw = Dirty
+			kidsHide(kids)
+			return false
+		}
+	}
+	for _, k := range kids {
+		if k.Hidden {
+			k.Layout = Clean
+			continue
+		}
 		if k.Layout == Clean {
 			continue
 		}
@@ -77,6 +158,7 @@ func KidsLayout(dui *DUI, self *Kid, kids []*Kid, force bool) (done bool) {
 		case Dirty:
 			self.Layout = Dirty
 			self.Draw = Dirty
+			kidsHide(kids)
 			return false
 		case DirtyKid:
 			panic("layout of kid results in kid.Layout = DirtKid")
@@ -88,6 +170,18 @@ func KidsLayout(dui *DUI, self *Kid, kids []*Kid, force bool) (done bool) {
 	return true
 }
 
+// kidsHide records the hidden state of kids for a new layout, and gives hidden kids no space.
+func kidsHide(kids []*Kid) {
+	for _, k := range kids {
+		k.hidden = k.Hidden
+		if k.Hidden {
+			k.R = image.ZR
+			k.Layout = Clean
+			k.Draw = Clean
+		}
+	}
+}
+
 // KidsDraw draws a UI by drawing all its kids.
 // uiSize is the size of the entire UI, used in case it has to be redrawn entirely.
 // Bg can override the default duit background color.
@@ -109,7 +203,7 @@ func KidsDraw(dui *DUI, self *Kid, kids []*Kid, uiSize image.Point, bg, img *dra
 		img.Draw(rect(uiSize).Add(orig), bg, nil, image.ZP)
 	}
 	for i, k := range kids {
-		if !force && k.Draw == Clean {
+		if k.Hidden || !force && k.Draw == Clean {
 			continue
 		}
 		if dui.DebugKids {
@@ -157,7 +251,7 @@ func propagateResult(dui *DUI, self, k *Kid) {
 // Mouse positions are always relative to their own origin. Orig is passed so UIs can calculate locations to warp the mouse to.
 func KidsMouse(dui *DUI, self *Kid, kids []*Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
 	for _, k := range kids {
-		if !origM.Point.In(k.R) {
+		if k.Hidden || !origM.Point.In(k.R) {
 			continue
 		}
 		origM.Point = origM.Point.Sub(k.R.Min)
@@ -166,6 +260,7 @@ func KidsMouse(dui *DUI, self *Kid, kids []*Kid, m draw.Mouse, origM draw.Mouse,
 		if r.Hit == nil {
 			r.Hit = k.UI
 		}
+		kidMenu(dui, k, m, &r)
 		propagateResult(dui, self, k)
 		return
 	}
@@ -176,7 +271,7 @@ func KidsMouse(dui *DUI, self *Kid, kids []*Kid, m draw.Mouse, origM draw.Mouse,
 // Orig is passed so UIs can calculate locations to warp the mouse to.
 func KidsKey(dui *DUI, self *Kid, kids []*Kid, key rune, m draw.Mouse, orig image.Point) (r Result) {
 	for i, k := range kids {
-		if !m.Point.In(k.R) {
+		if k.Hidden || !m.Point.In(k.R) {
 			continue
 		}
 		m.Point = m.Point.Sub(k.R.Min)
@@ -184,6 +279,9 @@ func KidsKey(dui *DUI, self *Kid, kids []*Kid, key rune, m draw.Mouse, orig imag
 		if !r.Consumed && key == '\t' {
 			for next := i + 1; next < len(kids); next++ {
 				k := kids[next]
+				if k.Hidden {
+					continue
+				}
 				first := k.UI.FirstFocus(dui, k)
 				if first != nil {
 					p := first.Add(orig).Add(k.R.Min)
@@ -209,6 +307,9 @@ func KidsFirstFocus(dui *DUI, self *Kid, kids []*Kid) *image.Point {
 		return nil
 	}
 	for _, k := range kids {
+		if k.Hidden {
+			continue
+		}
 		first := k.UI.FirstFocus(dui, k)
 		if first != nil {
 			p := first.Add(k.R.Min)
@@ -224,6 +325,9 @@ func KidsFocus(dui *DUI, self *Kid, kids []*Kid, ui UI) *image.Point {
 		return nil
 	}
 	for _, k := range kids {
+		if k.Hidden {
+			continue
+		}
 		p := k.UI.Focus(dui, k, ui)
 		if p != nil {
 			pp := p.Add(k.R.Min)
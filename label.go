@@ -2,23 +2,38 @@ package duit
 
 import (
 	"image"
+	"strings"
+	"unicode/utf8"
 
 	"9fans.net/go/draw"
 )
 
-// Label draws multiline text in a single font.:
+// Label draws multiline text in a single font.
+// Paragraphs starting with right-to-left text, such as Hebrew or Arabic, are drawn right-aligned.
+//
+// Text can be selected by dragging with button1, double-clicking selects a word, triple-clicking a line.
 //
 // Keys:
-//	cmd-c, copy text
+//	cmd-a, select all text
+//	cmd-n, clear selection
+//	cmd-c, copy selection, or all text without selection
 //	\n, like button1 click, calls the Click function
 type Label struct {
-	Text  string           // Text to draw, wrapped at glyph boundary.
-	Font  *draw.Font       `json:"-"` // For drawing text.
-	Click func() (e Event) `json:"-"` // Called on button1 click.
+	Text   string           // Text to draw, wrapped at word boundaries, or at glyph boundary for words that do not fit.
+	NoWrap bool             // If set, lines are only broken at newlines.
+	Font   *draw.Font       `json:"-"` // For drawing text.
+	Click  func() (e Event) `json:"-"` // Called on button1 click.
 
-	lines []string
+	lines []labelLine
 	size  image.Point
 	m     draw.Mouse
+	sel   textSelect
+}
+
+type labelLine struct {
+	text   string // Without trailing spaces.
+	offset int    // Of text in Label.Text.
+	rtl    bool   // Direction of the paragraph the line is part of.
 }
 
 var _ UI = &Label{}
@@ -27,56 +42,127 @@ func (ui *Label) font(dui *DUI) *draw.Font {
 	return dui.Font(ui.Font)
 }
 
-func (ui *Label) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
-	dui.debugLayout(self)
-
+// glyphs returns the characters of Text with their widths and line break opportunities.
+func (ui *Label) glyphs(dui *DUI) []textGlyph {
 	font := ui.font(dui)
-	ui.lines = []string{}
-	s := 0
-	x := 0
-	xmax := 0
-	for i, c := range ui.Text {
-		if c == '\n' {
-			xmax = maximum(xmax, x)
-			ui.lines = append(ui.lines, ui.Text[s:i])
-			s = i + 1
-			x = 0
-			continue
+	var glyphs []textGlyph
+	var lb lineBreaker
+	for o, c := range ui.Text {
+		g := textGlyph{
+			offset:  o,
+			size:    utf8.RuneLen(c),
+			width:   font.StringWidth(string(c)),
+			space:   c == ' ',
+			newline: c == '\n',
+			brk:     lb.next(c),
 		}
-		dx := font.StringWidth(string(c))
-		x += dx
-		if i-s == 0 || x <= sizeAvail.X {
-			continue
+		glyphs = append(glyphs, g)
+	}
+	return glyphs
+}
+
+// wrap breaks Text into lines that fit in width, and returns the lines and their size.
+// Lines are broken at word boundaries when possible. Spaces at the end of a wrapped line do not count towards its width.
+func (ui *Label) wrap(dui *DUI, glyphs []textGlyph, width int) ([]labelLine, image.Point) {
+	offset := func(i int) int {
+		if i < len(glyphs) {
+			return glyphs[i].offset
 		}
-		xmax = maximum(xmax, x-dx)
-		ui.lines = append(ui.lines, ui.Text[s:i])
-		s = i
-		x = dx
+		return len(ui.Text)
 	}
-	if s < len(ui.Text) || s == 0 {
-		ui.lines = append(ui.lines, ui.Text[s:])
-		xmax = maximum(xmax, x)
+	lines := []labelLine{}
+	xmax := 0
+	rtl := false
+	wrapGlyphs(glyphs, width, ui.NoWrap, func(s, e, dx int) {
+		o := offset(s)
+		if s == 0 || glyphs[s-1].newline {
+			rtl = ui.paragraphRTL(dui, o)
+		}
+		lines = append(lines, labelLine{ui.Text[o:offset(e)], o, rtl})
+		xmax = maximum(xmax, dx)
+	})
+	return lines, image.Pt(xmax, len(lines)*ui.font(dui).Height)
+}
+
+// paragraphRTL returns whether the paragraph starting at offset o is right-to-left.
+func (ui *Label) paragraphRTL(dui *DUI, o int) bool {
+	s := ui.Text[o:]
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
 	}
-	ui.size = image.Pt(xmax, len(ui.lines)*font.Height)
+	return bidiRTL(s, dui.RTL)
+}
+
+func (ui *Label) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+
+	ui.lines, ui.size = ui.wrap(dui, ui.glyphs(dui), sizeAvail.X)
 	self.R = rect(ui.size)
 }
 
+// Measure returns as minimum the size with the widest word determining the width, and as maximum the size without wrapping.
+func (ui *Label) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	glyphs := ui.glyphs(dui)
+	_, max := ui.wrap(dui, glyphs, ui.maxLineWidth(dui))
+	if ui.NoWrap {
+		return Sizes{max, max, max}
+	}
+	_, pref := ui.wrap(dui, glyphs, sizeAvail.X)
+	_, min := ui.wrap(dui, glyphs, maxWordWidth(glyphs))
+	return Sizes{min, pref, max}
+}
+
+func (ui *Label) maxLineWidth(dui *DUI) int {
+	font := ui.font(dui)
+	dx := 0
+	for _, line := range strings.Split(ui.Text, "\n") {
+		dx = maximum(dx, font.StringWidth(line))
+	}
+	return dx
+}
+
 func (ui *Label) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	dui.debugDraw(self)
 
 	p := orig
 	font := ui.font(dui)
+	s, e := ui.sel.ordered(ui.Text)
 	for _, line := range ui.lines {
-		img.String(p, dui.Regular.Normal.Text, image.ZP, font, line)
+		b := newBidiText(font, line.text, line.rtl)
+		lp := p.Add(image.Pt(b.align(ui.size.X), 0))
+		ls, le := s-line.offset, e-line.offset
+		for _, selR := range b.selectionRects(ls, le) {
+			selR.Max.Y = font.Height
+			img.Draw(selR.Add(lp), dui.Selection.Background, nil, image.ZP)
+		}
+		b.draw(img, lp, font, dui.Regular.Normal.Text, ls, le, dui.Selection.Text)
 		p.Y += font.Height
 	}
 }
 
+// offsetAt returns the offset in Text for the cursor closest to p.
+func (ui *Label) offsetAt(dui *DUI, p image.Point) int {
+	if p.Y < 0 || len(ui.lines) == 0 {
+		return 0
+	}
+	i := p.Y / ui.font(dui).Height
+	if i >= len(ui.lines) {
+		return len(ui.Text)
+	}
+	line := ui.lines[i]
+	b := newBidiText(ui.font(dui), line.text, line.rtl)
+	return line.offset + b.offsetAt(p.X-b.align(ui.size.X))
+}
+
 func (ui *Label) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
 	if m.In(rect(ui.size)) && ui.m.Buttons == 0 && m.Buttons == Button1 && ui.Click != nil {
 		e := ui.Click()
 		propagateEvent(self, &r, e)
 	}
+	if !r.Consumed && ui.sel.mouse(ui.Text, ui.m, m, ui.offsetAt(dui, m.Point)) {
+		self.Draw = Dirty
+		r.Consumed = true
+	}
 	ui.m = m
 	return
 }
@@ -88,9 +174,12 @@ func (ui *Label) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point
 			e := ui.Click()
 			propagateEvent(self, &r, e)
 		}
-	case draw.KeyCmd + 'c':
-		dui.WriteSnarf([]byte(ui.Text))
-		r.Consumed = true
+	default:
+		var changed bool
+		r.Consumed, changed = ui.sel.key(dui, ui.Text, k)
+		if changed {
+			self.Draw = Dirty
+		}
 	}
 	return
 }
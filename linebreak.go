@@ -0,0 +1,241 @@
+package duit
+
+import (
+	"unicode"
+)
+
+// Line breaking, for wrapping text at word boundaries.
+// This is a simplified version of the Unicode Line Breaking Algorithm (UAX #14): it knows about spaces, punctuation, hyphens and ideographs, but not about scripts that need a dictionary to find word boundaries, such as Thai.
+
+type lbClass byte
+
+const (
+	lbAL lbClass = iota // Alphabetic and other characters without special behaviour.
+	lbBK                // Mandatory break, e.g. newline.
+	lbSP                // Space.
+	lbZW                // Zero width space, allows a break.
+	lbWJ                // Word joiner, prevents breaks on both sides.
+	lbGL                // Non-breaking ("glue"), e.g. no-break space.
+	lbCM                // Combining mark.
+	lbOP                // Opening punctuation.
+	lbCL                // Closing punctuation.
+	lbEX                // Exclamation and question mark.
+	lbIS                // Infix separator, e.g. comma and period.
+	lbQU                // Quotation mark.
+	lbHY                // Hyphen-minus.
+	lbBA                // Break after, e.g. tab and dashes.
+	lbBB                // Break before.
+	lbNS                // Nonstarter, e.g. small kana.
+	lbID                // Ideographic, breaks are allowed before and after.
+	lbNU                // Numeric.
+)
+
+// lbNonstarters are small kana and marks that do not start a line.
+const lbNonstarters = "ぁぃぅぇぉっゃゅょゎゕゖァィゥェォッャュョヮヵヶーヽヾゝゞ々〻"
+
+func lbClassOf(c rune) lbClass {
+	switch c {
+	case '\n', '\r', '\v', '\f', 0x85, 0x2028, 0x2029:
+		return lbBK
+	case ' ':
+		return lbSP
+	case '\t', 0xad, 0x2010, 0x2013, 0x2014:
+		return lbBA
+	case 0x200b:
+		return lbZW
+	case 0x2060, 0xfeff:
+		return lbWJ
+	case 0xa0, 0x202f, 0x2007, 0x2011:
+		return lbGL
+	case '!', '?', 0xff01, 0xff1f:
+		return lbEX
+	case ',', '.', ':', ';':
+		return lbIS
+	case '"', '\'':
+		return lbQU
+	case '-':
+		return lbHY
+	case 0xb4:
+		return lbBB
+	case 0x3001, 0x3002, 0xff0c, 0xff0e:
+		return lbCL
+	}
+	switch {
+	case c >= '0' && c <= '9':
+		return lbNU
+	case unicode.In(c, unicode.Mn, unicode.Me, unicode.Mc):
+		return lbCM
+	case unicode.Is(unicode.Ps, c):
+		return lbOP
+	case unicode.Is(unicode.Pe, c):
+		return lbCL
+	case unicode.In(c, unicode.Pi, unicode.Pf):
+		return lbQU
+	case c < 0x2e80:
+		return lbAL
+	case containsRune(lbNonstarters, c):
+		return lbNS
+	case unicode.In(c, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul), c >= 0x3000 && c <= 0x303f, c >= 0xff01 && c <= 0xff60:
+		return lbID
+	}
+	return lbAL
+}
+
+func containsRune(s string, c rune) bool {
+	for _, x := range s {
+		if x == c {
+			return true
+		}
+	}
+	return false
+}
+
+// lbBreak returns whether a line may be broken between a character of class prev and a character of class next, with spaces in between if spaces is set.
+func lbBreak(prev lbClass, spaces bool, next lbClass) bool {
+	switch {
+	case prev == lbBK:
+		return true
+	case next == lbBK, next == lbSP, next == lbZW:
+		return false
+	case prev == lbZW:
+		return true
+	case prev == lbWJ, next == lbWJ:
+		return false
+	case !spaces && prev == lbGL:
+		return false
+	case !spaces && next == lbGL && prev != lbBA && prev != lbHY:
+		return false
+	case next == lbCL, next == lbEX, next == lbIS:
+		return false
+	case prev == lbOP:
+		return false
+	case !spaces && (prev == lbQU || next == lbQU):
+		return false
+	case spaces:
+		return true
+	case next == lbBA, next == lbHY, next == lbNS, prev == lbBB:
+		return false
+	case (prev == lbAL || prev == lbNU || prev == lbIS) && (next == lbAL || next == lbNU):
+		return false
+	case prev == lbHY && next == lbNU:
+		// e.g. negative numbers
+		return false
+	case (prev == lbAL || prev == lbNU) && next == lbOP, prev == lbCL && (next == lbAL || next == lbNU):
+		return false
+	}
+	return true
+}
+
+// lineBreaker finds line break opportunities in text that is read one character at a time.
+type lineBreaker struct {
+	started bool
+	prev    lbClass // Class of the last character that is not a space.
+	spaces  bool    // Whether spaces followed prev.
+}
+
+// next returns whether a line may be broken before c, and moves past c.
+func (lb *lineBreaker) next(c rune) bool {
+	cl := lbClassOf(c)
+	if !lb.started {
+		lb.started = true
+		if cl == lbCM {
+			cl = lbAL
+		}
+		lb.prev = cl
+		lb.spaces = cl == lbSP
+		return false
+	}
+	if cl == lbCM {
+		if !lb.spaces && lb.prev != lbBK {
+			// combining marks attach to the preceding character
+			return false
+		}
+		cl = lbAL
+	}
+	brk := lbBreak(lb.prev, lb.spaces, cl)
+	if cl == lbSP {
+		lb.spaces = true
+	} else {
+		lb.prev = cl
+		lb.spaces = false
+	}
+	return brk
+}
+
+// textGlyph is a character of text that is wrapped into lines.
+type textGlyph struct {
+	span, offset, size, width int  // Span is the index of the span in a RichText. Offset and size are in bytes, in the text.
+	space, newline, brk       bool // Brk is whether a line can be broken before this glyph.
+}
+
+// wrapGlyphs breaks glyphs into lines that fit in width, at line break opportunities when possible, and at glyph boundaries for words that do not fit.
+// For each line, line is called with the indices in glyphs of its start and end, and its width. Spaces at the end of a line are not part of it and do not count towards its width. Newlines end a line and are not part of any line.
+func wrapGlyphs(glyphs []textGlyph, width int, noWrap bool, line func(s, e, dx int)) {
+	s := 0     // index in glyphs of start of current line
+	x := 0     // width of current line
+	xText := 0 // width of current line without trailing spaces
+	brk := -1  // index in glyphs of last break opportunity in current line
+	brkX := 0  // width of current line up to brk
+	brkXText := 0
+	add := func(end, dx int) {
+		e := end
+		for e > s && glyphs[e-1].space {
+			e--
+		}
+		line(s, e, dx)
+		s = end
+		brk = -1
+	}
+	for i, g := range glyphs {
+		if g.newline {
+			add(i, xText)
+			s = i + 1
+			x = 0
+			xText = 0
+			continue
+		}
+		if g.brk && i > s {
+			brk = i
+			brkX = x
+			brkXText = xText
+		}
+		x += g.width
+		if g.space {
+			continue
+		}
+		prevXText := xText
+		xText = x
+		if noWrap || i == s || xText <= width {
+			continue
+		}
+		if brk > s {
+			add(brk, brkXText)
+			x -= brkX
+			xText -= brkX
+		} else {
+			add(i, prevXText)
+			x = g.width
+			xText = g.width
+		}
+	}
+	if s < len(glyphs) || s == 0 {
+		add(len(glyphs), xText)
+	}
+}
+
+// maxWordWidth returns the width of the widest text between line break opportunities in glyphs.
+func maxWordWidth(glyphs []textGlyph) int {
+	dx := 0
+	x := 0
+	for _, g := range glyphs {
+		if g.brk || g.newline {
+			x = 0
+		}
+		if g.space || g.newline {
+			continue
+		}
+		x += g.width
+		dx = maximum(dx, x)
+	}
+	return dx
+}
@@ -0,0 +1,148 @@
+package duit
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testGlyphs returns the glyphs of text, each one wide.
+func testGlyphs(text string) []textGlyph {
+	var glyphs []textGlyph
+	var lb lineBreaker
+	for o, c := range text {
+		glyphs = append(glyphs, textGlyph{offset: o, size: 1, width: 1, space: c == ' ', newline: c == '\n', brk: lb.next(c)})
+	}
+	return glyphs
+}
+
+func TestWrapGlyphs(t *testing.T) {
+	tests := []struct {
+		text   string
+		width  int
+		noWrap bool
+		lines  []string
+		widths []int
+	}{
+		{"", 10, false, []string{""}, []int{0}},
+		{"one two three", 100, false, []string{"one two three"}, []int{13}},
+		{"one two three", 7, false, []string{"one two", "three"}, []int{7, 5}},
+		{"one two three", 6, false, []string{"one", "two", "three"}, []int{3, 3, 5}},
+		{"one   two", 4, false, []string{"one", "two"}, []int{3, 3}},
+		{"abcdefgh", 3, false, []string{"abc", "def", "gh"}, []int{3, 3, 2}},
+		{"one\ntwo", 100, false, []string{"one", "two"}, []int{3, 3}},
+		{"one\n\ntwo", 100, false, []string{"one", "", "two"}, []int{3, 0, 3}},
+		{"one two three", 3, true, []string{"one two three"}, []int{13}},
+		{"well-known", 6, false, []string{"well-", "known"}, []int{5, 5}},
+	}
+	for _, test := range tests {
+		glyphs := testGlyphs(test.text)
+		var lines []string
+		var widths []int
+		wrapGlyphs(glyphs, test.width, test.noWrap, func(s, e, dx int) {
+			o, end := len(test.text), len(test.text)
+			if s < len(glyphs) {
+				o = glyphs[s].offset
+			}
+			if e < len(glyphs) {
+				end = glyphs[e].offset
+			}
+			lines = append(lines, test.text[o:end])
+			widths = append(widths, dx)
+		})
+		if !reflect.DeepEqual(lines, test.lines) || !reflect.DeepEqual(widths, test.widths) {
+			t.Errorf("%q, width %d: got lines %q, widths %v, expected %q, %v", test.text, test.width, lines, widths, test.lines, test.widths)
+		}
+	}
+}
+
+func TestMaxWordWidth(t *testing.T) {
+	if dx := maxWordWidth(testGlyphs("a bcd  ef\nghij")); dx != 4 {
+		t.Errorf("got %d, expected 4", dx)
+	}
+}
+
+// lbSegments returns text split at the line break opportunities found by a lineBreaker.
+func lbSegments(text string) []string {
+	var l []string
+	var lb lineBreaker
+	s := 0
+	for o, c := range text {
+		if lb.next(c) {
+			l = append(l, text[s:o])
+			s = o
+		}
+	}
+	return append(l, text[s:])
+}
+
+func TestLineBreaker(t *testing.T) {
+	tests := []struct {
+		text     string
+		segments []string
+	}{
+		{"one", []string{"one"}},
+		{"one two", []string{"one ", "two"}},
+		{"one   two", []string{"one   ", "two"}},
+		{"well-known", []string{"well-", "known"}},
+		{"a - b", []string{"a ", "- ", "b"}},
+		{"-5", []string{"-5"}},
+		{"10-20", []string{"10-20"}},
+		{"hello, world!", []string{"hello, ", "world!"}},
+		{"1,000.50", []string{"1,000.50"}},
+		{"a (b) c", []string{"a ", "(b) ", "c"}},
+		{`say "hi" now`, []string{"say ", `"hi" `, "now"}},
+		{"a b c", []string{"a b ", "c"}},
+		{"a\u200bb", []string{"a\u200b", "b"}},
+		{"a\u2060 b", []string{"a\u2060 b"}},
+		{"a\tb", []string{"a\t", "b"}},
+		{"a—b", []string{"a—", "b"}},
+		{"a\nb", []string{"a\n", "b"}},
+		{"a \nb", []string{"a \n", "b"}},
+		{"e\u0301x y", []string{"e\u0301x ", "y"}},
+		{"日本語", []string{"日", "本", "語"}},
+		{"日本。語", []string{"日", "本。", "語"}},
+		{"日ぁ本", []string{"日ぁ", "本"}},
+		{"「日本」です", []string{"「日", "本」", "で", "す"}},
+		{"abc日本", []string{"abc", "日", "本"}},
+	}
+	for _, test := range tests {
+		if l := lbSegments(test.text); !reflect.DeepEqual(l, test.segments) {
+			t.Errorf("%q: got %q, expected %q", test.text, l, test.segments)
+		}
+	}
+}
+
+func TestLbBreak(t *testing.T) {
+	tests := []struct {
+		prev   lbClass
+		spaces bool
+		next   lbClass
+		brk    bool
+	}{
+		{lbAL, false, lbAL, false},
+		{lbAL, true, lbAL, true},
+		{lbAL, true, lbCL, false},
+		{lbAL, true, lbEX, false},
+		{lbOP, true, lbAL, false},
+		{lbBK, false, lbAL, true},
+		{lbAL, false, lbBK, false},
+		{lbZW, false, lbAL, true},
+		{lbWJ, true, lbAL, false},
+		{lbGL, false, lbAL, false},
+		{lbBA, false, lbGL, true},
+		{lbHY, false, lbAL, true},
+		{lbHY, false, lbNU, false},
+		{lbAL, false, lbHY, false},
+		{lbID, false, lbID, true},
+		{lbID, false, lbNS, false},
+		{lbNU, false, lbIS, false},
+		{lbIS, false, lbNU, false},
+		{lbAL, false, lbOP, false},
+		{lbCL, false, lbAL, false},
+	}
+	for _, test := range tests {
+		if brk := lbBreak(test.prev, test.spaces, test.next); brk != test.brk {
+			t.Errorf("lbBreak(%d, %v, %d): got %v, expected %v", test.prev, test.spaces, test.next, brk, test.brk)
+		}
+	}
+}
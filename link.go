@@ -0,0 +1,148 @@
+package duit
+
+import (
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// linkCursor is the pointing hand shown while the mouse is over a link.
+var linkCursor = &draw.Cursor{
+	Point: image.Pt(-5, 0),
+	Clr: [2 * 16]uint8{
+		0x06, 0x00, 0x0f, 0x00, 0x0f, 0x00, 0x0f, 0x00, 0x0f, 0xc0, 0x0f, 0xf8, 0x6f, 0xfe, 0xff, 0xff,
+		0xff, 0xff, 0x7f, 0xff, 0x3f, 0xff, 0x3f, 0xfe, 0x1f, 0xfe, 0x0f, 0xfc, 0x0f, 0xfc, 0x0f, 0xfc,
+	},
+	Set: [2 * 16]uint8{
+		0x00, 0x00, 0x06, 0x00, 0x06, 0x00, 0x06, 0x00, 0x06, 0x00, 0x06, 0xc0, 0x06, 0xd8, 0x67, 0xf6,
+		0x77, 0xfe, 0x3f, 0xfe, 0x1f, 0xfe, 0x1f, 0xfc, 0x0f, 0xfc, 0x07, 0xf8, 0x07, 0xf8, 0x00, 0x00,
+	},
+}
+
+// Link is a line of text that calls a function when clicked, like a hyperlink.
+// While the mouse is over the link, its text is underlined and the mouse cursor is a pointing hand.
+// Links can be focused with tab, like buttons.
+//
+// Keys:
+//	\n, like button1 click, calls the Click function
+type Link struct {
+	Text    string           // Text to draw, on a single line.
+	Font    *draw.Font       `json:"-"` // For drawing text.
+	Colors  *LinkColors      `json:"-"` // Text colors. Defaults to DUI.Link.
+	Visited bool             // Whether the link has been followed, drawn in the visited color. Set when the link is clicked.
+	Click   func() (e Event) `json:"-"` // Called on button1 click, or enter.
+
+	m     draw.Mouse
+	size  image.Point
+	hover bool
+}
+
+var _ UI = &Link{}
+
+func (ui *Link) font(dui *DUI) *draw.Font {
+	return dui.Font(ui.Font)
+}
+
+func (ui *Link) colors(dui *DUI) *LinkColors {
+	if ui.Colors != nil {
+		return ui.Colors
+	}
+	return &dui.Link
+}
+
+// hovered updates the hover state and mouse cursor, and returns whether the hover state changed.
+func (ui *Link) hovered(dui *DUI, hover bool) bool {
+	if hover == ui.hover {
+		return false
+	}
+	ui.hover = hover
+	if hover {
+		dui.setCursor(ui, linkCursor)
+	} else {
+		dui.setCursor(ui, nil)
+	}
+	return true
+}
+
+// follow marks the link visited and calls Click.
+func (ui *Link) follow(self *Kid, r *Result) {
+	ui.Visited = true
+	self.Draw = Dirty
+	if ui.Click != nil {
+		e := ui.Click()
+		propagateEvent(self, r, e)
+	}
+}
+
+func (ui *Link) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+
+	ui.size = ui.font(dui).StringSize(ui.Text)
+	self.R = rect(ui.size)
+}
+
+func (ui *Link) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	return fixedSizes(ui.font(dui).StringSize(ui.Text))
+}
+
+func (ui *Link) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+
+	// the mouse may have left without a call to Mouse
+	ui.hovered(dui, m.In(rect(ui.size)))
+
+	colors := ui.colors(dui)
+	color := colors.Normal
+	if ui.hover {
+		color = colors.Hover
+	} else if ui.Visited {
+		color = colors.Visited
+	}
+	font := ui.font(dui)
+	drawBidiString(dui, img, orig, ui.size.X, color, font, ui.Text)
+	if ui.hover {
+		y := orig.Y + font.Ascent + 1
+		img.Draw(image.Rect(orig.X, y, orig.X+ui.size.X, y+1), color, nil, image.ZP)
+	}
+}
+
+func (ui *Link) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	hover := m.In(rect(ui.size))
+	if ui.hovered(dui, hover) {
+		self.Draw = Dirty
+	}
+	if hover && ui.m.Buttons&Button1 == Button1 && m.Buttons&Button1 == 0 {
+		ui.follow(self, &r)
+		r.Consumed = true
+	}
+	ui.m = m
+	return
+}
+
+func (ui *Link) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	if k == '\n' {
+		r.Consumed = true
+		ui.follow(self, &r)
+	}
+	return
+}
+
+func (ui *Link) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	p := image.Pt(0, ui.font(dui).Height/2)
+	return &p
+}
+
+func (ui *Link) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if o != ui {
+		return nil
+	}
+	return ui.FirstFocus(dui, self)
+}
+
+func (ui *Link) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return self.Mark(o, forLayout)
+}
+
+func (ui *Link) Print(self *Kid, indent int) {
+	PrintUI("Link", self, indent)
+}
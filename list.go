@@ -48,6 +48,18 @@ func (ui *List) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 	self.R = rect(ui.size)
 }
 
+// Measure returns the available width as preferred size, and the width of the widest value as minimum.
+func (ui *List) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	font := ui.font(dui)
+	dx := 0
+	for _, v := range ui.Values {
+		dx = maximum(dx, font.StringWidth(v.Text))
+	}
+	dy := len(ui.Values) * ui.rowHeight(dui)
+	size := image.Pt(sizeAvail.X, dy)
+	return Sizes{image.Pt(dx+2*(font.Height/4), dy), size, size}
+}
+
 func (ui *List) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	dui.debugDraw(self)
 
@@ -64,7 +76,8 @@ func (ui *List) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m d
 			colors = dui.Inverse
 			img.Draw(lineR, colors.Background, nil, image.ZP)
 		}
-		img.String(lineR.Min.Add(pt(font.Height/4)), colors.Text, image.ZP, font, v.Text)
+		pad := font.Height / 4
+		drawBidiString(dui, img, lineR.Min.Add(pt(pad)), lineR.Dx()-2*pad, colors.Text, font, v.Text)
 		lineR = lineR.Add(image.Pt(0, rowHeight))
 	}
 }
@@ -0,0 +1,460 @@
+package duit
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"9fans.net/go/draw"
+)
+
+// MarkdownFonts are the fonts used by Markdown.
+type MarkdownFonts struct {
+	Text       *draw.Font    // For regular text. If nil, the default font is used.
+	Bold       *draw.Font    // For strong emphasis. If nil, Text is used.
+	Italic     *draw.Font    // For emphasis. If nil, Text is used.
+	BoldItalic *draw.Font    // For text with both emphasis and strong emphasis. If nil, Bold or Italic is used.
+	Code       *draw.Font    // Monospace font for code spans and code blocks. If nil, Text is used.
+	Heading    [6]*draw.Font // For headings of level 1 to 6. If nil, the first three levels use a larger size of Text if it is served by fontsrv, all others use Bold.
+}
+
+// Markdown displays a document in Markdown format, following CommonMark.
+// Headings, emphasis, code spans, code blocks, lists, block quotes, thematic breaks, links and images are drawn, raw HTML is shown as text.
+// The document is drawn with RichText UIs, text can be selected and copied like in a RichText.
+// Put a Markdown in a Scroll for long documents.
+type Markdown struct {
+	Text  string                                // Document in Markdown format. Call MarkLayout after changing.
+	Fonts MarkdownFonts                         `json:"-"` // Fonts for text, emphasis, code and headings. Call MarkLayout after changing.
+	Link  func(url string) (e Event)            `json:"-"` // Called when a link is clicked, with its destination.
+	Image func(src string) (*draw.Image, error) `json:"-"` // Loads images by their source, called from the main loop during layout, so it should be quick. If nil, src is read as a local file with ReadImagePath. Images that cannot be loaded are shown as their alternative text. Markdown frees images when they are no longer in the document, so Image must return a new image each time.
+
+	text       string                 // Text that kids were made for.
+	fonts      MarkdownFonts          // Fonts that kids were made for.
+	kids       []*Kid                 // Single kid, with an mdBox for the document.
+	images     map[string]*draw.Image // Images in the document, by source. Nil for images that could not be loaded.
+	scaled     map[string]*draw.Font  // Fonts opened for headings, by name. Nil for fonts that could not be opened.
+	prevImages map[string]*draw.Image // Images of the previous document, reused while making the kids.
+	prevScaled map[string]*draw.Font  // Fonts of the previous document, reused while making the kids.
+}
+
+var _ UI = &Markdown{}
+
+// mdStyle is the style for inline text, determined by the elements the text is in.
+type mdStyle struct {
+	font         *draw.Font // For headings, instead of a font for emphasis.
+	bold, italic bool
+	link         bool
+	url          string
+}
+
+// ensure makes the UIs for the document when Text or Fonts have changed.
+// Images and fonts of the previous document are reused, those no longer needed are freed.
+func (ui *Markdown) ensure(dui *DUI) {
+	if ui.kids != nil && ui.text == ui.Text && ui.fonts == ui.Fonts {
+		return
+	}
+	ui.prevImages, ui.prevScaled = ui.images, ui.scaled
+	ui.images = map[string]*draw.Image{}
+	ui.scaled = map[string]*draw.Font{}
+	ui.text = ui.Text
+	ui.fonts = ui.Fonts
+	blocks, p := parseMarkdown(ui.Text)
+	ui.kids = NewKids(ui.blocksUI(dui, p, blocks, false))
+
+	for src, img := range ui.prevImages {
+		if _, ok := ui.images[src]; !ok {
+			img.Free()
+		}
+	}
+	for name, f := range ui.prevScaled {
+		if _, ok := ui.scaled[name]; !ok {
+			f.Free()
+		}
+	}
+	ui.prevImages, ui.prevScaled = nil, nil
+}
+
+func (ui *Markdown) blocksUI(dui *DUI, p *mdParser, blocks []*mdBlock, tight bool) *mdBox {
+	box := &mdBox{spacing: 10}
+	if tight {
+		box.spacing = 2
+	}
+	for _, b := range blocks {
+		box.Kids = append(box.Kids, &Kid{UI: ui.blockUI(dui, p, b)})
+	}
+	return box
+}
+
+func (ui *Markdown) blockUI(dui *DUI, p *mdParser, b *mdBlock) UI {
+	switch b.kind {
+	case mdHeading:
+		st := mdStyle{font: ui.headingFont(dui, b.level)}
+		return &RichText{Spans: ui.spans(dui, p.inlines(strings.Join(b.lines, "\n")), st)}
+	case mdCode:
+		rt := &RichText{Spans: []*Span{{Text: strings.Join(b.lines, "\n"), Font: ui.codeFont(dui)}}}
+		return &mdBox{Kids: NewKids(rt), padding: SpaceXY(6, 4), background: dui.Striped.Background}
+	case mdQuote:
+		box := ui.blocksUI(dui, p, b.kids, false)
+		box.indent = 16
+		box.bar = true
+		return box
+	case mdList:
+		box := &mdBox{spacing: 10}
+		if b.tight {
+			box.spacing = 2
+		}
+		for i, item := range b.kids {
+			itemBox := ui.blocksUI(dui, p, item.kids, b.tight)
+			itemBox.indent = 24
+			itemBox.marker = "•"
+			if b.ordered {
+				itemBox.marker = fmt.Sprintf("%d.", b.start+i)
+			}
+			itemBox.markerFont = ui.Fonts.Text
+			box.Kids = append(box.Kids, &Kid{UI: itemBox})
+		}
+		return box
+	case mdRule:
+		return &mdBox{rule: true, padding: SpaceXY(0, 4)}
+	}
+	return &RichText{Spans: ui.spans(dui, p.inlines(strings.Join(b.lines, "\n")), mdStyle{})}
+}
+
+func (ui *Markdown) spans(dui *DUI, nodes []*mdInline, st mdStyle) (spans []*Span) {
+	for _, x := range nodes {
+		nst := st
+		switch x.kind {
+		case mdText:
+			spans = append(spans, ui.span(dui, x.text, st))
+		case mdBreak:
+			spans = append(spans, ui.span(dui, "\n", st))
+		case mdCodeSpan:
+			s := ui.span(dui, x.text, st)
+			s.Font = ui.codeFont(dui)
+			s.Background = dui.Striped.Background
+			spans = append(spans, s)
+		case mdEmph:
+			nst.italic = true
+			spans = append(spans, ui.spans(dui, x.kids, nst)...)
+		case mdStrong:
+			nst.bold = true
+			spans = append(spans, ui.spans(dui, x.kids, nst)...)
+		case mdLink:
+			nst.link = true
+			nst.url = x.url
+			spans = append(spans, ui.spans(dui, x.kids, nst)...)
+		case mdImage:
+			if img := ui.image(dui, x.url); img != nil {
+				s := ui.span(dui, mdPlain(x.kids), st)
+				s.Image = img
+				spans = append(spans, s)
+			} else {
+				nst.italic = true
+				spans = append(spans, ui.spans(dui, x.kids, nst)...)
+			}
+		}
+	}
+	return
+}
+
+func (ui *Markdown) span(dui *DUI, text string, st mdStyle) *Span {
+	s := &Span{Text: text, Font: st.font}
+	if s.Font == nil {
+		s.Font = ui.font(dui, st.bold, st.italic)
+	}
+	if st.link {
+		url := st.url
+		s.Link = func() (e Event) {
+			if ui.Link != nil {
+				e = ui.Link(url)
+			}
+			return
+		}
+	}
+	return s
+}
+
+func (ui *Markdown) font(dui *DUI, bold, italic bool) *draw.Font {
+	f := ui.Fonts
+	switch {
+	case bold && italic && f.BoldItalic != nil:
+		return f.BoldItalic
+	case bold && f.Bold != nil:
+		return f.Bold
+	case italic && f.Italic != nil:
+		return f.Italic
+	}
+	return dui.Font(f.Text)
+}
+
+func (ui *Markdown) codeFont(dui *DUI) *draw.Font {
+	if ui.Fonts.Code != nil {
+		return ui.Fonts.Code
+	}
+	return dui.Font(ui.Fonts.Text)
+}
+
+func (ui *Markdown) headingFont(dui *DUI, level int) *draw.Font {
+	if f := ui.Fonts.Heading[level-1]; f != nil {
+		return f
+	}
+	if level <= 3 {
+		if f := ui.scaledFont(dui, []int{200, 150, 125}[level-1]); f != nil {
+			return f
+		}
+	}
+	return ui.font(dui, true, false)
+}
+
+var mdFontsrvRegexp = regexp.MustCompile(`^(.*/mnt/font/[^/]+/)([0-9]+)(a?/font)$`)
+
+// scaledFont returns the text font at percent of its size, opened through fontsrv. It returns nil if the text font is not served by fontsrv.
+func (ui *Markdown) scaledFont(dui *DUI, percent int) *draw.Font {
+	l := mdFontsrvRegexp.FindStringSubmatch(dui.Font(ui.Fonts.Text).Name)
+	if l == nil {
+		return nil
+	}
+	size, _ := strconv.Atoi(l[2])
+	name := fmt.Sprintf("%s%d%s", l[1], size*percent/100, l[3])
+	if f, ok := ui.scaled[name]; ok {
+		return f
+	}
+	if f, ok := ui.prevScaled[name]; ok {
+		ui.scaled[name] = f
+		return f
+	}
+	f, err := dui.Display.OpenFont(name)
+	if err != nil {
+		if dui.Debug {
+			log.Printf("duit: markdown: open font %s: %s\n", name, err)
+		}
+		f = nil
+	}
+	ui.scaled[name] = f
+	return f
+}
+
+// image returns the image for src, loading it if the previous document did not have it. It returns nil if the image could not be loaded.
+func (ui *Markdown) image(dui *DUI, src string) *draw.Image {
+	if img, ok := ui.images[src]; ok {
+		return img
+	}
+	if img, ok := ui.prevImages[src]; ok {
+		ui.images[src] = img
+		return img
+	}
+	var img *draw.Image
+	var err error
+	if ui.Image != nil {
+		img, err = ui.Image(src)
+	} else {
+		img, err = ReadImagePath(dui.Display, src)
+	}
+	if err != nil {
+		if dui.Debug {
+			log.Printf("duit: markdown: image %s: %s\n", src, err)
+		}
+		img = nil
+	}
+	ui.images[src] = img
+	return img
+}
+
+func (ui *Markdown) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	ui.ensure(dui)
+	dui.debugLayout(self)
+	if KidsLayout(dui, self, ui.kids, force) {
+		return
+	}
+
+	k := ui.kids[0]
+	k.UI.Layout(dui, k, sizeAvail, true)
+	k.R = rect(k.R.Size())
+	self.R = k.R
+}
+
+func (ui *Markdown) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	ui.ensure(dui)
+	return KidMeasure(dui, ui.kids[0], sizeAvail)
+}
+
+func (ui *Markdown) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	ui.ensure(dui)
+	KidsDraw(dui, self, ui.kids, ui.kids[0].R.Size(), nil, img, orig, m, force)
+}
+
+func (ui *Markdown) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	ui.ensure(dui)
+	return KidsMouse(dui, self, ui.kids, m, origM, orig)
+}
+
+func (ui *Markdown) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	ui.ensure(dui)
+	return KidsKey(dui, self, ui.kids, k, m, orig)
+}
+
+func (ui *Markdown) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	ui.ensure(dui)
+	return KidsFirstFocus(dui, self, ui.kids)
+}
+
+func (ui *Markdown) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	ui.ensure(dui)
+	return KidsFocus(dui, self, ui.kids, o)
+}
+
+func (ui *Markdown) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	if ui.kids == nil {
+		return self.Mark(o, forLayout)
+	}
+	return KidsMark(self, ui.kids, o, forLayout)
+}
+
+func (ui *Markdown) Print(self *Kid, indent int) {
+	PrintUI("Markdown", self, indent)
+	KidsPrint(ui.kids, indent+1)
+}
+
+// mdBox stacks the blocks of a Markdown document vertically, each at the full width.
+// It also draws the decorations of blocks: markers of list items, the bar of block quotes and thematic breaks.
+type mdBox struct {
+	Kids       []*Kid
+	indent     int        // In lowDPI pixels, before the kids, for list items and block quotes.
+	marker     string     // Drawn at the end of the indent, for list items.
+	markerFont *draw.Font // For marker.
+	bar        bool       // Draw a bar in the indent, for block quotes.
+	rule       bool       // Draw a horizontal line, for thematic breaks.
+	padding    Space      // In lowDPI pixels.
+	spacing    int        // In lowDPI pixels, between kids.
+	background *draw.Image
+
+	size image.Point
+}
+
+var _ UI = &mdBox{}
+
+func (ui *mdBox) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+	if KidsLayout(dui, self, ui.Kids, force) {
+		return
+	}
+
+	padding := dui.ScaleSpace(ui.padding)
+	x := padding.Left + dui.Scale(ui.indent)
+	width := maximum(0, sizeAvail.X-x-padding.Right)
+	y := padding.Top
+	xmax := sizeAvail.X
+	n := 0
+	for _, k := range ui.Kids {
+		if k.Hidden {
+			continue
+		}
+		if n > 0 {
+			y += dui.Scale(ui.spacing)
+		}
+		n++
+		k.UI.Layout(dui, k, image.Pt(width, sizeAvail.Y-y), true)
+		k.R = k.R.Add(image.Pt(x, y))
+		y += k.R.Dy()
+		xmax = maximum(xmax, k.R.Max.X+padding.Right)
+	}
+	if ui.rule {
+		y += dui.Scale(1)
+	}
+	if ui.marker != "" {
+		y = maximum(y, padding.Top+dui.Font(ui.markerFont).Height)
+	}
+	ui.size = image.Pt(xmax, y+padding.Bottom)
+	if dui.RTL {
+		kidsMirror(ui.Kids, ui.size.X)
+	}
+	self.R = rect(ui.size)
+}
+
+// Measure stacks the sizes of the kids. The preferred width is the available width.
+func (ui *mdBox) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	padding := dui.ScaleSpace(ui.padding)
+	x := padding.Left + dui.Scale(ui.indent)
+	width := maximum(0, sizeAvail.X-x-padding.Right)
+	var s Sizes
+	n := 0
+	for _, k := range ui.Kids {
+		if k.Hidden {
+			continue
+		}
+		ks := KidMeasure(dui, k, image.Pt(width, sizeAvail.Y))
+		s.Min = image.Pt(maximum(s.Min.X, ks.Min.X), s.Min.Y+ks.Min.Y)
+		s.Pref = image.Pt(maximum(s.Pref.X, ks.Pref.X), s.Pref.Y+ks.Pref.Y)
+		s.Max = image.Pt(maximum(s.Max.X, ks.Max.X), s.Max.Y+ks.Max.Y)
+		n++
+	}
+	extra := image.Pt(x+padding.Right, padding.Dy()+dui.Scale(ui.spacing)*maximum(0, n-1))
+	if ui.rule {
+		extra.Y += dui.Scale(1)
+	}
+	s.Min = s.Min.Add(extra)
+	s.Pref = s.Pref.Add(extra)
+	s.Max = s.Max.Add(extra)
+	s.Pref.X = maximum(s.Pref.X, sizeAvail.X)
+	s.Max.X = maximum(s.Max.X, s.Pref.X)
+	return s
+}
+
+func (ui *mdBox) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	full := force || self.Draw == Dirty
+	KidsDraw(dui, self, ui.Kids, ui.size, ui.background, img, orig, m, force)
+	if !full {
+		return
+	}
+
+	padding := dui.ScaleSpace(ui.padding)
+	mirror := func(r image.Rectangle) image.Rectangle {
+		if dui.RTL {
+			r.Min.X, r.Max.X = ui.size.X-r.Max.X, ui.size.X-r.Min.X
+		}
+		return r.Add(orig)
+	}
+	if ui.rule {
+		r := image.Rect(padding.Left, padding.Top, ui.size.X-padding.Right, padding.Top+dui.Scale(1))
+		img.Draw(mirror(r), dui.Gutter, nil, image.ZP)
+	}
+	if ui.bar {
+		x := padding.Left + dui.Scale(4)
+		r := image.Rect(x, padding.Top, x+dui.Scale(3), ui.size.Y-padding.Bottom)
+		img.Draw(mirror(r), dui.Gutter, nil, image.ZP)
+	}
+	if ui.marker != "" {
+		font := dui.Font(ui.markerFont)
+		x := padding.Left + dui.Scale(ui.indent) - dui.Scale(6)
+		r := image.Rect(x-font.StringWidth(ui.marker), padding.Top, x, padding.Top+font.Height)
+		img.String(mirror(r).Min, dui.Regular.Normal.Text, image.ZP, font, ui.marker)
+	}
+}
+
+func (ui *mdBox) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	return KidsMouse(dui, self, ui.Kids, m, origM, orig)
+}
+
+func (ui *mdBox) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	return KidsKey(dui, self, ui.Kids, k, m, orig)
+}
+
+func (ui *mdBox) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	return KidsFirstFocus(dui, self, ui.Kids)
+}
+
+func (ui *mdBox) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	return KidsFocus(dui, self, ui.Kids, o)
+}
+
+func (ui *mdBox) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return KidsMark(self, ui.Kids, o, forLayout)
+}
+
+func (ui *mdBox) Print(self *Kid, indent int) {
+	PrintUI("mdBox", self, indent)
+	KidsPrint(ui.Kids, indent+1)
+}
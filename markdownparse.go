@@ -0,0 +1,796 @@
+package duit
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Parsing of Markdown for the Markdown UI.
+// This follows CommonMark for the parts used in typical documents: headings, paragraphs, code blocks, block quotes, lists, thematic breaks, emphasis, code spans, links, images, autolinks, entities, escapes and hard line breaks.
+// Raw HTML is kept as text, and the rules for some edge cases are simplified.
+
+type mdKind byte
+
+const (
+	mdParagraph mdKind = iota
+	mdHeading
+	mdCode // Fenced or indented code block.
+	mdQuote
+	mdList
+	mdItem
+	mdRule // Thematic break.
+)
+
+type mdBlock struct {
+	kind    mdKind
+	level   int      // Of heading, 1 to 6.
+	lines   []string // Of paragraph, heading or code block.
+	ordered bool     // For list.
+	start   int      // Number of first item of an ordered list.
+	tight   bool     // List without blank lines between its items.
+	kids    []*mdBlock
+}
+
+type mdInlineKind byte
+
+const (
+	mdText mdInlineKind = iota
+	mdCodeSpan
+	mdEmph
+	mdStrong
+	mdLink
+	mdImage
+	mdBreak // Hard line break.
+)
+
+type mdInline struct {
+	kind       mdInlineKind
+	text       string // Of text or code span.
+	url, title string // Of link or image.
+	kids       []*mdInline
+
+	// Set while parsing for delimiter runs of * and _, which turn into text when not matched.
+	delim             byte
+	count, origCount  int
+	canOpen, canClose bool
+	bracket, active   bool // Text is [ or ![, possibly starting a link or image.
+	image             bool // Bracket is ![.
+	pos               int  // Offset of bracket in source.
+}
+
+type mdRef struct {
+	url, title string
+}
+
+type mdParser struct {
+	refs map[string]mdRef // Link reference definitions, by normalized label.
+}
+
+// parseMarkdown parses text into blocks. The returned parser is needed to parse the inline text of blocks, it holds the link reference definitions.
+func parseMarkdown(text string) ([]*mdBlock, *mdParser) {
+	p := &mdParser{refs: map[string]mdRef{}}
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = mdExpandTabs(line)
+	}
+	return p.blocks(lines), p
+}
+
+func mdExpandTabs(s string) string {
+	if strings.IndexByte(s, '\t') < 0 {
+		return s
+	}
+	r := ""
+	n := 0
+	for _, c := range s {
+		if c == '\t' {
+			r += strings.Repeat(" ", 4-n%4)
+			n += 4 - n%4
+			continue
+		}
+		r += string(c)
+		n++
+	}
+	return r
+}
+
+func mdBlank(s string) bool {
+	return strings.TrimLeft(s, " ") == ""
+}
+
+func mdIndent(s string) int {
+	return len(s) - len(strings.TrimLeft(s, " "))
+}
+
+// mdStripIndent removes up to n leading spaces.
+func mdStripIndent(s string, n int) string {
+	return s[minimum(n, mdIndent(s)):]
+}
+
+var (
+	mdFenceRegexp    = regexp.MustCompile("^ {0,3}(```+|~~~+)([^`]*)$")
+	mdATXRegexp      = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ ]+(.*?))??(?:[ ]+#+)?[ ]*$`)
+	mdRuleRegexp     = regexp.MustCompile(`^ {0,3}(?:(?:\*[ ]*){3,}|(?:-[ ]*){3,}|(?:_[ ]*){3,})$`)
+	mdSetextRegexp   = regexp.MustCompile(`^ {0,3}(=+|-+)[ ]*$`)
+	mdItemRegexp     = regexp.MustCompile(`^( {0,3})([-+*]|[0-9]{1,9}[.)])( *)(.*)$`)
+	mdRefDefRegexp   = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:[ ]*(<[^>]*>|[^ ]+)(?:[ ]+("[^"]*"|'[^']*'|\([^)]*\)))?[ ]*$`)
+	mdAutolinkRegexp = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^ <>]*)>`)
+	mdEmailRegexp    = regexp.MustCompile(`^<([a-zA-Z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*)>`)
+	mdEntityRegexp   = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+)
+
+var mdEntities = map[string]string{
+	"amp":    "&",
+	"lt":     "<",
+	"gt":     ">",
+	"quot":   `"`,
+	"apos":   "'",
+	"nbsp":   " ",
+	"copy":   "©",
+	"reg":    "®",
+	"trade":  "™",
+	"mdash":  "—",
+	"ndash":  "–",
+	"hellip": "…",
+	"laquo":  "«",
+	"raquo":  "»",
+	"ldquo":  "“",
+	"rdquo":  "”",
+	"lsquo":  "‘",
+	"rsquo":  "’",
+	"middot": "·",
+	"bull":   "•",
+	"times":  "×",
+	"deg":    "°",
+	"euro":   "€",
+}
+
+// mdListItem describes the marker at the start of a list item.
+type mdListItem struct {
+	ordered bool
+	delim   byte   // Bullet character, or . or ) for ordered lists.
+	num     int    // Of ordered list item.
+	width   int    // Indent of the content of the item.
+	content string // Text after the marker on the first line.
+}
+
+func mdParseItem(line string) (m mdListItem, ok bool) {
+	l := mdItemRegexp.FindStringSubmatch(line)
+	if l == nil {
+		return
+	}
+	marker, spaces, rest := l[2], l[3], l[4]
+	if spaces == "" && rest != "" {
+		return
+	}
+	m.delim = marker[len(marker)-1]
+	if m.delim == '.' || m.delim == ')' {
+		m.ordered = true
+		m.num, _ = strconv.Atoi(marker[:len(marker)-1])
+	}
+	m.width = len(l[1]) + len(marker) + len(spaces)
+	if rest == "" || len(spaces) > 4 {
+		// content starting with more spaces is indented code
+		m.width = len(l[1]) + len(marker) + 1
+	}
+	if m.width < len(line) {
+		m.content = line[m.width:]
+	}
+	return m, true
+}
+
+// interrupts returns whether the list item can start while a paragraph is being read.
+func (m mdListItem) interrupts() bool {
+	return !mdBlank(m.content) && (!m.ordered || m.num == 1)
+}
+
+func mdQuoteLine(line string) (string, bool) {
+	if mdIndent(line) > 3 {
+		return "", false
+	}
+	s := strings.TrimLeft(line, " ")
+	if !strings.HasPrefix(s, ">") {
+		return "", false
+	}
+	s = s[1:]
+	if strings.HasPrefix(s, " ") {
+		s = s[1:]
+	}
+	return s, true
+}
+
+// mdClosesFence returns whether line closes a code block started with fence.
+func mdClosesFence(line, fence string) bool {
+	if mdIndent(line) > 3 {
+		return false
+	}
+	s := strings.TrimSpace(line)
+	return len(s) >= len(fence) && strings.Trim(s, fence[:1]) == ""
+}
+
+// mdStartsBlock returns whether line starts a block that ends a paragraph.
+func mdStartsBlock(line string) bool {
+	if mdBlank(line) || mdFenceRegexp.MatchString(line) || mdATXRegexp.MatchString(line) || mdRuleRegexp.MatchString(line) {
+		return true
+	}
+	if _, ok := mdQuoteLine(line); ok {
+		return true
+	}
+	m, ok := mdParseItem(line)
+	return ok && m.interrupts()
+}
+
+func mdNormalizeLabel(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+func (p *mdParser) blocks(lines []string) []*mdBlock {
+	var blocks []*mdBlock
+	var para *mdBlock // Paragraph being read, that following lines may continue.
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		if mdBlank(line) {
+			para = nil
+			i++
+			continue
+		}
+		indent := mdIndent(line)
+
+		if para != nil && indent < 4 {
+			if l := mdSetextRegexp.FindStringSubmatch(line); l != nil {
+				para.kind = mdHeading
+				para.level = 1
+				if l[1][0] == '-' {
+					para.level = 2
+				}
+				para = nil
+				i++
+				continue
+			}
+		}
+
+		if indent >= 4 {
+			if para != nil {
+				para.lines = append(para.lines, strings.TrimLeft(line, " "))
+				i++
+				continue
+			}
+			var code []string
+			j := i
+			for ; j < len(lines) && (mdBlank(lines[j]) || mdIndent(lines[j]) >= 4); j++ {
+				code = append(code, mdStripIndent(lines[j], 4))
+			}
+			for mdBlank(code[len(code)-1]) {
+				code = code[:len(code)-1]
+			}
+			blocks = append(blocks, &mdBlock{kind: mdCode, lines: code})
+			i = j
+			continue
+		}
+
+		if l := mdFenceRegexp.FindStringSubmatch(line); l != nil {
+			fence := l[1]
+			var code []string
+			closed := false
+			j := i + 1
+			for ; j < len(lines) && !closed; j++ {
+				if mdClosesFence(lines[j], fence) {
+					closed = true
+					continue
+				}
+				code = append(code, mdStripIndent(lines[j], indent))
+			}
+			blocks = append(blocks, &mdBlock{kind: mdCode, lines: code})
+			para = nil
+			i = j
+			continue
+		}
+
+		if l := mdATXRegexp.FindStringSubmatch(line); l != nil {
+			blocks = append(blocks, &mdBlock{kind: mdHeading, level: len(l[1]), lines: []string{l[2]}})
+			para = nil
+			i++
+			continue
+		}
+
+		if mdRuleRegexp.MatchString(line) {
+			blocks = append(blocks, &mdBlock{kind: mdRule})
+			para = nil
+			i++
+			continue
+		}
+
+		if _, ok := mdQuoteLine(line); ok {
+			var inner []string
+			j := i
+			for ; j < len(lines); j++ {
+				if s, ok := mdQuoteLine(lines[j]); ok {
+					inner = append(inner, s)
+					continue
+				}
+				// lazy continuation of a paragraph in the quote
+				if !mdBlank(lines[j]) && !mdBlank(inner[len(inner)-1]) && !mdStartsBlock(lines[j]) {
+					inner = append(inner, lines[j])
+					continue
+				}
+				break
+			}
+			blocks = append(blocks, &mdBlock{kind: mdQuote, kids: p.blocks(inner)})
+			para = nil
+			i = j
+			continue
+		}
+
+		if m, ok := mdParseItem(line); ok && (para == nil || m.interrupts()) {
+			list := &mdBlock{kind: mdList, ordered: m.ordered, start: m.num, tight: true}
+			j := i
+			for j < len(lines) && !mdRuleRegexp.MatchString(lines[j]) {
+				mm, ok := mdParseItem(lines[j])
+				if !ok || mm.ordered != m.ordered || mm.delim != m.delim {
+					break
+				}
+				item := []string{mm.content}
+				k := j + 1
+				for ; k < len(lines); k++ {
+					l := lines[k]
+					if mdBlank(l) {
+						item = append(item, "")
+					} else if mdIndent(l) >= mm.width {
+						item = append(item, l[mm.width:])
+					} else if _, ok := mdParseItem(l); ok {
+						// next item, whatever its number, of this list or a new one
+						break
+					} else if !mdBlank(item[len(item)-1]) && !mdStartsBlock(l) {
+						// lazy continuation
+						item = append(item, l)
+					} else {
+						break
+					}
+				}
+				n := len(item)
+				for n > 1 && mdBlank(item[n-1]) {
+					n--
+				}
+				kids := p.blocks(item[:n])
+				if len(kids) > 1 {
+					for _, l := range item[1:n] {
+						if mdBlank(l) {
+							list.tight = false
+						}
+					}
+				}
+				list.kids = append(list.kids, &mdBlock{kind: mdItem, kids: kids})
+				if n < len(item) && k < len(lines) {
+					// blank lines between items make the list loose
+					if mm2, ok := mdParseItem(lines[k]); ok && mm2.ordered == m.ordered && mm2.delim == m.delim {
+						list.tight = false
+					}
+				}
+				j = k
+			}
+			blocks = append(blocks, list)
+			para = nil
+			i = j
+			continue
+		}
+
+		if para != nil {
+			para.lines = append(para.lines, strings.TrimLeft(line, " "))
+			i++
+			continue
+		}
+
+		if l := mdRefDefRegexp.FindStringSubmatch(line); l != nil {
+			label := mdNormalizeLabel(l[1])
+			if _, ok := p.refs[label]; !ok {
+				url := strings.TrimSuffix(strings.TrimPrefix(l[2], "<"), ">")
+				title := ""
+				if len(l[3]) >= 2 {
+					title = l[3][1 : len(l[3])-1]
+				}
+				p.refs[label] = mdRef{mdUnescape(url), mdUnescape(title)}
+			}
+			i++
+			continue
+		}
+
+		para = &mdBlock{kind: mdParagraph, lines: []string{strings.TrimLeft(line, " ")}}
+		blocks = append(blocks, para)
+		i++
+	}
+	return blocks
+}
+
+func mdPunct(c byte) bool {
+	return c < 0x80 && strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+// mdUnescape resolves backslash escapes and entities.
+func mdUnescape(s string) string {
+	if strings.IndexAny(s, `\&`) < 0 {
+		return s
+	}
+	r := ""
+	for i := 0; i < len(s); {
+		if s[i] == '\\' && i+1 < len(s) && mdPunct(s[i+1]) {
+			r += s[i+1 : i+2]
+			i += 2
+		} else if e, n := mdEntity(s[i:]); n > 0 {
+			r += e
+			i += n
+		} else {
+			r += s[i : i+1]
+			i++
+		}
+	}
+	return r
+}
+
+// mdEntity returns the text for the entity at the start of s, and its length in s, or 0 if s does not start with a known entity.
+func mdEntity(s string) (string, int) {
+	m := mdEntityRegexp.FindString(s)
+	if m == "" {
+		return "", 0
+	}
+	name := m[1 : len(m)-1]
+	if name[0] != '#' {
+		if e, ok := mdEntities[name]; ok {
+			return e, len(m)
+		}
+		return "", 0
+	}
+	var v int64
+	var err error
+	if name[1] == 'x' || name[1] == 'X' {
+		v, err = strconv.ParseInt(name[2:], 16, 32)
+	} else {
+		v, err = strconv.ParseInt(name[1:], 10, 32)
+	}
+	c := rune(v)
+	if err != nil || c == 0 || !utf8.ValidRune(c) {
+		c = utf8.RuneError
+	}
+	return string(c), len(m)
+}
+
+// inlines parses the text of a paragraph or heading.
+func (p *mdParser) inlines(s string) []*mdInline {
+	s = strings.TrimRight(s, " ")
+	var nodes []*mdInline
+	text := ""
+	flush := func() {
+		if text != "" {
+			nodes = append(nodes, &mdInline{kind: mdText, text: text})
+			text = ""
+		}
+	}
+	skipSpaces := func(i int) int {
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		return i
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			flush()
+			nodes = append(nodes, &mdInline{kind: mdBreak})
+			i = skipSpaces(i + 2)
+
+		case c == '\\' && i+1 < len(s) && mdPunct(s[i+1]):
+			text += s[i+1 : i+2]
+			i += 2
+
+		case c == '\n':
+			trimmed := strings.TrimRight(text, " ")
+			hard := len(text)-len(trimmed) >= 2
+			text = trimmed
+			if hard {
+				flush()
+				nodes = append(nodes, &mdInline{kind: mdBreak})
+			} else {
+				text += " "
+			}
+			i = skipSpaces(i + 1)
+
+		case c == '`':
+			n := mdRun(s[i:], '`')
+			end := -1
+			for j := i + n; j < len(s); {
+				if s[j] != '`' {
+					j++
+					continue
+				}
+				nn := mdRun(s[j:], '`')
+				if nn == n {
+					end = j
+					break
+				}
+				j += nn
+			}
+			if end < 0 {
+				text += s[i : i+n]
+				i += n
+				break
+			}
+			code := strings.Replace(s[i+n:end], "\n", " ", -1)
+			if len(code) >= 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+				code = code[1 : len(code)-1]
+			}
+			flush()
+			nodes = append(nodes, &mdInline{kind: mdCodeSpan, text: code})
+			i = end + n
+
+		case c == '*' || c == '_':
+			n := mdRun(s[i:], c)
+			before, _ := utf8.DecodeLastRuneInString(s[:i])
+			after, _ := utf8.DecodeRuneInString(s[i+n:])
+			if i == 0 {
+				before = ' '
+			}
+			if i+n == len(s) {
+				after = ' '
+			}
+			left := !mdSpace(after) && (!mdPunctRune(after) || mdSpace(before) || mdPunctRune(before))
+			right := !mdSpace(before) && (!mdPunctRune(before) || mdSpace(after) || mdPunctRune(after))
+			d := &mdInline{kind: mdText, text: s[i : i+n], delim: c, count: n, origCount: n}
+			if c == '*' {
+				d.canOpen = left
+				d.canClose = right
+			} else {
+				d.canOpen = left && (!right || mdPunctRune(before))
+				d.canClose = right && (!left || mdPunctRune(after))
+			}
+			flush()
+			nodes = append(nodes, d)
+			i += n
+
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			flush()
+			nodes = append(nodes, &mdInline{kind: mdText, text: "![", bracket: true, active: true, image: true, pos: i})
+			i += 2
+
+		case c == '[':
+			flush()
+			nodes = append(nodes, &mdInline{kind: mdText, text: "[", bracket: true, active: true, pos: i})
+			i++
+
+		case c == ']':
+			flush()
+			o := len(nodes) - 1
+			for o >= 0 && !nodes[o].bracket {
+				o--
+			}
+			if o < 0 {
+				text += "]"
+				i++
+				break
+			}
+			opener := nodes[o]
+			opener.bracket = false
+			var url, title string
+			n, ok := 0, false
+			if opener.active {
+				url, title, n, ok = p.linkTail(s[i+1:], s[opener.pos+len(opener.text):i])
+			}
+			if !ok {
+				text += "]"
+				i++
+				break
+			}
+			link := &mdInline{kind: mdLink, url: url, title: title, kids: mdEmphasis(nodes[o+1:])}
+			if opener.image {
+				link.kind = mdImage
+			} else {
+				// links cannot contain other links
+				for _, x := range nodes[:o] {
+					if x.bracket && !x.image {
+						x.active = false
+					}
+				}
+			}
+			nodes = append(nodes[:o:o], link)
+			i += 1 + n
+
+		case c == '<':
+			if l := mdAutolinkRegexp.FindStringSubmatch(s[i:]); l != nil {
+				flush()
+				nodes = append(nodes, &mdInline{kind: mdLink, url: l[1], kids: []*mdInline{{kind: mdText, text: l[1]}}})
+				i += len(l[0])
+			} else if l := mdEmailRegexp.FindStringSubmatch(s[i:]); l != nil {
+				flush()
+				nodes = append(nodes, &mdInline{kind: mdLink, url: "mailto:" + l[1], kids: []*mdInline{{kind: mdText, text: l[1]}}})
+				i += len(l[0])
+			} else {
+				text += "<"
+				i++
+			}
+
+		case c == '&':
+			if e, n := mdEntity(s[i:]); n > 0 {
+				text += e
+				i += n
+			} else {
+				text += "&"
+				i++
+			}
+
+		default:
+			text += s[i : i+1]
+			i++
+		}
+	}
+	flush()
+	return mdEmphasis(nodes)
+}
+
+func mdRun(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func mdSpace(c rune) bool {
+	return unicode.IsSpace(c)
+}
+
+func mdPunctRune(c rune) bool {
+	return c < 0x80 && mdPunct(byte(c)) || unicode.IsPunct(c) || unicode.IsSymbol(c)
+}
+
+// linkTail parses what follows the closing bracket of a link with text label: an inline destination and title, or a reference.
+// It returns the length of s that was consumed.
+func (p *mdParser) linkTail(s, label string) (url, title string, n int, ok bool) {
+	if strings.HasPrefix(s, "(") {
+		if url, title, n, ok = mdInlineLink(s); ok {
+			return
+		}
+	}
+	ref := label
+	if strings.HasPrefix(s, "[]") {
+		n = 2
+	} else if strings.HasPrefix(s, "[") {
+		if e := strings.IndexByte(s, ']'); e > 1 {
+			ref = s[1:e]
+			n = e + 1
+		}
+	}
+	r, ok := p.refs[mdNormalizeLabel(ref)]
+	return r.url, r.title, n, ok
+}
+
+// mdInlineLink parses "(destination title)".
+func mdInlineLink(s string) (url, title string, n int, ok bool) {
+	i := 1
+	skip := func() {
+		for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+			i++
+		}
+	}
+	skip()
+	if i < len(s) && s[i] == '<' {
+		e := strings.IndexAny(s[i+1:], ">\n")
+		if e < 0 || s[i+1+e] != '>' {
+			return
+		}
+		url = s[i+1 : i+1+e]
+		i += e + 2
+	} else {
+		start := i
+		depth := 0
+	dest:
+		for ; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '(':
+				depth++
+			case ')':
+				if depth == 0 {
+					break dest
+				}
+				depth--
+			case ' ', '\n':
+				break dest
+			}
+		}
+		if i > len(s) {
+			i = len(s)
+		}
+		url = s[start:i]
+	}
+	skip()
+	if i < len(s) && strings.IndexByte(`"'(`, s[i]) >= 0 {
+		end := s[i]
+		if end == '(' {
+			end = ')'
+		}
+		e := strings.IndexByte(s[i+1:], end)
+		if e < 0 {
+			return
+		}
+		title = s[i+1 : i+1+e]
+		i += e + 2
+		skip()
+	}
+	if i >= len(s) || s[i] != ')' {
+		return
+	}
+	return mdUnescape(url), mdUnescape(title), i + 1, true
+}
+
+// mdEmphasis matches the * and _ delimiter runs in nodes into emphasis, following the CommonMark rules.
+// Unmatched delimiters remain as text.
+func mdEmphasis(nodes []*mdInline) []*mdInline {
+	for c := 0; c < len(nodes); c++ {
+		closer := nodes[c]
+		if closer.delim == 0 || !closer.canClose {
+			continue
+		}
+		for closer.count > 0 {
+			o := c - 1
+			for ; o >= 0; o-- {
+				x := nodes[o]
+				if x.delim != closer.delim || !x.canOpen || x.count == 0 {
+					continue
+				}
+				// the "rule of 3"
+				if (x.canClose || closer.canOpen) && (x.origCount+closer.origCount)%3 == 0 && (x.origCount%3 != 0 || closer.origCount%3 != 0) {
+					continue
+				}
+				break
+			}
+			if o < 0 {
+				break
+			}
+			opener := nodes[o]
+			n := 1
+			kind := mdEmph
+			if opener.count >= 2 && closer.count >= 2 {
+				n = 2
+				kind = mdStrong
+			}
+			opener.count -= n
+			closer.count -= n
+			opener.text = strings.Repeat(string(opener.delim), opener.count)
+			closer.text = strings.Repeat(string(closer.delim), closer.count)
+			e := &mdInline{kind: kind, kids: append([]*mdInline{}, nodes[o+1:c]...)}
+			l := append([]*mdInline{}, nodes[:o+1]...)
+			l = append(l, e)
+			nodes = append(l, nodes[c:]...)
+			c = o + 2
+			if opener.count == 0 {
+				nodes = append(nodes[:o], nodes[o+1:]...)
+				c--
+			}
+		}
+		if closer.count == 0 {
+			nodes = append(nodes[:c], nodes[c+1:]...)
+			c--
+		}
+	}
+	return nodes
+}
+
+// mdPlain returns the text of nodes without formatting, for the alt text of images.
+func mdPlain(nodes []*mdInline) string {
+	r := ""
+	for _, x := range nodes {
+		switch x.kind {
+		case mdText, mdCodeSpan:
+			r += x.text
+		case mdBreak:
+			r += " "
+		default:
+			r += mdPlain(x.kids)
+		}
+	}
+	return r
+}
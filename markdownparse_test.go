@@ -0,0 +1,242 @@
+package duit
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mdItemTexts returns the lines of the paragraph in each item of list.
+func mdItemTexts(t *testing.T, list *mdBlock) (l [][]string) {
+	t.Helper()
+	for _, item := range list.kids {
+		if len(item.kids) != 1 || item.kids[0].kind != mdParagraph {
+			t.Fatalf("item with %d blocks, expected a single paragraph", len(item.kids))
+		}
+		l = append(l, item.kids[0].lines)
+	}
+	return
+}
+
+func TestMarkdownOrderedList(t *testing.T) {
+	tests := []struct {
+		text  string
+		start int
+		tight bool
+		items [][]string
+	}{
+		{"1. one\n2. two", 1, true, [][]string{{"one"}, {"two"}}},
+		{"1. one\n2. two\n3. three", 1, true, [][]string{{"one"}, {"two"}, {"three"}}},
+		{"3) three\n4) four", 3, true, [][]string{{"three"}, {"four"}}},
+		{"1. one\n\n2. two", 1, false, [][]string{{"one"}, {"two"}}},
+		{"1. one\ncontinued\n2. two", 1, true, [][]string{{"one", "continued"}, {"two"}}},
+		{"1. one\n   indented\n2. two", 1, true, [][]string{{"one", "indented"}, {"two"}}},
+	}
+	for _, test := range tests {
+		blocks, _ := parseMarkdown(test.text)
+		if len(blocks) != 1 || blocks[0].kind != mdList {
+			t.Errorf("%q: got %d blocks, expected a single list", test.text, len(blocks))
+			continue
+		}
+		list := blocks[0]
+		if !list.ordered || list.start != test.start || list.tight != test.tight {
+			t.Errorf("%q: got ordered %v, start %d, tight %v, expected ordered true, start %d, tight %v", test.text, list.ordered, list.start, list.tight, test.start, test.tight)
+		}
+		if items := mdItemTexts(t, list); !reflect.DeepEqual(items, test.items) {
+			t.Errorf("%q: got items %q, expected %q", test.text, items, test.items)
+		}
+	}
+}
+
+func TestMarkdownOrderedListDelims(t *testing.T) {
+	// a different delimiter starts a new list
+	blocks, _ := parseMarkdown("1. one\n2) two")
+	if len(blocks) != 2 || blocks[0].kind != mdList || blocks[1].kind != mdList {
+		t.Fatalf("got %d blocks, expected two lists", len(blocks))
+	}
+
+	// only an ordered list starting at 1 interrupts a paragraph
+	blocks, _ = parseMarkdown("text\n2. two")
+	if len(blocks) != 1 || blocks[0].kind != mdParagraph || len(blocks[0].lines) != 2 {
+		t.Fatalf("got %d blocks, expected a single paragraph of two lines", len(blocks))
+	}
+}
+
+// mdHTML returns blocks as HTML, similar to CommonMark, for comparing the parsed structure.
+func mdHTML(p *mdParser, blocks []*mdBlock, tight bool) string {
+	s := ""
+	for _, b := range blocks {
+		switch b.kind {
+		case mdParagraph:
+			text := mdInlineHTML(p.inlines(strings.Join(b.lines, "\n")))
+			if tight {
+				s += text
+			} else {
+				s += "<p>" + text + "</p>"
+			}
+		case mdHeading:
+			s += fmt.Sprintf("<h%d>%s</h%d>", b.level, mdInlineHTML(p.inlines(strings.Join(b.lines, "\n"))), b.level)
+		case mdCode:
+			s += "<pre>" + strings.Join(b.lines, "\n") + "</pre>"
+		case mdQuote:
+			s += "<blockquote>" + mdHTML(p, b.kids, false) + "</blockquote>"
+		case mdList:
+			tag := "ul"
+			if b.ordered {
+				tag = "ol"
+			}
+			s += "<" + tag
+			if b.ordered && b.start != 1 {
+				s += fmt.Sprintf(` start="%d"`, b.start)
+			}
+			s += ">"
+			for _, item := range b.kids {
+				s += "<li>" + mdHTML(p, item.kids, b.tight) + "</li>"
+			}
+			s += "</" + tag + ">"
+		case mdRule:
+			s += "<hr>"
+		}
+	}
+	return s
+}
+
+// mdInlineHTML returns nodes as HTML, similar to CommonMark.
+func mdInlineHTML(nodes []*mdInline) string {
+	s := ""
+	for _, x := range nodes {
+		switch x.kind {
+		case mdText:
+			s += x.text
+		case mdCodeSpan:
+			s += "<code>" + x.text + "</code>"
+		case mdEmph:
+			s += "<em>" + mdInlineHTML(x.kids) + "</em>"
+		case mdStrong:
+			s += "<strong>" + mdInlineHTML(x.kids) + "</strong>"
+		case mdLink:
+			s += fmt.Sprintf(`<a href="%s"`, x.url)
+			if x.title != "" {
+				s += fmt.Sprintf(` title="%s"`, x.title)
+			}
+			s += ">" + mdInlineHTML(x.kids) + "</a>"
+		case mdImage:
+			s += fmt.Sprintf(`<img src="%s" alt="%s">`, x.url, mdPlain(x.kids))
+		case mdBreak:
+			s += "<br>"
+		}
+	}
+	return s
+}
+
+func testMarkdown(t *testing.T, tests [][2]string) {
+	t.Helper()
+	for _, test := range tests {
+		blocks, p := parseMarkdown(test[0])
+		if html := mdHTML(p, blocks, false); html != test[1] {
+			t.Errorf("%q: got %q, expected %q", test[0], html, test[1])
+		}
+	}
+}
+
+func TestMarkdownHeadings(t *testing.T) {
+	testMarkdown(t, [][2]string{
+		{"# one", "<h1>one</h1>"},
+		{"### three ###", "<h3>three</h3>"},
+		{"###### six", "<h6>six</h6>"},
+		{"####### seven", "<p>####### seven</p>"},
+		{"#no", "<p>#no</p>"},
+		{"# *em*", "<h1><em>em</em></h1>"},
+		{"one\n===", "<h1>one</h1>"},
+		{"two\n---", "<h2>two</h2>"},
+		{"a\nb\n---", "<h2>a b</h2>"},
+		{"text\n\n---", "<p>text</p><hr>"},
+		{"***", "<hr>"},
+	})
+}
+
+func TestMarkdownEmphasis(t *testing.T) {
+	testMarkdown(t, [][2]string{
+		{"*a*", "<p><em>a</em></p>"},
+		{"_a_", "<p><em>a</em></p>"},
+		{"**a**", "<p><strong>a</strong></p>"},
+		{"***a***", "<p><em><strong>a</strong></em></p>"},
+		{"**a *b* c**", "<p><strong>a <em>b</em> c</strong></p>"},
+		{"*a **b** c*", "<p><em>a <strong>b</strong> c</em></p>"},
+		{"*a **b* c**", "<p><em>a <em><em>b</em> c</em></em></p>"},
+		{"_a_b_", "<p><em>a_b</em></p>"},
+		{"foo_bar_", "<p>foo_bar_</p>"},
+		{"* a *", "<ul><li>a *</li></ul>"},
+		{"a * b *", "<p>a * b *</p>"},
+		{"**a*", "<p>*<em>a</em></p>"},
+		{`\*a\*`, "<p>*a*</p>"},
+	})
+}
+
+func TestMarkdownCode(t *testing.T) {
+	testMarkdown(t, [][2]string{
+		{"`a`", "<p><code>a</code></p>"},
+		{"`` a`b ``", "<p><code>a`b</code></p>"},
+		{"```a``b```", "<p><code>a``b</code></p>"},
+		{"``a`", "<p>``a`</p>"},
+		{"*a `*` b*", "<p><em>a <code>*</code> b</em></p>"},
+		{"`a\\`b", "<p><code>a\\</code>b</p>"},
+		{"```\ncode\n  x\n```", "<pre>code\n  x</pre>"},
+		{"~~~go\na\n~~~", "<pre>a</pre>"},
+		{"````\n```\n````", "<pre>```</pre>"},
+		{"```\nunclosed", "<pre>unclosed</pre>"},
+		{"    a\n\n    b", "<pre>a\n\nb</pre>"},
+		{"      a", "<pre>  a</pre>"},
+		{"text\n    not code", "<p>text not code</p>"},
+	})
+}
+
+func TestMarkdownQuotesLists(t *testing.T) {
+	testMarkdown(t, [][2]string{
+		{"> a\n> b", "<blockquote><p>a b</p></blockquote>"},
+		{"> a\nlazy", "<blockquote><p>a lazy</p></blockquote>"},
+		{"> a\n\nb", "<blockquote><p>a</p></blockquote><p>b</p>"},
+		{"> > a", "<blockquote><blockquote><p>a</p></blockquote></blockquote>"},
+		{"> # h\n> - i", "<blockquote><h1>h</h1><ul><li>i</li></ul></blockquote>"},
+		{"- a\n- b", "<ul><li>a</li><li>b</li></ul>"},
+		{"- a\n\n- b", "<ul><li><p>a</p></li><li><p>b</p></li></ul>"},
+		{"- a\n  - b\n- c", "<ul><li>a<ul><li>b</li></ul></li><li>c</li></ul>"},
+		{"- a\n  - b\n    - c", "<ul><li>a<ul><li>b<ul><li>c</li></ul></li></ul></li></ul>"},
+		{"1. a\n   - b\n2. c", "<ol><li>a<ul><li>b</li></ul></li><li>c</li></ol>"},
+		{"- a\n  > q", "<ul><li>a<blockquote><p>q</p></blockquote></li></ul>"},
+		{"- a\n- b\n* c", "<ul><li>a</li><li>b</li></ul><ul><li>c</li></ul>"},
+		{"- a\n\n  b", "<ul><li><p>a</p><p>b</p></li></ul>"},
+	})
+}
+
+func TestMarkdownLinks(t *testing.T) {
+	testMarkdown(t, [][2]string{
+		{"[a](/u)", `<p><a href="/u">a</a></p>`},
+		{`[a](/u "t")`, `<p><a href="/u" title="t">a</a></p>`},
+		{"[a](</my u>)", `<p><a href="/my u">a</a></p>`},
+		{"[a *b*](/u)", `<p><a href="/u">a <em>b</em></a></p>`},
+		{"[a][r]\n\n[r]: /u", `<p><a href="/u">a</a></p>`},
+		{"[r]\n\n[R]: /u 'title'", `<p><a href="/u" title="title">r</a></p>`},
+		{"[a]", "<p>[a]</p>"},
+		{"[a] (/u)", "<p>[a] (/u)</p>"},
+		{"<http://x.org/a>", `<p><a href="http://x.org/a">http://x.org/a</a></p>`},
+		{"![alt *x*](/i.png)", `<p><img src="/i.png" alt="alt x"></p>`},
+		{"[![alt](/i.png)](/u)", `<p><a href="/u"><img src="/i.png" alt="alt"></a></p>`},
+		{"[a [b](/i)](/o)", `<p>[a <a href="/i">b</a>](/o)</p>`},
+		{"*[a*](/u)", `<p>*<a href="/u">a*</a></p>`},
+	})
+}
+
+func TestMarkdownBreaks(t *testing.T) {
+	testMarkdown(t, [][2]string{
+		{"a\nb", "<p>a b</p>"}, // soft line breaks become spaces
+		{"a  \nb", "<p>a<br>b</p>"},
+		{"a\\\nb", "<p>a<br>b</p>"},
+		{"a  \n  b", "<p>a<br>b</p>"},
+		{"*a  \nb*", "<p><em>a<br>b</em></p>"},
+		{"a  ", "<p>a</p>"},
+		{"a\\", "<p>a\\</p>"},
+		{"`a  \nb`", "<p><code>a   b</code></p>"},
+	})
+}
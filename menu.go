@@ -0,0 +1,405 @@
+package duit
+
+import (
+	"fmt"
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// MenuItem is an entry in a Menu.
+type MenuItem struct {
+	Text      string           // Shown in the menu.
+	Shortcut  rune             // If not zero, a key that selects the item, such as draw.KeyCmd+'s', shown after Text. Handled by an open menu, and by a MenuBar for keys not consumed below it.
+	Checked   bool             // Whether a checkmark is shown before Text. Click can toggle it.
+	Disabled  bool             // Disabled items are drawn in lighter color, and cannot be selected.
+	Separator bool             // If set, the item is a line between groups of items, and its other fields are ignored.
+	Submenu   *Menu            // If set, opened beside the menu when the item is highlighted.
+	Value     interface{}      `json:"-"` // Auxiliary data.
+	Click     func() (e Event) `json:"-"` // Called after the item was selected and the menu closed. If the event needs a layout or draw, the entire UI is marked.
+}
+
+// Menu is a list of items shown in an overlay, opened with Open, by a MenuBar, or by button 3 on a UI with a Kid.Menu function.
+//
+// As in acme, a menu opened by a button press follows the mouse while the button is held, and releasing the button on an item selects it.
+// Releasing the button before the mouse reached an item keeps the menu open, for selecting an item with a click.
+// Items with a submenu open it when highlighted.
+//
+// Keys:
+//	arrow up, highlight the previous item
+//	arrow down, highlight the next item
+//	enter, select the highlighted item
+//	escape, close the menu
+type Menu struct {
+	Title string      // Shown in a MenuBar.
+	Items []*MenuItem // Items, from top to bottom.
+	Font  *draw.Font  `json:"-"` // For drawing the items.
+
+	size      image.Point
+	itemRs    []image.Rectangle
+	overlay   Overlay
+	open      bool
+	parent    *Menu // Menu this menu is a submenu of, while open.
+	sub       *Menu // Open submenu.
+	highlight int   // Index of highlighted item, -1 for none.
+	m         draw.Mouse
+	entered   bool   // Whether the mouse was on an item while a button was held.
+	closed    func() // Called after the menu closed, by a MenuBar.
+}
+
+var _ UI = &Menu{}
+
+func (ui *Menu) font(dui *DUI) *draw.Font {
+	return dui.Font(ui.Font)
+}
+
+// shortcutText returns how key k is shown in a menu.
+func shortcutText(k rune) string {
+	switch {
+	case k >= draw.KeyCmd && k < draw.KeyCmd+0x100:
+		return "cmd-" + string(k-draw.KeyCmd)
+	case k > draw.KeyFn && k <= draw.KeyFn+12:
+		return fmt.Sprintf("F%d", k-draw.KeyFn)
+	case k > 0 && k < ' ':
+		return "ctrl-" + string(k-1+'a')
+	}
+	return string(k)
+}
+
+// Open shows the menu with its top-left corner at p, in window coordinates, typically the mouse position.
+// If a button is held, the menu follows the mouse until the button is released.
+func (ui *Menu) Open(dui *DUI, p image.Point) {
+	ui.popup(dui, image.Rectangle{p, p}, false)
+}
+
+// popup opens the menu below anchor, or beside it for a submenu.
+func (ui *Menu) popup(dui *DUI, anchor image.Rectangle, beside bool) {
+	if ui.overlay.UI == nil {
+		ui.overlay.UI = ui
+		ui.overlay.Dismiss = func() {
+			ui.Close(dui)
+		}
+	}
+	ui.overlay.Anchor = anchor
+	ui.overlay.Beside = beside
+	ui.highlight = -1
+	ui.entered = false
+	ui.m = draw.Mouse{Buttons: dui.mouse.Buttons}
+	ui.open = true
+	dui.OpenOverlay(&ui.overlay)
+	if dui.mouse.Buttons != 0 && dui.grab == nil {
+		dui.grab = &ui.overlay
+	}
+}
+
+// Close closes the menu and its open submenu. Closing a menu that is not open has no effect.
+func (ui *Menu) Close(dui *DUI) {
+	if !ui.open {
+		return
+	}
+	if ui.sub != nil {
+		ui.sub.Close(dui)
+	}
+	ui.open = false
+	dui.CloseOverlay(&ui.overlay)
+	if ui.parent != nil {
+		if ui.parent.sub == ui {
+			ui.parent.sub = nil
+		}
+		ui.parent = nil
+	}
+	if ui.closed != nil {
+		ui.closed()
+	}
+}
+
+// root returns the menu this (sub)menu was opened from.
+func (ui *Menu) root() *Menu {
+	for ui.parent != nil {
+		ui = ui.parent
+	}
+	return ui
+}
+
+// selectable returns whether the item at index can be highlighted and selected.
+func (ui *Menu) selectable(index int) bool {
+	it := ui.Items[index]
+	return !it.Separator && !it.Disabled
+}
+
+// itemAt returns the index of the selectable item at p, or -1.
+func (ui *Menu) itemAt(p image.Point) int {
+	for i, r := range ui.itemRs {
+		if p.In(r) && ui.selectable(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (ui *Menu) rowHeight(dui *DUI) int {
+	return 4 * ui.font(dui).Height / 3
+}
+
+func (ui *Menu) separatorHeight(dui *DUI) int {
+	return ui.font(dui).Height / 2
+}
+
+// columns returns the widths of the checkmark, text and shortcut columns, and the padding around them.
+func (ui *Menu) columns(dui *DUI) (checkW, textW, rightW, pad int) {
+	font := ui.font(dui)
+	pad = font.Height / 2
+	checkW = font.StringWidth("✓ ")
+	for _, it := range ui.Items {
+		if it.Separator {
+			continue
+		}
+		textW = maximum(textW, font.StringWidth(it.Text))
+		if it.Submenu != nil {
+			rightW = maximum(rightW, font.StringWidth("▸"))
+		} else if it.Shortcut != 0 {
+			rightW = maximum(rightW, font.StringWidth(shortcutText(it.Shortcut)))
+		}
+	}
+	if rightW > 0 {
+		rightW += font.StringWidth("mm")
+	}
+	return
+}
+
+func (ui *Menu) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+
+	ui.size = ui.Measure(dui, self, sizeAvail).Pref
+	ui.itemRs = make([]image.Rectangle, len(ui.Items))
+	y := 0
+	for i, it := range ui.Items {
+		h := ui.rowHeight(dui)
+		if it.Separator {
+			h = ui.separatorHeight(dui)
+		}
+		ui.itemRs[i] = image.Rect(0, y, ui.size.X, y+h)
+		y += h
+	}
+	self.R = rect(ui.size)
+}
+
+// Measure returns the size needed for all items, as minimum and preferred size.
+func (ui *Menu) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	checkW, textW, rightW, pad := ui.columns(dui)
+	dy := 0
+	for _, it := range ui.Items {
+		if it.Separator {
+			dy += ui.separatorHeight(dui)
+		} else {
+			dy += ui.rowHeight(dui)
+		}
+	}
+	return fixedSizes(image.Pt(pad+checkW+textW+rightW+pad, dy))
+}
+
+func (ui *Menu) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+
+	font := ui.font(dui)
+	checkW, _, _, pad := ui.columns(dui)
+	img.Draw(rect(ui.size).Add(orig), dui.Background, nil, image.ZP)
+	// x returns where to draw something w wide starting at x in left-to-right layout
+	x := func(x, w int) int {
+		if dui.RTL {
+			return ui.size.X - x - w
+		}
+		return x
+	}
+	for i, it := range ui.Items {
+		if i >= len(ui.itemRs) {
+			break
+		}
+		r := ui.itemRs[i].Add(orig)
+		if it.Separator {
+			y := r.Min.Y + r.Dy()/2
+			img.Line(image.Pt(r.Min.X+pad, y), image.Pt(r.Max.X-pad, y), 0, 0, 0, dui.Gutter, image.ZP)
+			continue
+		}
+		colors := dui.Regular.Normal
+		if it.Disabled {
+			colors = dui.Disabled
+		} else if i == ui.highlight {
+			colors = dui.Inverse
+			img.Draw(r, colors.Background, nil, image.ZP)
+		}
+		y := r.Min.Y + (r.Dy()-font.Height)/2
+		if it.Checked {
+			s := "✓"
+			img.String(image.Pt(orig.X+x(pad, font.StringWidth(s)), y), colors.Text, image.ZP, font, s)
+		}
+		textX := pad + checkW
+		textW := ui.size.X - textX - pad
+		drawBidiString(dui, img, image.Pt(orig.X+x(textX, textW), y), textW, colors.Text, font, it.Text)
+		var right string
+		if it.Submenu != nil {
+			right = "▸"
+			if dui.RTL {
+				right = "◂"
+			}
+		} else if it.Shortcut != 0 {
+			right = shortcutText(it.Shortcut)
+		}
+		if right != "" {
+			w := font.StringWidth(right)
+			img.String(image.Pt(orig.X+x(ui.size.X-pad-w, w), y), colors.Text, image.ZP, font, right)
+		}
+	}
+}
+
+// setHighlight highlights the item at index, and opens its submenu, closing another open submenu.
+// Orig is the window location of the menu, for placing the submenu.
+func (ui *Menu) setHighlight(dui *DUI, self *Kid, index int, orig image.Point) {
+	if index == ui.highlight {
+		return
+	}
+	ui.highlight = index
+	self.Draw = Dirty
+	if ui.sub != nil {
+		ui.sub.Close(dui)
+	}
+	if index < 0 {
+		return
+	}
+	if sub := ui.Items[index].Submenu; sub != nil {
+		sub.Close(dui)
+		sub.parent = ui
+		ui.sub = sub
+		sub.popup(dui, ui.itemRs[index].Add(orig), true)
+	}
+}
+
+// activate closes all menus and calls Click for the item at index, unless it opens a submenu.
+func (ui *Menu) activate(dui *DUI, index int) (e Event) {
+	it := ui.Items[index]
+	if it.Submenu != nil {
+		return
+	}
+	ui.root().Close(dui)
+	if it.Click != nil {
+		e = it.Click()
+	}
+	if e.NeedLayout {
+		dui.MarkLayout(nil)
+	} else if e.NeedDraw {
+		dui.MarkDraw(nil)
+	}
+	e.NeedLayout = false
+	e.NeedDraw = false
+	e.Consumed = true
+	return
+}
+
+// findShortcut returns the menu and index of the selectable item with shortcut k, searching submenus too. Menu is nil if not found.
+func (ui *Menu) findShortcut(k rune) (menu *Menu, index int) {
+	for i, it := range ui.Items {
+		if it.Separator || it.Disabled {
+			continue
+		}
+		if it.Submenu != nil {
+			if menu, index = it.Submenu.findShortcut(k); menu != nil {
+				return
+			}
+		} else if it.Shortcut == k {
+			return ui, i
+		}
+	}
+	return nil, -1
+}
+
+func (ui *Menu) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	prevM := ui.m
+	ui.m = m
+	r.Consumed = true
+	index := ui.itemAt(m.Point)
+	if index >= 0 || ui.sub == nil {
+		// leaving the menu keeps an open submenu, for moving to it
+		ui.setHighlight(dui, self, index, orig)
+	}
+	if index >= 0 && m.Buttons != 0 {
+		ui.root().entered = true
+	}
+	if prevM.Buttons == 0 || m.Buttons != 0 {
+		return
+	}
+	if index >= 0 {
+		e := ui.activate(dui, index)
+		propagateEvent(self, &r, e)
+	} else if ui.root().entered {
+		ui.root().Close(dui)
+	}
+	return
+}
+
+func (ui *Menu) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	switch k {
+	case draw.KeyUp, draw.KeyDown:
+		n := len(ui.Items)
+		delta := 1
+		if k == draw.KeyUp {
+			delta = n - 1
+		}
+		index := ui.highlight
+		if index < 0 && k == draw.KeyUp {
+			index = 0
+		}
+		for i := 0; i < n; i++ {
+			index = (index + delta + n) % n
+			if ui.selectable(index) {
+				ui.setHighlight(dui, self, index, orig)
+				break
+			}
+		}
+		r.Consumed = true
+	case '\n':
+		if ui.highlight >= 0 {
+			e := ui.activate(dui, ui.highlight)
+			propagateEvent(self, &r, e)
+		}
+		r.Consumed = true
+	default:
+		if menu, index := ui.findShortcut(k); menu != nil {
+			ui.root().Close(dui)
+			e := menu.activate(dui, index)
+			propagateEvent(self, &r, e)
+		}
+	}
+	return
+}
+
+func (ui *Menu) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	return &image.ZP
+}
+
+func (ui *Menu) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if o != ui {
+		return nil
+	}
+	return &image.ZP
+}
+
+func (ui *Menu) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return self.Mark(o, forLayout)
+}
+
+func (ui *Menu) Print(self *Kid, indent int) {
+	PrintUI(fmt.Sprintf("Menu %q", ui.Title), self, indent)
+}
+
+// kidMenu opens the menu of k on a fresh button 3 press that k's UI did not consume.
+func kidMenu(dui *DUI, k *Kid, m draw.Mouse, r *Result) {
+	if r.Consumed || k.Menu == nil || m.Buttons != Button3 || dui.mouse != dui.origMouse {
+		return
+	}
+	menu := k.Menu(m)
+	if menu == nil {
+		return
+	}
+	menu.Open(dui, dui.mouse.Point)
+	r.Consumed = true
+}
@@ -0,0 +1,233 @@
+package duit
+
+import (
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// MenuBar shows the titles of menus in a bar at the top of a window, with the rest of the window below it in Kid.
+// Pressing a button on a title opens its menu. While a menu is open, moving the mouse over another title opens that menu instead.
+// Keys that are not consumed by Kid are checked against the shortcuts of the menu items, so shortcuts work anywhere below the bar.
+type MenuBar struct {
+	Menus []*Menu    // Menus, shown by their Title.
+	Kid   *Kid       // UI below the bar, typically the rest of the window. May be nil.
+	Font  *draw.Font `json:"-"` // For drawing the titles.
+
+	kids      []*Kid
+	size      image.Point
+	barHeight int
+	titleRs   []image.Rectangle
+	openMenu  *Menu // Menu opened from the bar, or nil.
+	barDirty  bool  // Whether the bar needs a draw.
+	m         draw.Mouse
+}
+
+var _ UI = &MenuBar{}
+
+func (ui *MenuBar) font(dui *DUI) *draw.Font {
+	return dui.Font(ui.Font)
+}
+
+func (ui *MenuBar) ensure() {
+	ui.kids = ui.kids[:0]
+	if ui.Kid != nil {
+		ui.kids = append(ui.kids, ui.Kid)
+	}
+}
+
+func (ui *MenuBar) padding(dui *DUI) image.Point {
+	fontHeight := ui.font(dui).Height
+	return image.Pt(fontHeight/2, fontHeight/4)
+}
+
+// markBar requests a draw of the bar only.
+func (ui *MenuBar) markBar(self *Kid) {
+	ui.barDirty = true
+	if self.Draw == Clean {
+		self.Draw = DirtyKid
+	}
+}
+
+func (ui *MenuBar) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	ui.ensure()
+	dui.debugLayout(self)
+
+	if KidsLayout(dui, self, ui.kids, force) {
+		return
+	}
+
+	font := ui.font(dui)
+	pad := ui.padding(dui)
+	ui.barHeight = font.Height + 2*pad.Y + 1
+	ui.titleRs = make([]image.Rectangle, len(ui.Menus))
+	x := 0
+	for i, menu := range ui.Menus {
+		dx := font.StringWidth(menu.Title) + 2*pad.X
+		r := image.Rect(x, 0, x+dx, ui.barHeight-1)
+		if dui.RTL {
+			r = image.Rect(sizeAvail.X-r.Max.X, r.Min.Y, sizeAvail.X-r.Min.X, r.Max.Y)
+		}
+		ui.titleRs[i] = r
+		x += dx
+	}
+	ui.size = image.Pt(sizeAvail.X, ui.barHeight)
+	if ui.Kid != nil {
+		ui.Kid.UI.Layout(dui, ui.Kid, image.Pt(sizeAvail.X, maximum(0, sizeAvail.Y-ui.barHeight)), true)
+		ui.Kid.R = ui.Kid.R.Add(image.Pt(0, ui.barHeight))
+		ui.size.Y += ui.Kid.R.Dy()
+	}
+	ui.barDirty = true
+	self.R = rect(ui.size)
+}
+
+// Measure returns the available width as preferred size, with the height of the bar added to the sizes of Kid.
+func (ui *MenuBar) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	ui.ensure()
+	font := ui.font(dui)
+	pad := ui.padding(dui)
+	barHeight := font.Height + 2*pad.Y + 1
+	dx := 0
+	for _, menu := range ui.Menus {
+		dx += font.StringWidth(menu.Title) + 2*pad.X
+	}
+	bar := image.Pt(dx, barHeight)
+	if ui.Kid == nil {
+		return Sizes{bar, image.Pt(sizeAvail.X, barHeight), image.Pt(sizeAvail.X, barHeight)}
+	}
+	k := KidMeasure(dui, ui.Kid, image.Pt(sizeAvail.X, maximum(0, sizeAvail.Y-barHeight)))
+	min := image.Pt(maximum(dx, k.Min.X), barHeight+k.Min.Y)
+	pref := image.Pt(maximum(sizeAvail.X, k.Pref.X), barHeight+k.Pref.Y)
+	max := image.Pt(maximum(pref.X, k.Max.X), barHeight+k.Max.Y)
+	return Sizes{min, pref, max}
+}
+
+func (ui *MenuBar) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	ui.ensure()
+	dui.debugDraw(self)
+
+	drawBar := force || self.Draw == Dirty || ui.barDirty
+	ui.barDirty = false
+	KidsDraw(dui, self, ui.kids, ui.size, nil, img, orig, m, force)
+	if !drawBar {
+		return
+	}
+
+	font := ui.font(dui)
+	pad := ui.padding(dui)
+	barR := image.Rect(0, 0, ui.size.X, ui.barHeight).Add(orig)
+	img.Draw(barR, dui.Background, nil, image.ZP)
+	for i, menu := range ui.Menus {
+		r := ui.titleRs[i]
+		colors := dui.Regular.Normal
+		if menu == ui.openMenu {
+			colors = dui.Inverse
+			img.Draw(r.Add(orig), colors.Background, nil, image.ZP)
+		} else if m.In(r) {
+			colors = dui.Regular.Hover
+			img.Draw(r.Add(orig), colors.Background, nil, image.ZP)
+		}
+		img.String(r.Min.Add(orig).Add(pad), colors.Text, image.ZP, font, menu.Title)
+	}
+	y := barR.Max.Y - 1
+	img.Line(image.Pt(barR.Min.X, y), image.Pt(barR.Max.X-1, y), 0, 0, 0, dui.Gutter, image.ZP)
+}
+
+// titleAt returns the index of the menu whose title is at p, or -1.
+func (ui *MenuBar) titleAt(p image.Point) int {
+	for i, r := range ui.titleRs {
+		if p.In(r) {
+			return i
+		}
+	}
+	return -1
+}
+
+// openIndex opens the menu at index below its title, closing the menu that was open.
+func (ui *MenuBar) openIndex(dui *DUI, self *Kid, index int, orig image.Point) {
+	if ui.openMenu != nil {
+		ui.openMenu.Close(dui)
+	}
+	menu := ui.Menus[index]
+	menu.Close(dui)
+	ui.openMenu = menu
+	menu.closed = func() {
+		if ui.openMenu == menu {
+			ui.openMenu = nil
+			ui.barDirty = true
+			dui.MarkDraw(ui)
+		}
+	}
+	menu.popup(dui, ui.titleRs[index].Add(orig), false)
+	ui.markBar(self)
+}
+
+func (ui *MenuBar) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	ui.ensure()
+	prevM := ui.m
+	ui.m = m
+	if ui.titleAt(prevM.Point) != ui.titleAt(m.Point) {
+		ui.markBar(self)
+	}
+	if origM.Y >= ui.barHeight {
+		return KidsMouse(dui, self, ui.kids, m, origM, orig)
+	}
+	r.Consumed = true
+	index := ui.titleAt(m.Point)
+	if index < 0 {
+		return
+	}
+	// the release of a press may have gone to the menu, so a new press is recognized by the DUI's mouse state
+	if m.Buttons != 0 && dui.mouse == dui.origMouse {
+		if ui.openMenu == ui.Menus[index] {
+			ui.openMenu.Close(dui)
+		} else {
+			ui.openIndex(dui, self, index, orig)
+		}
+	} else if m.Buttons == 0 && ui.openMenu != nil && ui.openMenu != ui.Menus[index] {
+		ui.openIndex(dui, self, index, orig)
+	}
+	return
+}
+
+func (ui *MenuBar) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	ui.ensure()
+	if m.Y >= ui.barHeight {
+		r = KidsKey(dui, self, ui.kids, k, m, orig)
+		if r.Consumed {
+			return
+		}
+	}
+	for _, menu := range ui.Menus {
+		if mm, index := menu.findShortcut(k); mm != nil {
+			if ui.openMenu != nil {
+				ui.openMenu.Close(dui)
+			}
+			e := mm.activate(dui, index)
+			propagateEvent(self, &r, e)
+			return
+		}
+	}
+	return
+}
+
+func (ui *MenuBar) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	ui.ensure()
+	return KidsFirstFocus(dui, self, ui.kids)
+}
+
+func (ui *MenuBar) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	ui.ensure()
+	return KidsFocus(dui, self, ui.kids, o)
+}
+
+func (ui *MenuBar) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	ui.ensure()
+	return KidsMark(self, ui.kids, o, forLayout)
+}
+
+func (ui *MenuBar) Print(self *Kid, indent int) {
+	ui.ensure()
+	PrintUI("MenuBar", self, indent)
+	KidsPrint(ui.kids, indent+1)
+}
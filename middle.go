@@ -49,6 +49,13 @@ func (ui *Middle) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool)
 	self.R = rect(ui.size)
 }
 
+func (ui *Middle) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	ui.ensure()
+	k := KidMeasure(dui, ui.Kid, sizeAvail)
+	size := image.Pt(maximum(k.Pref.X, sizeAvail.X), maximum(k.Pref.Y, sizeAvail.Y))
+	return Sizes{k.Min, size, size}
+}
+
 func (ui *Middle) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	ui.ensure()
 	dui.debugDraw(self)
@@ -0,0 +1,256 @@
+package duit
+
+import (
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// Overlay is a UI drawn above the Top UI of a DUI, such as a list of suggestions or a menu.
+// Overlays are shown with DUI.OpenOverlay and stay open until DUI.CloseOverlay.
+// Mouse events that start in an overlay are delivered to its UI, as are keys pressed while the mouse is over it.
+//
+// The overlay is as wide as the minimum width of its UI, and at least as wide as Anchor.
+// It is as high as the preferred height of its UI, limited to the room in the window.
+// A border is drawn around the UI.
+//
+// A modal overlay, such as a dialog, gets the preferred size of its UI and is centered in the window.
+// While it is open, the Top UI and the overlays below it get no mouse events or keys. Keys go to the modal overlay, also when the mouse is outside it.
+type Overlay struct {
+	Kid                     // Holds the UI. Its R is set by the UI's Layout, relative to the overlay.
+	Anchor  image.Rectangle // Window coordinates of the UI the overlay belongs to, typically the UI that opened it. The overlay is placed below the anchor, or above if there is more room there.
+	Beside  bool            // Place the overlay to the right of Anchor, or left with DUI.RTL, like a submenu.
+	Modal   bool            // Center the overlay, and keep input from the UIs below it. Button presses outside a modal overlay do not dismiss it.
+	Dismiss func()          `json:"-"` // Called on escape, or a button press outside the overlay, its anchor and overlays opened after it. If nil, the overlay is closed.
+
+	r image.Rectangle // In window coordinates, including border.
+}
+
+// OpenOverlay shows o above the Top UI and the overlays opened earlier.
+// Call OpenOverlay again after changing Anchor, to place the overlay anew.
+func (d *DUI) OpenOverlay(o *Overlay) {
+	o.Layout = Dirty
+	o.Draw = Dirty
+	if !d.overlayOpen(o) {
+		d.overlays = append(d.overlays, o)
+	}
+}
+
+// CloseOverlay removes o, and redraws the UIs below it. Closing an overlay that is not open has no effect.
+func (d *DUI) CloseOverlay(o *Overlay) {
+	for i, oo := range d.overlays {
+		if oo != o {
+			continue
+		}
+		d.overlays = append(d.overlays[:i], d.overlays[i+1:]...)
+		if d.lastMouseUI != nil && o.UI.Mark(&o.Kid, d.lastMouseUI, false) {
+			d.lastMouseUI = nil
+		}
+		if d.cursorUI != nil && o.UI.Mark(&o.Kid, d.cursorUI, false) {
+			d.setCursor(d.cursorUI, nil)
+		}
+		if d.grab == o {
+			d.grab = nil
+		}
+		o.r = image.Rectangle{}
+		d.Top.Draw = Dirty
+		return
+	}
+}
+
+// dismiss calls Dismiss for the overlay, or closes it.
+func (d *DUI) dismiss(o *Overlay) {
+	if o.Dismiss != nil {
+		o.Dismiss()
+	} else {
+		d.CloseOverlay(o)
+	}
+}
+
+// dismissOverlays dismisses the overlays that a button press at p is outside of.
+// Overlays below the overlay or anchor that p is in stay open, as do modal overlays and the overlays below them.
+// Dismiss functions may close other overlays, so overlays that are no longer open are skipped.
+func (d *DUI) dismissOverlays(p image.Point) {
+	overlays := append([]*Overlay{}, d.overlays...)
+	for i := len(overlays) - 1; i >= 0; i-- {
+		o := overlays[i]
+		if !d.overlayOpen(o) {
+			continue
+		}
+		if p.In(o.r) || p.In(o.Anchor) || o.Modal {
+			return
+		}
+		d.dismiss(o)
+	}
+}
+
+func (d *DUI) overlayOpen(o *Overlay) bool {
+	for _, oo := range d.overlays {
+		if oo == o {
+			return true
+		}
+	}
+	return false
+}
+
+// modal returns the topmost modal overlay, or nil.
+func (d *DUI) modal() *Overlay {
+	for i := len(d.overlays) - 1; i >= 0; i-- {
+		if o := d.overlays[i]; o.Modal {
+			return o
+		}
+	}
+	return nil
+}
+
+// overlayAt returns the topmost overlay containing p, or nil.
+// Overlays below a modal overlay are skipped.
+func (d *DUI) overlayAt(p image.Point) *Overlay {
+	for i := len(d.overlays) - 1; i >= 0; i-- {
+		o := d.overlays[i]
+		if p.In(o.r) {
+			return o
+		}
+		if o.Modal {
+			break
+		}
+	}
+	return nil
+}
+
+func (o *Overlay) orig() image.Point {
+	return o.r.Min.Add(pt(1))
+}
+
+func (d *DUI) layoutOverlay(o *Overlay, force bool) {
+	if !force && o.Layout == Clean {
+		return
+	}
+	screen := d.Display.ScreenImage.R
+	avail := screen.Size().Sub(pt(2))
+	sizes := KidMeasure(d, &o.Kid, avail)
+	size := image.Pt(minimum(sizes.Min.X, avail.X), sizes.Pref.Y)
+
+	var p image.Point
+	if o.Modal {
+		size = image.Pt(minimum(maximum(sizes.Min.X, sizes.Pref.X), avail.X), minimum(sizes.Pref.Y, avail.Y))
+		p = screen.Min.Add(avail.Sub(size).Div(2))
+	} else if o.Beside {
+		size.Y = minimum(size.Y, avail.Y)
+		right := image.Pt(o.Anchor.Max.X, o.Anchor.Min.Y)
+		left := image.Pt(o.Anchor.Min.X-size.X-2, o.Anchor.Min.Y)
+		if d.RTL {
+			p = left
+			if p.X < screen.Min.X {
+				p = right
+			}
+		} else {
+			p = right
+			if p.X+size.X+2 > screen.Max.X {
+				p = left
+			}
+		}
+		p.Y = maximum(screen.Min.Y, minimum(p.Y, screen.Max.Y-size.Y-2))
+	} else {
+		size.X = minimum(maximum(size.X, o.Anchor.Dx()-2), avail.X)
+		below := screen.Max.Y - o.Anchor.Max.Y - 2
+		above := o.Anchor.Min.Y - screen.Min.Y - 2
+		if size.Y <= below || below >= above {
+			size.Y = minimum(size.Y, below)
+			p.Y = o.Anchor.Max.Y
+		} else {
+			size.Y = minimum(size.Y, above)
+			p.Y = o.Anchor.Min.Y - size.Y - 2
+		}
+		p.X = o.Anchor.Min.X
+		if d.RTL {
+			p.X = o.Anchor.Max.X - size.X - 2
+		}
+	}
+	p.X = maximum(screen.Min.X, minimum(p.X, screen.Max.X-size.X-2))
+
+	o.UI.Layout(d, &o.Kid, size, force || o.Layout == Dirty)
+	o.Layout = Clean
+	r := rect(o.R.Size().Add(pt(2))).Add(p)
+	if r != o.r {
+		if !o.r.Empty() {
+			// uncover the old location
+			d.Top.Draw = Dirty
+		}
+		o.r = r
+		o.Draw = Dirty
+	}
+}
+
+func (d *DUI) drawOverlay(o *Overlay, force bool) {
+	if !force && o.Draw == Clean {
+		return
+	}
+	img := d.Display.ScreenImage
+	if force || o.Draw == Dirty {
+		img.Draw(o.r, d.Background, nil, image.ZP)
+		img.Border(o.r, 1, d.Gutter, image.ZP)
+		force = true
+		o.Draw = Dirty
+	}
+	m := d.mouse
+	m.Point = m.Point.Sub(o.orig())
+	o.UI.Draw(d, &o.Kid, img, o.orig(), m, force)
+	o.Draw = Clean
+}
+
+// mouseOverlays delivers a mouse event to the overlay origM is in, or to the Top UI.
+// While an overlay has the grab, the event goes to the overlay m is in, or the grabbing overlay.
+func (d *DUI) mouseOverlays(m, origM draw.Mouse) (r Result) {
+	o := d.overlayAt(origM.Point)
+	if d.grab != nil {
+		o = d.overlayAt(m.Point)
+		if o == nil {
+			o = d.grab
+		}
+	}
+	if o == nil {
+		if d.modal() != nil {
+			return
+		}
+		r = d.Top.UI.Mouse(d, &d.Top, m, origM, image.ZP)
+		kidMenu(d, &d.Top, m, &r)
+		return
+	}
+	orig := o.orig()
+	m.Point = m.Point.Sub(orig)
+	origM.Point = origM.Point.Sub(orig)
+	r = o.UI.Mouse(d, &o.Kid, m, origM, orig)
+	if r.Hit == nil {
+		r.Hit = o.UI
+	}
+	return
+}
+
+// keyOverlays delivers a key to the overlay the mouse is in, the modal overlay, or to the Top UI.
+func (d *DUI) keyOverlays(k rune) (r Result) {
+	o := d.overlayAt(d.mouse.Point)
+	if o == nil {
+		o = d.modal()
+	}
+	if o == nil {
+		return d.Top.UI.Key(d, &d.Top, k, d.mouse, image.ZP)
+	}
+	orig := o.orig()
+	m := d.mouse
+	m.Point = m.Point.Sub(orig)
+	return o.UI.Key(d, &o.Kid, k, m, orig)
+}
+
+// mark marks ui for layout or draw, in the Top UI or the overlays.
+func (d *DUI) mark(ui UI, forLayout bool) bool {
+	if d.Top.UI.Mark(&d.Top, ui, forLayout) {
+		return true
+	}
+	for _, o := range d.overlays {
+		if o.UI.Mark(&o.Kid, ui, forLayout) {
+			return true
+		}
+	}
+	return false
+}
@@ -25,6 +25,11 @@ func (ui *Pick) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 	ui.ui.Layout(dui, self, sizeAvail, force || oui != ui.ui)
 }
 
+// Measure measures the UI that Pick returns for sizeAvail.
+func (ui *Pick) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	return ui.Pick(sizeAvail).Measure(dui, self, sizeAvail)
+}
+
 func (ui *Pick) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	dui.debugDraw(self)
 	ui.ui.Draw(dui, self, img, orig, m, force)
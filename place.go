@@ -8,7 +8,7 @@ import (
 
 // Place contains other UIs it can position absolute, possibly on top of each other.
 type Place struct {
-	// Place is called during layout. It must configure Kids, and set self.R, based on sizeAvail.
+	// Place is called during layout. It must configure Kids, and set self.R, based on sizeAvail. Hidden kids need not be configured.
 	Place      func(self *Kid, sizeAvail image.Point) `json:"-"`
 	Kids       []*Kid                                 // Kids to draw, set by the Place function.
 	Background *draw.Image                            `json:"-"` // For background color.
@@ -21,7 +21,17 @@ var _ UI = &Place{}
 
 func (ui *Place) ensure() {
 	if len(ui.kidsReversed) == len(ui.Kids) {
-		return
+		n := len(ui.Kids)
+		same := true
+		for i, k := range ui.Kids {
+			if ui.kidsReversed[n-1-i] != k {
+				same = false
+				break
+			}
+		}
+		if same {
+			return
+		}
 	}
 	ui.kidsReversed = make([]*Kid, len(ui.Kids))
 	for i, k := range ui.Kids {
@@ -34,6 +44,42 @@ func (ui *Place) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool)
 	dui.debugLayout(self)
 
 	ui.Place(self, sizeAvail)
+	kidsHide(ui.Kids)
+}
+
+// Measure returns the available space as preferred and maximum size, the actual size is determined by the Place function.
+func (ui *Place) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	return Sizes{image.ZP, sizeAvail, sizeAvail}
+}
+
+// Insert adds uis to Kids at index, and marks the place for layout. Index -1 means at the end.
+// The Place function must position the new kids.
+func (ui *Place) Insert(dui *DUI, index int, uis ...UI) {
+	ui.Kids = kidsInsert(ui.Kids, index, uis...)
+	ui.ensure()
+	dui.MarkLayout(ui)
+}
+
+// Remove removes the kid at index, and marks the place for layout.
+func (ui *Place) Remove(dui *DUI, index int) {
+	ui.Kids = kidsRemove(ui.Kids, index, 1)
+	ui.ensure()
+	dui.MarkLayout(ui)
+}
+
+// Move moves the kid at index from to index to, and marks the place for layout.
+// Later kids are drawn on top of earlier kids.
+func (ui *Place) Move(dui *DUI, from, to int) {
+	kidsMove(ui.Kids, from, to, 1)
+	ui.ensure()
+	dui.MarkLayout(ui)
+}
+
+// Replace replaces the kid at index with a new kid for newUI, and marks the place for layout.
+func (ui *Place) Replace(dui *DUI, index int, newUI UI) {
+	ui.Kids[index] = &Kid{UI: newUI}
+	ui.ensure()
+	dui.MarkLayout(ui)
 }
 
 func (ui *Place) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
@@ -0,0 +1,127 @@
+package duit
+
+import (
+	"image"
+	"math"
+
+	"9fans.net/go/draw"
+)
+
+// Progress is a horizontal bar showing how much of a task is done, with an optional label drawn on the bar.
+// Progress is typically updated with Set from a function sent on DUI.Call by a goroutine doing the work.
+// Set only marks the bar for drawing, and only when its looks change, so frequent updates are cheap.
+// With DUI.RTL, the bar fills from the right.
+type Progress struct {
+	Fraction float64    // Done, from 0 to 1. Call MarkDraw after changing, or use Set.
+	Label    string     // Drawn centered on the bar, e.g. "42%". Call MarkDraw after changing, or use Set.
+	Font     *draw.Font `json:"-"` // For drawing Label.
+
+	size       image.Point
+	drawnWidth int    // Width of the filled part when last drawn.
+	drawnLabel string // Label when last drawn.
+}
+
+var _ UI = &Progress{}
+
+func (ui *Progress) font(dui *DUI) *draw.Font {
+	return dui.Font(ui.Font)
+}
+
+func (ui *Progress) padding(dui *DUI) int {
+	return ui.font(dui).Height / 4
+}
+
+// fillWidth returns the width of the filled part of the bar.
+func (ui *Progress) fillWidth() int {
+	f := math.Max(0, math.Min(1, ui.Fraction))
+	return int(f * float64(ui.size.X))
+}
+
+// Set changes the fraction done and the label, and marks the bar for drawing if it looks different.
+// Set must be called from the main loop, e.g. in a function sent on DUI.Call.
+func (ui *Progress) Set(dui *DUI, fraction float64, label string) {
+	ui.Fraction = fraction
+	ui.Label = label
+	if ui.fillWidth() != ui.drawnWidth || ui.Label != ui.drawnLabel {
+		dui.MarkDraw(ui)
+	}
+}
+
+func (ui *Progress) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+	ui.size = ui.Measure(dui, self, sizeAvail).Pref
+	self.R = rect(ui.size)
+}
+
+// Measure returns the available width as preferred size, and a few characters as minimum, as high as a line of text.
+func (ui *Progress) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	font := ui.font(dui)
+	dy := font.Height + 2*ui.padding(dui)
+	return Sizes{image.Pt(font.StringWidth("100%")+2*ui.padding(dui), dy), image.Pt(sizeAvail.X, dy), image.Pt(sizeAvail.X, dy)}
+}
+
+func (ui *Progress) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+
+	ui.drawnWidth = ui.fillWidth()
+	ui.drawnLabel = ui.Label
+	if ui.size.X <= 0 || ui.size.Y <= 0 {
+		return
+	}
+	r := rect(ui.size).Add(orig)
+	fillR := r
+	if dui.RTL {
+		fillR.Min.X = fillR.Max.X - ui.drawnWidth
+	} else {
+		fillR.Max.X = fillR.Min.X + ui.drawnWidth
+	}
+	img.Draw(r, dui.Regular.Normal.Background, nil, image.ZP)
+	img.Draw(fillR, dui.Primary.Normal.Background, nil, image.ZP)
+	drawRoundedBorder(img, r, dui.Regular.Normal.Border)
+
+	if ui.Label == "" {
+		return
+	}
+	// the label is drawn in two colors, for readability on and beside the filled part
+	font := ui.font(dui)
+	p := r.Min.Add(image.Pt((r.Dx()-font.StringWidth(ui.Label))/2, ui.padding(dui)))
+	clipr := img.Clipr
+	img.ReplClipr(img.Repl, clipr.Intersect(fillR))
+	img.String(p, dui.Primary.Normal.Text, image.ZP, font, ui.Label)
+	restR := r
+	if dui.RTL {
+		restR.Max.X = fillR.Min.X
+	} else {
+		restR.Min.X = fillR.Max.X
+	}
+	img.ReplClipr(img.Repl, clipr.Intersect(restR))
+	img.String(p, dui.Regular.Normal.Text, image.ZP, font, ui.Label)
+	img.ReplClipr(img.Repl, clipr)
+}
+
+func (ui *Progress) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	return
+}
+
+func (ui *Progress) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	return
+}
+
+func (ui *Progress) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	return nil
+}
+
+func (ui *Progress) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if o != ui {
+		return nil
+	}
+	return &image.ZP
+}
+
+func (ui *Progress) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return self.Mark(o, forLayout)
+}
+
+func (ui *Progress) Print(self *Kid, indent int) {
+	PrintUI("Progress", self, indent)
+}
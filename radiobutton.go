@@ -59,6 +59,11 @@ func (ui *Radiobutton) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force
 	self.R = rect(size)
 }
 
+func (ui *Radiobutton) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	hit := image.Point{0, 1}
+	return fixedSizes(pt(2*BorderSize + 7*dui.Display.DefaultFont.Height/10).Add(hit))
+}
+
 func (ui *Radiobutton) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	dui.debugDraw(self)
 
@@ -0,0 +1,90 @@
+package duit
+
+import (
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// RangeSlider is like a Slider, but with two handles, for picking a range of values from Low to High.
+// The handles cannot pass each other. Clicking on the track moves the nearest handle to that place, and keys move the handle nearest to the mouse.
+type RangeSlider struct {
+	Low, High float64                                      // Current range, with Low <= High. Call MarkDraw after changing.
+	Min, Max  float64                                      // Range of values. If Max <= Min, the range is 0 to 1.
+	Step      float64                                      // If > 0, values are multiples of Step from Min. Also the amount keys change a value by, 1/100th of the range if zero.
+	Ticks     int                                          // If > 0, the number of intervals between the tick marks drawn along the track.
+	Vertical  bool                                         // Whether the slider is vertical, with Min at the bottom.
+	Disabled  bool                                         // If disabled, mouse and key input have no effect.
+	Changed   func(low, high float64, done bool) (e Event) `json:"-"` // Called while dragging with done false, and with done true when dragging ends, or after a key changed a value.
+
+	s slider
+}
+
+var _ UI = &RangeSlider{}
+
+func (ui *RangeSlider) core() *slider {
+	s := &ui.s
+	s.min, s.max, s.step = ui.Min, ui.Max, ui.Step
+	s.ticks = ui.Ticks
+	s.vertical = ui.Vertical
+	s.disabled = ui.Disabled
+	s.values = append(s.values[:0], ui.Low, ui.High)
+	return s
+}
+
+func (ui *RangeSlider) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+	ui.core().layout(dui, self, sizeAvail)
+}
+
+func (ui *RangeSlider) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	return ui.core().measure(dui, sizeAvail)
+}
+
+func (ui *RangeSlider) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+	ui.core().draw(dui, img, orig, m)
+}
+
+func (ui *RangeSlider) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	s := ui.core()
+	changed, done, consumed := s.mouse(dui, self, m)
+	ui.Low, ui.High = s.values[0], s.values[1]
+	r.Consumed = consumed
+	if (changed || done) && ui.Changed != nil {
+		e := ui.Changed(ui.Low, ui.High, done)
+		propagateEvent(self, &r, e)
+	}
+	return
+}
+
+func (ui *RangeSlider) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	s := ui.core()
+	changed, consumed := s.key(dui, self, k, m)
+	ui.Low, ui.High = s.values[0], s.values[1]
+	r.Consumed = consumed
+	if changed && ui.Changed != nil {
+		e := ui.Changed(ui.Low, ui.High, true)
+		propagateEvent(self, &r, e)
+	}
+	return
+}
+
+func (ui *RangeSlider) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	return ui.core().focus(dui)
+}
+
+func (ui *RangeSlider) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if o != ui {
+		return nil
+	}
+	return ui.FirstFocus(dui, self)
+}
+
+func (ui *RangeSlider) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return self.Mark(o, forLayout)
+}
+
+func (ui *RangeSlider) Print(self *Kid, indent int) {
+	PrintUI("RangeSlider", self, indent)
+}
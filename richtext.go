@@ -0,0 +1,385 @@
+package duit
+
+import (
+	"image"
+	"strings"
+	"unicode/utf8"
+
+	"9fans.net/go/draw"
+)
+
+// Span is a run of text in a RichText, drawn in its own style.
+type Span struct {
+	Text       string           // Text to draw, may contain newlines.
+	Font       *draw.Font       `json:"-"` // If nil, the default font is used.
+	Color      *draw.Image      `json:"-"` // Color of the text. If nil, the regular text color is used, or for links the colors of DUI.Link.
+	Background *draw.Image      `json:"-"` // If set, drawn behind the text.
+	Underline  bool             // Draw a line under the text. Links are always underlined.
+	Link       func() (e Event) `json:"-"` // If set, the span is a link, called when clicked.
+	Visited    bool             // Whether the link has been followed, drawn in the visited color. Set when the link is clicked.
+	Image      *draw.Image      `json:"-"` // If set, drawn instead of Text, with its bottom on the baseline. Text is still used when copying.
+}
+
+// RichText draws text made up of spans, each with their own font, colors and underline.
+// Text is wrapped at word boundaries, also across spans. Lines are as high as their largest font, with text of all spans on the same baseline.
+// Spans with a Link function are drawn as links, highlighted when hovered with a pointing hand as mouse cursor, and called when clicked.
+// Text can be selected with the mouse like in a Label.
+//
+// Keys:
+//	cmd-a, select all text
+//	cmd-n, clear selection
+//	cmd-c, copy selection, or all text without selection
+//	\n, calls the Link function of the hovered link
+type RichText struct {
+	Spans  []*Span // Spans to draw. Call MarkLayout after changing.
+	NoWrap bool    // If set, lines are only broken at newlines.
+
+	text   string // Text of all spans, set by layout.
+	lines  []richTextLine
+	size   image.Point
+	m      draw.Mouse
+	sel    textSelect
+	hover  *Span // Link under the mouse.
+	press  *Span // Link where button1 was pressed.
+}
+
+type richTextLine struct {
+	frags         []richTextFrag
+	start, end    int // Offsets in text, end excluding trailing spaces.
+	y, height     int
+	ascent, width int
+}
+
+// richTextFrag is the part of a span on a line.
+type richTextFrag struct {
+	span       int
+	start, end int // Offsets in text.
+	x, width   int
+}
+
+var _ UI = &RichText{}
+
+// spansText returns the text of all spans.
+func (ui *RichText) spansText() string {
+	l := []string{}
+	for _, span := range ui.Spans {
+		l = append(l, span.Text)
+	}
+	return strings.Join(l, "")
+}
+
+// glyphs returns all characters of the spans with their widths and line break opportunities.
+func (ui *RichText) glyphs(dui *DUI) []textGlyph {
+	var glyphs []textGlyph
+	var lb lineBreaker
+	end := 0
+	for i, span := range ui.Spans {
+		// offset of span in the text of all spans
+		start := end
+		end += len(span.Text)
+		if span.Image != nil {
+			// an image is a single glyph
+			g := textGlyph{
+				span:   i,
+				offset: start,
+				size:   len(span.Text),
+				width:  span.Image.R.Dx(),
+				brk:    lb.next(0xfffc),
+			}
+			glyphs = append(glyphs, g)
+			continue
+		}
+		font := dui.Font(span.Font)
+		for o, c := range span.Text {
+			g := textGlyph{
+				span:    i,
+				offset:  start + o,
+				size:    utf8.RuneLen(c),
+				width:   font.StringWidth(string(c)),
+				space:   c == ' ',
+				newline: c == '\n',
+				brk:     lb.next(c),
+			}
+			glyphs = append(glyphs, g)
+		}
+	}
+	return glyphs
+}
+
+// wrap breaks the text of glyphs into lines that fit in width, and returns the lines and their size.
+func (ui *RichText) wrap(dui *DUI, glyphs []textGlyph, width int) ([]richTextLine, image.Point) {
+	lines := []richTextLine{}
+	size := image.ZP
+	wrapGlyphs(glyphs, width, ui.NoWrap, func(s, e, dx int) {
+		line := ui.line(dui, glyphs, s, e)
+		line.y = size.Y
+		size.Y += line.height
+		size.X = maximum(size.X, line.width)
+		lines = append(lines, line)
+	})
+	return lines, size
+}
+
+// line makes a line of glyphs s to e, with fragments for the spans.
+func (ui *RichText) line(dui *DUI, glyphs []textGlyph, s, e int) richTextLine {
+	var line richTextLine
+	if s < len(glyphs) {
+		line.start = glyphs[s].offset
+	} else if len(glyphs) > 0 {
+		g := glyphs[len(glyphs)-1]
+		line.start = g.offset + g.size
+	}
+	line.end = line.start
+	descent := 0
+	grow := func(font *draw.Font) {
+		line.ascent = maximum(line.ascent, font.Ascent)
+		descent = maximum(descent, font.Height-font.Ascent)
+	}
+	if s < len(glyphs) {
+		// for empty lines, the font of the newline
+		grow(dui.Font(ui.Spans[glyphs[s].span].Font))
+	} else {
+		grow(dui.Font(nil))
+	}
+	for i := s; i < e; i++ {
+		g := glyphs[i]
+		n := len(line.frags)
+		if n == 0 || line.frags[n-1].span != g.span {
+			line.frags = append(line.frags, richTextFrag{span: g.span, start: g.offset, end: g.offset, x: line.width})
+			if span := ui.Spans[g.span]; span.Image != nil {
+				line.ascent = maximum(line.ascent, span.Image.R.Dy())
+			} else {
+				grow(dui.Font(span.Font))
+			}
+			n++
+		}
+		f := &line.frags[n-1]
+		f.end += g.size
+		f.width += g.width
+		line.width += g.width
+		line.end = f.end
+	}
+	line.height = line.ascent + descent
+	return line
+}
+
+func (ui *RichText) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+
+	ui.text = ui.spansText()
+	ui.lines, ui.size = ui.wrap(dui, ui.glyphs(dui), sizeAvail.X)
+	self.R = rect(ui.size)
+}
+
+// Measure returns as minimum the size with the widest word determining the width, and as maximum the size without wrapping.
+func (ui *RichText) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	glyphs := ui.glyphs(dui)
+	_, max := ui.wrap(dui, glyphs, maxInt)
+	if ui.NoWrap {
+		return Sizes{max, max, max}
+	}
+	_, pref := ui.wrap(dui, glyphs, sizeAvail.X)
+	_, min := ui.wrap(dui, glyphs, maxWordWidth(glyphs))
+	return Sizes{min, pref, max}
+}
+
+func (ui *RichText) color(dui *DUI, span *Span) *draw.Image {
+	switch {
+	case span.Link != nil && span == ui.hover:
+		return dui.Link.Hover
+	case span.Color != nil:
+		return span.Color
+	case span.Link != nil && span.Visited:
+		return dui.Link.Visited
+	case span.Link != nil:
+		return dui.Link.Normal
+	}
+	return dui.Regular.Normal.Text
+}
+
+func (ui *RichText) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+
+	// the mouse may have left without a call to Mouse
+	ui.hovered(dui, ui.linkAt(dui, m.Point))
+	selStart, selEnd := ui.sel.ordered(ui.text)
+	for _, line := range ui.lines {
+		for _, f := range line.frags {
+			span := ui.Spans[f.span]
+			font := dui.Font(span.Font)
+			r := image.Rect(f.x, line.y, f.x+f.width, line.y+line.height).Add(orig)
+			if span.Background != nil {
+				img.Draw(r, span.Background, nil, image.ZP)
+			}
+			color := ui.color(dui, span)
+
+			if span.Image != nil {
+				ir := rect(span.Image.R.Size()).Add(image.Pt(r.Min.X, r.Min.Y+line.ascent-span.Image.R.Dy()))
+				img.Draw(ir, span.Image, nil, span.Image.R.Min)
+				if selStart < f.end && selEnd > f.start {
+					img.Border(ir, 1, dui.Selection.Background, image.ZP)
+				}
+				continue
+			}
+
+			// draw text before, in and after the selection
+			p := image.Pt(r.Min.X, r.Min.Y+line.ascent-font.Ascent)
+			a := maximum(f.start, minimum(selStart, f.end))
+			b := maximum(a, minimum(selEnd, f.end))
+			p = img.String(p, color, image.ZP, font, ui.text[f.start:a])
+			if a < b {
+				t := ui.text[a:b]
+				selR := image.Rect(p.X, r.Min.Y, p.X+font.StringWidth(t), r.Max.Y)
+				img.Draw(selR, dui.Selection.Background, nil, image.ZP)
+				p = img.String(p, dui.Selection.Text, image.ZP, font, t)
+			}
+			img.String(p, color, image.ZP, font, ui.text[b:f.end])
+
+			if span.Underline || span.Link != nil {
+				y := r.Min.Y + line.ascent + 1
+				img.Draw(image.Rect(r.Min.X, y, r.Max.X, y+1), color, nil, image.ZP)
+			}
+		}
+	}
+}
+
+// lineAt returns the line at y, or nil.
+func (ui *RichText) lineAt(y int) *richTextLine {
+	for i := range ui.lines {
+		line := &ui.lines[i]
+		if y >= line.y && y < line.y+line.height {
+			return line
+		}
+	}
+	return nil
+}
+
+// linkAt returns the span with a link at p, or nil.
+func (ui *RichText) linkAt(dui *DUI, p image.Point) *Span {
+	line := ui.lineAt(p.Y)
+	if line == nil {
+		return nil
+	}
+	for _, f := range line.frags {
+		if p.X >= f.x && p.X < f.x+f.width {
+			if span := ui.Spans[f.span]; span.Link != nil {
+				return span
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// offsetAt returns the offset in the text for the cursor closest to p.
+func (ui *RichText) offsetAt(dui *DUI, p image.Point) int {
+	if p.Y < 0 || len(ui.lines) == 0 {
+		return 0
+	}
+	line := ui.lineAt(p.Y)
+	if line == nil {
+		return len(ui.text)
+	}
+	for _, f := range line.frags {
+		if p.X >= f.x+f.width {
+			continue
+		}
+		span := ui.Spans[f.span]
+		if span.Image != nil {
+			if p.X < f.x+f.width/2 {
+				return f.start
+			}
+			return f.end
+		}
+		font := dui.Font(span.Font)
+		x := f.x
+		for o, c := range ui.text[f.start:f.end] {
+			dx := font.StringWidth(string(c))
+			if p.X < x+dx/2 {
+				return f.start + o
+			}
+			x += dx
+		}
+		return f.end
+	}
+	return line.end
+}
+
+// hovered updates the link under the mouse and the mouse cursor, and returns whether the link changed.
+func (ui *RichText) hovered(dui *DUI, link *Span) bool {
+	if link == ui.hover {
+		return false
+	}
+	ui.hover = link
+	if link != nil {
+		dui.setCursor(ui, linkCursor)
+	} else {
+		dui.setCursor(ui, nil)
+	}
+	return true
+}
+
+// follow marks link visited and calls its Link function.
+func (ui *RichText) follow(self *Kid, r *Result, link *Span) {
+	link.Visited = true
+	self.Draw = Dirty
+	e := link.Link()
+	propagateEvent(self, r, e)
+}
+
+func (ui *RichText) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	hover := ui.linkAt(dui, m.Point)
+	if ui.hovered(dui, hover) {
+		self.Draw = Dirty
+	}
+	if ui.m.Buttons == 0 && m.Buttons == Button1 {
+		ui.press = hover
+	} else if ui.m.Buttons == Button1 && m.Buttons == 0 {
+		s, e := ui.sel.ordered(ui.text)
+		if ui.press != nil && ui.press == hover && s == e {
+			ui.follow(self, &r, hover)
+		}
+		ui.press = nil
+	}
+	if !r.Consumed && ui.sel.mouse(ui.text, ui.m, m, ui.offsetAt(dui, m.Point)) {
+		self.Draw = Dirty
+		r.Consumed = true
+	}
+	ui.m = m
+	return
+}
+
+func (ui *RichText) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	if k == '\n' {
+		if link := ui.linkAt(dui, m.Point); link != nil {
+			ui.follow(self, &r, link)
+			r.Consumed = true
+		}
+		return
+	}
+	var changed bool
+	r.Consumed, changed = ui.sel.key(dui, ui.text, k)
+	if changed {
+		self.Draw = Dirty
+	}
+	return
+}
+
+func (ui *RichText) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	return nil
+}
+
+func (ui *RichText) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if ui != o {
+		return nil
+	}
+	return &image.ZP
+}
+
+func (ui *RichText) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return self.Mark(o, forLayout)
+}
+
+func (ui *RichText) Print(self *Kid, indent int) {
+	PrintUI("RichText", self, indent)
+}
@@ -0,0 +1,55 @@
+package duit
+
+import (
+	"image"
+	"testing"
+
+	"9fans.net/go/draw"
+)
+
+func TestRichTextMeasure(t *testing.T) {
+	// images need no display for their widths
+	img := &draw.Image{R: image.Rect(0, 0, 10, 10)}
+	font := &draw.Font{Height: 12, Ascent: 10}
+	ui := &RichText{Spans: []*Span{{Text: "a", Image: img, Font: font}, {Text: "b", Image: img, Font: font}}}
+	dui := &DUI{}
+	self := &Kid{UI: ui}
+
+	sizes := ui.Measure(dui, self, image.Pt(100, 100))
+	if sizes.Pref != image.Pt(20, 12) {
+		t.Errorf("got preferred size %v, expected (20,12)", sizes.Pref)
+	}
+	if ui.text != "" || ui.lines != nil || ui.size != image.ZP {
+		t.Fatalf("measure changed layout state")
+	}
+
+	ui.Layout(dui, self, image.Pt(100, 100), false)
+	if ui.text != "ab" || len(ui.lines) != 1 || ui.lines[0].end != 2 {
+		t.Fatalf("got text %q and %d lines after layout, expected \"ab\" on a single line", ui.text, len(ui.lines))
+	}
+}
+
+func TestRichTextKeyLink(t *testing.T) {
+	img := &draw.Image{R: image.Rect(0, 0, 10, 10)}
+	font := &draw.Font{Height: 12, Ascent: 10}
+	followed := false
+	link := &Span{Text: "link", Image: img, Font: font, Link: func() (e Event) {
+		followed = true
+		return
+	}}
+	ui := &RichText{Spans: []*Span{{Text: "a", Image: img, Font: font}, link}}
+	dui := &DUI{}
+	self := &Kid{UI: ui}
+	ui.Layout(dui, self, image.Pt(100, 100), false)
+
+	r := ui.Key(dui, self, '\n', draw.Mouse{Point: image.Pt(15, 5)}, image.ZP)
+	if !followed || !r.Consumed || !link.Visited {
+		t.Fatalf("newline over link: followed %v, consumed %v, visited %v, expected all", followed, r.Consumed, link.Visited)
+	}
+
+	followed = false
+	r = ui.Key(dui, self, '\n', draw.Mouse{Point: image.Pt(5, 5)}, image.ZP)
+	if followed || r.Consumed {
+		t.Fatalf("newline outside link: followed %v, consumed %v, expected neither", followed, r.Consumed)
+	}
+}
@@ -64,6 +64,23 @@ func (ui *Scroll) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool)
 	self.R = rect(ui.r.Size())
 }
 
+// Measure returns the full available width, and the height of the kid limited by the available height.
+func (ui *Scroll) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	scaledHeight := dui.Scale(ui.Height)
+	if scaledHeight > 0 && scaledHeight < sizeAvail.Y {
+		sizeAvail.Y = scaledHeight
+	}
+	barDx := dui.Scale(ScrollbarSize)
+	k := KidMeasure(dui, &ui.Kid, image.Pt(sizeAvail.X-barDx, sizeAvail.Y))
+	pref := sizeAvail
+	if ui.Height == 0 {
+		pref.Y = minimum(pref.Y, k.Pref.Y)
+	}
+	min := image.Pt(barDx+k.Min.X, minimum(pref.Y, dui.Display.DefaultFont.Height))
+	max := image.Pt(maximum(pref.X, barDx+k.Max.X), pref.Y)
+	return Sizes{min, pref, max}
+}
+
 func (ui *Scroll) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	dui.debugDraw(self)
 
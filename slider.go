@@ -0,0 +1,377 @@
+package duit
+
+import (
+	"image"
+	"math"
+
+	"9fans.net/go/draw"
+)
+
+// Slider lets the user pick a value from a range by dragging a handle along a track, or with the keyboard.
+// Clicking on the track moves the handle to that place. Tick marks can be drawn along the track.
+// Horizontal sliders are mirrored with DUI.RTL.
+//
+// Keys:
+//	arrow right and up, increase by Step
+//	arrow left and down, decrease by Step
+//	page up and page down, increase or decrease by 10 times Step
+//	home and end, to the minimum and maximum
+type Slider struct {
+	Value    float64                                  // Current value. Call MarkDraw after changing.
+	Min, Max float64                                  // Range of values. If Max <= Min, the range is 0 to 1.
+	Step     float64                                  // If > 0, values are multiples of Step from Min. Also the amount keys change the value by, 1/100th of the range if zero.
+	Ticks    int                                      // If > 0, the number of intervals between the tick marks drawn along the track.
+	Vertical bool                                     // Whether the slider is vertical, with Min at the bottom.
+	Disabled bool                                     // If disabled, mouse and key input have no effect.
+	Changed  func(value float64, done bool) (e Event) `json:"-"` // Called while dragging with done false, and with done true when dragging ends, or after a key changed the value.
+
+	s slider
+}
+
+var _ UI = &Slider{}
+
+// slider implements the track, handles and input of Slider and RangeSlider.
+// The UIs set the fields at the top before each use, and read back values.
+type slider struct {
+	min, max, step float64
+	ticks          int
+	vertical       bool
+	disabled       bool
+	values         []float64 // One or two handles, in increasing order.
+
+	size image.Point
+	m    draw.Mouse
+	drag int // 1 + index of the handle being dragged, 0 if none.
+	grab int // Distance along the track between the mouse and the center of the dragged handle.
+}
+
+func (ui *Slider) core() *slider {
+	s := &ui.s
+	s.min, s.max, s.step = ui.Min, ui.Max, ui.Step
+	s.ticks = ui.Ticks
+	s.vertical = ui.Vertical
+	s.disabled = ui.Disabled
+	s.values = append(s.values[:0], ui.Value)
+	return s
+}
+
+func (s *slider) valueRange() (min, max float64) {
+	if s.max <= s.min {
+		return 0, 1
+	}
+	return s.min, s.max
+}
+
+// handleSize is the height of the handle across the track.
+func (s *slider) handleSize(dui *DUI) int {
+	return dui.Display.DefaultFont.Height
+}
+
+func (s *slider) length() int {
+	if s.vertical {
+		return s.size.Y
+	}
+	return s.size.X
+}
+
+// axis returns the distance of p along the track, from the end with the minimum.
+func (s *slider) axis(dui *DUI, p image.Point) int {
+	if s.vertical {
+		return s.size.Y - p.Y
+	}
+	if dui.RTL {
+		return s.size.X - p.X
+	}
+	return p.X
+}
+
+// point returns the point a along the track, and c across it.
+func (s *slider) point(dui *DUI, a, c int) image.Point {
+	if s.vertical {
+		return image.Pt(c, s.size.Y-a)
+	}
+	if dui.RTL {
+		return image.Pt(s.size.X-a, c)
+	}
+	return image.Pt(a, c)
+}
+
+// rect returns the rectangle from a0 to a1 along the track, and c0 to c1 across it.
+func (s *slider) rect(dui *DUI, a0, c0, a1, c1 int) image.Rectangle {
+	return image.Rectangle{s.point(dui, a0, c0), s.point(dui, a1, c1)}.Canon()
+}
+
+// pos returns the position along the track of the center of a handle for v.
+func (s *slider) pos(dui *DUI, v float64) int {
+	min, max := s.valueRange()
+	h := s.handleSize(dui)
+	f := math.Max(0, math.Min(1, (v-min)/(max-min)))
+	return h/2 + int(f*float64(s.length()-h))
+}
+
+// valueAt returns the value for a handle centered at a along the track.
+func (s *slider) valueAt(dui *DUI, a int) float64 {
+	min, max := s.valueRange()
+	h := s.handleSize(dui)
+	n := s.length() - h
+	if n <= 0 {
+		return min
+	}
+	f := math.Max(0, math.Min(1, float64(a-h/2)/float64(n)))
+	return min + f*(max-min)
+}
+
+func (s *slider) handleRect(dui *DUI, i int) image.Rectangle {
+	h := s.handleSize(dui)
+	a := s.pos(dui, s.values[i])
+	return s.rect(dui, a-h/4, 0, a+h/4, h)
+}
+
+// handleAt returns the index of the handle at p, or the handle nearest to p along the track.
+func (s *slider) handleAt(dui *DUI, p image.Point) (index int, onHandle bool) {
+	for i := len(s.values) - 1; i >= 0; i-- {
+		if p.In(s.handleRect(dui, i)) {
+			return i, true
+		}
+	}
+	a := s.axis(dui, p)
+	dist := -1
+	for i, v := range s.values {
+		d := a - s.pos(dui, v)
+		if d < 0 {
+			d = -d
+		}
+		// with handles at the same place, pick the one that can move toward p
+		if dist < 0 || d < dist || d == dist && a > s.pos(dui, v) {
+			index, dist = i, d
+		}
+	}
+	return index, false
+}
+
+// setValue sets handle i to v, snapped to Step and kept between the other handles. It returns whether the value changed.
+func (s *slider) setValue(i int, v float64) bool {
+	min, max := s.valueRange()
+	if s.step > 0 {
+		v = min + math.Round((v-min)/s.step)*s.step
+	}
+	v = math.Max(min, math.Min(v, max))
+	if i > 0 {
+		v = math.Max(v, s.values[i-1])
+	}
+	if i < len(s.values)-1 {
+		v = math.Min(v, s.values[i+1])
+	}
+	if v == s.values[i] {
+		return false
+	}
+	s.values[i] = v
+	return true
+}
+
+func (s *slider) layout(dui *DUI, self *Kid, sizeAvail image.Point) {
+	s.size = s.measure(dui, sizeAvail).Pref
+	self.R = rect(s.size)
+}
+
+// measure returns the available length as preferred size for horizontal sliders, and ten handles for vertical sliders.
+func (s *slider) measure(dui *DUI, sizeAvail image.Point) Sizes {
+	h := s.handleSize(dui)
+	cross := h
+	if s.ticks > 0 {
+		cross += h / 4
+	}
+	if s.vertical {
+		return Sizes{image.Pt(cross, 3*h), image.Pt(cross, minimum(sizeAvail.Y, 10*h)), image.Pt(cross, sizeAvail.Y)}
+	}
+	return Sizes{image.Pt(3*h, cross), image.Pt(sizeAvail.X, cross), image.Pt(sizeAvail.X, cross)}
+}
+
+func (s *slider) draw(dui *DUI, img *draw.Image, orig image.Point, m draw.Mouse) {
+	if s.size.X <= 0 || s.size.Y <= 0 {
+		return
+	}
+	h := s.handleSize(dui)
+	length := s.length()
+	img.Draw(rect(s.size).Add(orig), dui.Background, nil, image.ZP)
+
+	normal := dui.Regular.Normal
+	fill := dui.Primary.Normal.Background
+	if s.disabled {
+		normal = dui.Disabled
+		fill = dui.Disabled.Border
+	}
+
+	if s.ticks > 0 {
+		for i := 0; i <= s.ticks; i++ {
+			a := h/2 + (length-h)*i/s.ticks
+			img.Line(s.point(dui, a, h).Add(orig), s.point(dui, a, h+h/4-1).Add(orig), 0, 0, 0, dui.Gutter, image.ZP)
+		}
+	}
+
+	t := maximum(4, h/3)
+	trackR := s.rect(dui, h/2-t/2, (h-t)/2, length-h/2+t/2, (h+t)/2).Add(orig)
+	img.Draw(trackR, normal.Background, nil, image.ZP)
+	a0 := h / 2
+	if len(s.values) > 1 {
+		a0 = s.pos(dui, s.values[0])
+	}
+	a1 := s.pos(dui, s.values[len(s.values)-1])
+	img.Draw(s.rect(dui, a0, (h-t)/2, a1, (h+t)/2).Add(orig), fill, nil, image.ZP)
+	drawRoundedBorder(img, trackR, normal.Border)
+
+	for i := range s.values {
+		hr := s.handleRect(dui, i)
+		colors := normal
+		if !s.disabled && (s.drag == 1+i || s.drag == 0 && m.In(hr)) {
+			colors = dui.Regular.Hover
+		}
+		hr = hr.Add(orig)
+		img.Draw(hr, colors.Background, nil, image.ZP)
+		drawRoundedBorder(img, hr, colors.Border)
+	}
+}
+
+// mouse handles dragging the handles. Changed is set when a value changed, done when a drag ended.
+func (s *slider) mouse(dui *DUI, self *Kid, m draw.Mouse) (changed, done, consumed bool) {
+	prevM := s.m
+	s.m = m
+	if s.disabled {
+		return
+	}
+	pi, pon := s.handleAt(dui, prevM.Point)
+	ni, non := s.handleAt(dui, m.Point)
+	if pon != non || pi != ni {
+		self.Draw = Dirty
+	}
+
+	b1 := m.Buttons&Button1 != 0
+	if prevM.Buttons&Button1 == 0 && b1 {
+		if !m.In(rect(s.size)) {
+			return
+		}
+		s.drag = 1 + ni
+		s.grab = 0
+		if non {
+			s.grab = s.axis(dui, m.Point) - s.pos(dui, s.values[ni])
+		}
+	}
+	if s.drag == 0 {
+		return
+	}
+	consumed = true
+	self.Draw = Dirty
+	changed = s.setValue(s.drag-1, s.valueAt(dui, s.axis(dui, m.Point)-s.grab))
+	if !b1 {
+		s.drag = 0
+		done = true
+	}
+	return
+}
+
+// key changes the value of the handle nearest to the mouse.
+func (s *slider) key(dui *DUI, self *Kid, k rune, m draw.Mouse) (changed, consumed bool) {
+	if s.disabled || !m.In(rect(s.size)) {
+		return
+	}
+	min, max := s.valueRange()
+	step := s.step
+	if step <= 0 {
+		step = (max - min) / 100
+	}
+	right, left := draw.KeyRight, draw.KeyLeft
+	if dui.RTL && !s.vertical {
+		right, left = left, right
+	}
+	i, _ := s.handleAt(dui, m.Point)
+	v := s.values[i]
+	switch k {
+	case right, draw.KeyUp:
+		v += step
+	case left, draw.KeyDown:
+		v -= step
+	case draw.KeyPageUp:
+		v += 10 * step
+	case draw.KeyPageDown:
+		v -= 10 * step
+	case draw.KeyHome:
+		v = min
+	case draw.KeyEnd:
+		v = max
+	default:
+		return
+	}
+	consumed = true
+	changed = s.setValue(i, v)
+	if changed {
+		self.Draw = Dirty
+	}
+	return
+}
+
+// focus returns the center of the first handle.
+func (s *slider) focus(dui *DUI) *image.Point {
+	if len(s.values) == 0 {
+		return nil
+	}
+	hr := s.handleRect(dui, 0)
+	p := hr.Min.Add(hr.Size().Div(2))
+	return &p
+}
+
+func (ui *Slider) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+	ui.core().layout(dui, self, sizeAvail)
+}
+
+func (ui *Slider) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	return ui.core().measure(dui, sizeAvail)
+}
+
+func (ui *Slider) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+	ui.core().draw(dui, img, orig, m)
+}
+
+func (ui *Slider) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	s := ui.core()
+	changed, done, consumed := s.mouse(dui, self, m)
+	ui.Value = s.values[0]
+	r.Consumed = consumed
+	if (changed || done) && ui.Changed != nil {
+		e := ui.Changed(ui.Value, done)
+		propagateEvent(self, &r, e)
+	}
+	return
+}
+
+func (ui *Slider) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	s := ui.core()
+	changed, consumed := s.key(dui, self, k, m)
+	ui.Value = s.values[0]
+	r.Consumed = consumed
+	if changed && ui.Changed != nil {
+		e := ui.Changed(ui.Value, true)
+		propagateEvent(self, &r, e)
+	}
+	return
+}
+
+func (ui *Slider) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	return ui.core().focus(dui)
+}
+
+func (ui *Slider) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if o != ui {
+		return nil
+	}
+	return ui.FirstFocus(dui, self)
+}
+
+func (ui *Slider) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return self.Mark(o, forLayout)
+}
+
+func (ui *Slider) Print(self *Kid, indent int) {
+	PrintUI("Slider", self, indent)
+}
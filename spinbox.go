@@ -0,0 +1,339 @@
+package duit
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+
+	"9fans.net/go/draw"
+)
+
+// Spinbox is a field for entering a number, with buttons to increment and decrement it.
+// Typed text is restricted to numbers with at most Precision decimals. Values outside Min and Max are marked invalid, and not delivered to Changed.
+// The mouse wheel over the spinbox steps the value.
+//
+// Keys:
+//	arrow up, increment by Step
+//	arrow down, decrement by Step
+//	page up, increment by 10 times Step
+//	page down, decrement by 10 times Step
+type Spinbox struct {
+	Value     float64                       // Current value. Call MarkDraw after changing.
+	Min, Max  float64                       // Range of allowed values. If Max <= Min, the value is not limited.
+	Step      float64                       // Amount to increment or decrement by, 1 if zero.
+	Precision int                           // Number of decimals shown, and allowed when typing.
+	Disabled  bool                          // If disabled, mouse and key input have no effect.
+	Font      *draw.Font                    `json:"-"` // Font to use for drawing the value.
+	Changed   func(value float64) (e Event) `json:"-"` // Called after the value changed, by typing a valid value or by stepping.
+
+	field     Field
+	fieldKid  Kid
+	shown     float64 // Value in field, to detect changes to Value.
+	haveShown bool
+	size      image.Point
+	buttonsR  image.Rectangle // Increment button above decrement button.
+	m         draw.Mouse
+}
+
+var _ UI = &Spinbox{}
+
+func (ui *Spinbox) font(dui *DUI) *draw.Font {
+	return dui.Font(ui.Font)
+}
+
+func (ui *Spinbox) limited() bool {
+	return ui.Min < ui.Max
+}
+
+func (ui *Spinbox) step() float64 {
+	if ui.Step == 0 {
+		return 1
+	}
+	return ui.Step
+}
+
+func (ui *Spinbox) format(v float64) string {
+	return strconv.FormatFloat(v, 'f', maximum(0, ui.Precision), 64)
+}
+
+// ensure sets up the field, and puts a changed Value in it.
+func (ui *Spinbox) ensure() {
+	if ui.fieldKid.UI == nil {
+		ui.fieldKid.UI = &ui.field
+		ui.field.Mask = ui.mask
+		ui.field.Validate = ui.validate
+		ui.field.Changed = ui.fieldChanged
+	}
+	ui.field.Font = ui.Font
+	ui.field.Disabled = ui.Disabled
+	if !ui.haveShown || ui.Value != ui.shown {
+		ui.field.Text = ui.format(ui.Value)
+		ui.shown = ui.Value
+		ui.haveShown = true
+	}
+}
+
+// mask allows the start of a number with at most Precision decimals, and a minus sign only if negative values are allowed.
+func (ui *Spinbox) mask(text string) bool {
+	if strings.HasPrefix(text, "-") {
+		if ui.limited() && ui.Min >= 0 {
+			return false
+		}
+		text = text[1:]
+	}
+	t := strings.SplitN(text, ".", 2)
+	if len(t) == 2 && (ui.Precision <= 0 || len(t[1]) > ui.Precision) {
+		return false
+	}
+	return MaskDigits(strings.Replace(text, ".", "", 1))
+}
+
+// validate returns why text is not an allowed value.
+func (ui *Spinbox) validate(text string) (msg string) {
+	v, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return "not a number"
+	}
+	if ui.limited() && v < ui.Min {
+		return fmt.Sprintf("at least %s", ui.format(ui.Min))
+	}
+	if ui.limited() && v > ui.Max {
+		return fmt.Sprintf("at most %s", ui.format(ui.Max))
+	}
+	return ""
+}
+
+// fieldChanged sets Value from typed text, if it is valid.
+func (ui *Spinbox) fieldChanged(text string) (e Event) {
+	if ui.validate(text) != "" {
+		return
+	}
+	v, _ := strconv.ParseFloat(text, 64)
+	if v == ui.Value {
+		return
+	}
+	ui.Value = v
+	ui.shown = v
+	if ui.Changed != nil {
+		e = ui.Changed(v)
+	}
+	return
+}
+
+// stepBy changes Value by n steps, keeping it within Min and Max, and rounded to Precision.
+func (ui *Spinbox) stepBy(self *Kid, r *Result, n int) {
+	v := ui.Value + float64(n)*ui.step()
+	if ui.limited() {
+		v = math.Max(ui.Min, math.Min(v, ui.Max))
+	}
+	f := math.Pow(10, float64(maximum(0, ui.Precision)))
+	v = math.Round(v*f) / f
+	r.Consumed = true
+	self.Draw = Dirty
+	changed := v != ui.Value
+	ui.Value = v
+	ui.shown = v
+	ui.field.Text = ui.format(v)
+	ui.field.Cursor1 = 0
+	ui.field.SelectionStart1 = 0
+	if changed && ui.Changed != nil {
+		e := ui.Changed(v)
+		propagateEvent(self, r, e)
+	}
+}
+
+func (ui *Spinbox) buttonWidth(dui *DUI) int {
+	return ui.font(dui).Height
+}
+
+func (ui *Spinbox) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+	ui.ensure()
+
+	width := minimum(sizeAvail.X, ui.Measure(dui, self, sizeAvail).Pref.X)
+	bw := ui.buttonWidth(dui)
+	ui.field.Layout(dui, &ui.fieldKid, image.Pt(width-bw, sizeAvail.Y), force)
+	ui.size = image.Pt(width, ui.fieldKid.R.Dy())
+	ui.buttonsR = image.Rect(width-bw, 0, width, ui.size.Y)
+	if dui.RTL {
+		ui.buttonsR = image.Rect(0, 0, bw, ui.size.Y)
+		ui.fieldKid.R = ui.fieldKid.R.Add(image.Pt(bw, 0))
+	}
+	self.R = rect(ui.size)
+}
+
+// Measure returns room for the widest of Min and Max, or ten digits if the value is not limited.
+func (ui *Spinbox) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	ui.ensure()
+	font := ui.font(dui)
+	space := ui.field.space(dui)
+	s := "-" + strings.Repeat("0", 10)
+	if ui.limited() {
+		s = ui.format(ui.Min)
+		if t := ui.format(ui.Max); len(t) > len(s) {
+			s = t
+		}
+	}
+	dy := font.Height + 2*space.Y
+	dx := font.StringWidth(s+"0") + 2*space.X + ui.buttonWidth(dui)
+	min := image.Pt(font.StringWidth("000")+2*space.X+ui.buttonWidth(dui), dy)
+	return Sizes{min, image.Pt(dx, dy), image.Pt(dx, dy)}
+}
+
+func (ui *Spinbox) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+	ui.ensure()
+
+	mm := m
+	mm.Point = mm.Point.Sub(ui.fieldKid.R.Min)
+	ui.field.Draw(dui, &ui.fieldKid, img, orig.Add(ui.fieldKid.R.Min), mm, true)
+	ui.fieldKid.Draw = Clean
+
+	font := ui.font(dui)
+	upR, downR := ui.buttonRects()
+	drawButton := func(r image.Rectangle, s string) {
+		colors := dui.Regular.Normal
+		if ui.Disabled {
+			colors = dui.Disabled
+		} else if m.In(r) {
+			colors = dui.Regular.Hover
+		}
+		r = r.Add(orig)
+		img.Draw(r, colors.Background, nil, image.ZP)
+		p := r.Min.Add(image.Pt((r.Dx()-font.StringWidth(s))/2, (r.Dy()-font.Height)/2))
+		img.String(p, colors.Text, image.ZP, font, s)
+	}
+	drawButton(upR, "▴")
+	drawButton(downR, "▾")
+	border := dui.Regular.Normal.Border
+	if ui.Disabled {
+		border = dui.Disabled.Border
+	}
+	drawRoundedBorder(img, ui.buttonsR.Add(orig), border)
+	y := orig.Y + upR.Max.Y
+	img.Line(image.Pt(orig.X+ui.buttonsR.Min.X, y), image.Pt(orig.X+ui.buttonsR.Max.X-1, y), 0, 0, 0, border, image.ZP)
+}
+
+// buttonRects returns the rectangles of the increment and decrement buttons.
+func (ui *Spinbox) buttonRects() (upR, downR image.Rectangle) {
+	upR = ui.buttonsR
+	upR.Max.Y = upR.Min.Y + upR.Dy()/2
+	downR = ui.buttonsR
+	downR.Min.Y = upR.Max.Y
+	return
+}
+
+// buttonAt returns 1 if p is on the increment button, -1 for the decrement button, and 0 otherwise.
+func (ui *Spinbox) buttonAt(p image.Point) int {
+	upR, downR := ui.buttonRects()
+	if p.In(upR) {
+		return 1
+	} else if p.In(downR) {
+		return -1
+	}
+	return 0
+}
+
+// stepKey returns the number of steps for key k, or 0.
+func stepKey(k rune) int {
+	switch k {
+	case draw.KeyUp:
+		return 1
+	case draw.KeyDown:
+		return -1
+	case draw.KeyPageUp:
+		return 10
+	case draw.KeyPageDown:
+		return -10
+	}
+	return 0
+}
+
+func (ui *Spinbox) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	if ui.Disabled {
+		return
+	}
+	ui.ensure()
+	prevM := ui.m
+	ui.m = m
+	if ui.buttonAt(prevM.Point) != ui.buttonAt(m.Point) {
+		self.Draw = Dirty
+	}
+	switch m.Buttons {
+	case Button4:
+		ui.stepBy(self, &r, 1)
+		return
+	case Button5:
+		ui.stepBy(self, &r, -1)
+		return
+	}
+	if origM.In(ui.buttonsR) {
+		if n := ui.buttonAt(m.Point); n != 0 && prevM.Buttons == 0 && m.Buttons == Button1 {
+			ui.stepBy(self, &r, n)
+		}
+		r.Consumed = true
+		return
+	}
+	m.Point = m.Point.Sub(ui.fieldKid.R.Min)
+	origM.Point = origM.Point.Sub(ui.fieldKid.R.Min)
+	r = ui.field.Mouse(dui, &ui.fieldKid, m, origM, orig.Add(ui.fieldKid.R.Min))
+	ui.fieldResult(self)
+	return
+}
+
+// fieldResult propagates the layout and draw state of the field.
+func (ui *Spinbox) fieldResult(self *Kid) {
+	if ui.fieldKid.Layout != Clean {
+		self.Layout = Dirty
+	}
+	if ui.fieldKid.Draw != Clean {
+		self.Draw = Dirty
+	}
+	ui.shown = ui.Value
+}
+
+func (ui *Spinbox) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	if ui.Disabled || !m.In(rect(ui.size)) {
+		return
+	}
+	ui.ensure()
+	if n := stepKey(k); n != 0 {
+		ui.stepBy(self, &r, n)
+		return
+	}
+	m.Point = m.Point.Sub(ui.fieldKid.R.Min)
+	if !m.In(rect(ui.field.size)) {
+		// on the buttons, let the field handle typing anyway
+		m.Point = image.Pt(1, 1)
+	}
+	r = ui.field.Key(dui, &ui.fieldKid, k, m, orig.Add(ui.fieldKid.R.Min))
+	ui.fieldResult(self)
+	return
+}
+
+func (ui *Spinbox) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	ui.ensure()
+	p := ui.field.FirstFocus(dui, &ui.fieldKid)
+	if p == nil {
+		return nil
+	}
+	pp := p.Add(ui.fieldKid.R.Min)
+	return &pp
+}
+
+func (ui *Spinbox) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if o != ui {
+		return nil
+	}
+	return ui.FirstFocus(dui, self)
+}
+
+func (ui *Spinbox) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return self.Mark(o, forLayout)
+}
+
+func (ui *Spinbox) Print(self *Kid, indent int) {
+	PrintUI("Spinbox", self, indent)
+}
@@ -0,0 +1,135 @@
+package duit
+
+import (
+	"image"
+	"time"
+
+	"9fans.net/go/draw"
+)
+
+const (
+	spinnerInterval = 100 * time.Millisecond // Time between animation steps.
+	spinnerSteps    = 12                     // Steps for a full turn.
+)
+
+// Spinner is an animated busy indicator, for work of unknown length.
+// Call Start to begin animating, and Stop when the work is done. A stopped spinner draws nothing, but keeps its room.
+// While running, a goroutine sends a function on DUI.Call for each animation step, which marks only the spinner for drawing.
+// Steps are skipped while the main loop is busy, so they do not pile up.
+// Start and Stop must be called from the main loop, e.g. in a function sent on DUI.Call. Stop a spinner before removing it from the UI.
+type Spinner struct {
+	Size int // Diameter in lowDPI pixels, the font height if zero.
+
+	size image.Point
+	step int           // Current animation step.
+	stop chan struct{} // Closed to stop animating. Nil while stopped.
+}
+
+var _ UI = &Spinner{}
+
+// Running returns whether the spinner is animating.
+func (ui *Spinner) Running() bool {
+	return ui.stop != nil
+}
+
+// Start starts animating the spinner, if it is not already running.
+func (ui *Spinner) Start(dui *DUI) {
+	if ui.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	ui.stop = stop
+	dui.MarkDraw(ui)
+	go func() {
+		t := time.NewTicker(spinnerInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+			}
+			select {
+			case <-stop:
+				return
+			case dui.Call <- func() {
+				if ui.stop != stop {
+					// stopped, and perhaps started again, after this step was sent
+					return
+				}
+				ui.step = (ui.step + 1) % spinnerSteps
+				dui.MarkDraw(ui)
+			}:
+			}
+		}
+	}()
+}
+
+// Stop stops animating the spinner, and clears it.
+func (ui *Spinner) Stop(dui *DUI) {
+	if ui.stop == nil {
+		return
+	}
+	close(ui.stop)
+	ui.stop = nil
+	dui.MarkDraw(ui)
+}
+
+func (ui *Spinner) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+	ui.size = ui.Measure(dui, self, sizeAvail).Pref
+	self.R = rect(ui.size)
+}
+
+func (ui *Spinner) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	d := dui.Display.DefaultFont.Height
+	if ui.Size > 0 {
+		d = dui.Scale(ui.Size)
+	}
+	return fixedSizes(image.Pt(d, d))
+}
+
+func (ui *Spinner) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+
+	if ui.stop == nil || ui.size.X <= 0 {
+		return
+	}
+	thick := maximum(1, ui.size.X/10)
+	radius := ui.size.X/2 - thick - 1
+	if radius <= 0 {
+		return
+	}
+	c := orig.Add(ui.size.Div(2))
+	img.Ellipse(c, radius, radius, thick/2, dui.ScrollBGNormal, image.ZP)
+	// a quarter arc, turning clockwise
+	alpha := 90 - ui.step*360/spinnerSteps
+	img.Arc(c, radius, radius, thick/2, dui.Primary.Normal.Background, image.ZP, alpha, -90)
+}
+
+func (ui *Spinner) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	return
+}
+
+func (ui *Spinner) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	return
+}
+
+func (ui *Spinner) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	return nil
+}
+
+func (ui *Spinner) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if o != ui {
+		return nil
+	}
+	return &image.ZP
+}
+
+func (ui *Spinner) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return self.Mark(o, forLayout)
+}
+
+func (ui *Spinner) Print(self *Kid, indent int) {
+	PrintUI("Spinner", self, indent)
+}
@@ -36,14 +36,40 @@ func (ui *Split) ensureManual(dui *DUI) {
 	if len(ui.manual.dims) != len(ui.Kids) {
 		ui.manual.dims = make([]int, len(ui.dims))
 	}
-	copy(ui.manual.dims, ui.dims)
+	// hidden kids keep their manual dimension for when they are shown again
+	for i, d := range ui.dims {
+		if !ui.Kids[i].Hidden {
+			ui.manual.dims[i] = d
+		}
+	}
 	gut := dui.Scale(ui.Gutter)
-	ui.manual.uiDim = (len(ui.Kids) - 1) * gut
+	ui.manual.uiDim = (ui.visible() - 1) * gut
 	for _, d := range ui.dims {
 		ui.manual.uiDim += d
 	}
 }
 
+// visible returns the number of kids that are not hidden.
+func (ui *Split) visible() int {
+	n := 0
+	for _, k := range ui.Kids {
+		if !k.Hidden {
+			n++
+		}
+	}
+	return n
+}
+
+// nextVisible returns the index of the first kid after i that is not hidden, or -1.
+func (ui *Split) nextVisible(i int) int {
+	for i++; i < len(ui.Kids); i++ {
+		if !ui.Kids[i].Hidden {
+			return i
+		}
+	}
+	return -1
+}
+
 func (ui *Split) dim(p image.Point) int {
 	if ui.Vertical {
 		return p.Y
@@ -77,25 +103,39 @@ func (ui *Split) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool)
 	}
 
 	gut := dui.Scale(ui.Gutter)
+	nvisible := ui.visible()
+	last := -1 // last visible kid, it gets the remaining space
+	for i, k := range ui.Kids {
+		if !k.Hidden {
+			last = i
+		}
+	}
 
 	// from manual.dims to dims
 	reassign := func() {
 		if len(ui.dims) != len(ui.Kids) {
 			ui.dims = make([]int, len(ui.Kids))
 		}
-		if sizeAvail.X == ui.manual.uiDim {
+		if sizeAvail.X == ui.manual.uiDim && nvisible == len(ui.Kids) {
 			copy(ui.dims, ui.manual.dims)
 			return
 		}
 
 		had := 0
-		for _, d := range ui.manual.dims {
-			had += d
+		for i, d := range ui.manual.dims {
+			if !ui.Kids[i].Hidden {
+				had += d
+			}
+		}
+		if had == 0 {
+			had = 1
 		}
-		have := ui.dim(sizeAvail) - (len(ui.Kids)-1)*gut
+		have := ui.dim(sizeAvail) - (nvisible-1)*gut
 		left := have
 		for i, d := range ui.manual.dims {
-			if i == len(ui.manual.dims)-1 {
+			if ui.Kids[i].Hidden {
+				ui.dims[i] = 0
+			} else if i == last {
 				ui.dims[i] = left
 			} else {
 				ui.dims[i] = d * have / had
@@ -103,22 +143,39 @@ func (ui *Split) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool)
 			}
 		}
 		ui.manual.uiDim = ui.dim(sizeAvail)
-		copy(ui.manual.dims, ui.dims)
+		for i, d := range ui.dims {
+			if !ui.Kids[i].Hidden {
+				ui.manual.dims[i] = d
+			}
+		}
 	}
 
 	split := func() {
+		have := ui.dim(sizeAvail) - (nvisible-1)*gut
 		if ui.Split == nil {
-			have := ui.dim(sizeAvail) - (len(ui.Kids)-1)*gut
 			ui.dims = make([]int, len(ui.Kids))
-			for i := range ui.dims {
-				ui.dims[i] = have / len(ui.Kids)
+			left := have
+			for i, k := range ui.Kids {
+				if k.Hidden {
+					continue
+				}
+				if i == last {
+					ui.dims[i] = left
+				} else {
+					ui.dims[i] = have / nvisible
+					left -= ui.dims[i]
+				}
 			}
-			ui.dims[len(ui.dims)-1] = have - (len(ui.dims)-1)*(have/len(ui.Kids))
 		} else {
-			ui.dims = ui.Split(ui.dim(sizeAvail) - (len(ui.Kids)-1)*gut)
+			ui.dims = ui.Split(have)
 			if len(ui.dims) != len(ui.Kids) {
 				panic("bad number of dims from split")
 			}
+			for i, k := range ui.Kids {
+				if k.Hidden {
+					ui.dims[i] = 0
+				}
+			}
 		}
 		ui.manual.dims = nil
 		ui.manual.uiDim = 0
@@ -128,7 +185,7 @@ func (ui *Split) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool)
 	if len(ui.manual.dims) == len(ui.Kids) {
 		reassign()
 	} else if self.ID != "" && dui.ReadSettings(self, &r) && len(r) == len(ui.Kids) {
-		ui.manual.uiDim = (len(ui.Kids) - 1) * gut
+		ui.manual.uiDim = (nvisible - 1) * gut
 		for _, d := range r {
 			ui.manual.uiDim += d
 		}
@@ -141,20 +198,26 @@ func (ui *Split) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool)
 	ui.size = image.ZP
 	if ui.Vertical {
 		for i, k := range ui.Kids {
+			if k.Hidden {
+				continue
+			}
 			k.UI.Layout(dui, k, image.Pt(sizeAvail.X, ui.dims[i]), true)
 			k.R = k.R.Add(image.Pt(0, ui.size.Y))
 			ui.size.Y += ui.dims[i]
-			if i < len(ui.dims)-1 {
+			if i < last {
 				ui.size.Y += gut
 			}
 			ui.size.X = maximum(ui.size.X, k.R.Dx())
 		}
 	} else {
 		for i, k := range ui.Kids {
+			if k.Hidden {
+				continue
+			}
 			k.UI.Layout(dui, k, image.Pt(ui.dims[i], sizeAvail.Y), true)
 			k.R = k.R.Add(image.Pt(ui.size.X, 0))
 			ui.size.X += ui.dims[i]
-			if i < len(ui.dims)-1 {
+			if i < last {
 				ui.size.X += gut
 			}
 			ui.size.Y = maximum(ui.size.Y, k.R.Dy())
@@ -163,6 +226,105 @@ func (ui *Split) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool)
 	self.R = rect(ui.size)
 }
 
+// along returns p with its coordinate along the split replaced by v.
+func (ui *Split) along(p image.Point, v int) image.Point {
+	if ui.Vertical {
+		p.Y = v
+	} else {
+		p.X = v
+	}
+	return p
+}
+
+// Measure returns the available space as preferred size, and the minimum sizes of the kids next to each other as minimum.
+func (ui *Split) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	nvisible := ui.visible()
+	if nvisible == 0 {
+		return Sizes{}
+	}
+	gut := dui.Scale(ui.Gutter)
+	have := ui.dim(sizeAvail) - (nvisible-1)*gut
+	var min, cross image.Point // cross holds the preferred and maximum size perpendicular to the split
+	for _, k := range ui.Kids {
+		if k.Hidden {
+			continue
+		}
+		s := KidMeasure(dui, k, ui.along(sizeAvail, have/nvisible))
+		if ui.Vertical {
+			min = image.Pt(maximum(min.X, s.Min.X), min.Y+s.Min.Y)
+			cross = image.Pt(maximum(cross.X, s.Pref.X), maximum(cross.Y, s.Max.X))
+		} else {
+			min = image.Pt(min.X+s.Min.X, maximum(min.Y, s.Min.Y))
+			cross = image.Pt(maximum(cross.X, s.Pref.Y), maximum(cross.Y, s.Max.Y))
+		}
+	}
+	min = ui.along(min, ui.dim(min)+(nvisible-1)*gut)
+	var pref, max image.Point
+	if ui.Vertical {
+		pref = image.Pt(cross.X, sizeAvail.Y)
+		max = image.Pt(cross.Y, sizeAvail.Y)
+	} else {
+		pref = image.Pt(sizeAvail.X, cross.X)
+		max = image.Pt(sizeAvail.X, cross.Y)
+	}
+	return Sizes{min, pref, max}
+}
+
+// minDim returns the minimum size along the split of kid i.
+func (ui *Split) minDim(dui *DUI, i int) int {
+	k := ui.Kids[i]
+	return ui.dim(KidMeasure(dui, k, ui.along(ui.size, ui.dims[i])).Min)
+}
+
+// Insert adds uis to Kids at index, and marks the split for layout. Index -1 means at the end.
+// The division of space is reset.
+func (ui *Split) Insert(dui *DUI, index int, uis ...UI) {
+	ui.Kids = kidsInsert(ui.Kids, index, uis...)
+	ui.resetDims()
+	dui.MarkLayout(ui)
+}
+
+// Remove removes the kid at index, and marks the split for layout.
+// The division of space is reset.
+func (ui *Split) Remove(dui *DUI, index int) {
+	ui.Kids = kidsRemove(ui.Kids, index, 1)
+	ui.resetDims()
+	dui.MarkLayout(ui)
+}
+
+// Move moves the kid at index from to index to, and marks the split for layout.
+// The kid keeps its size.
+func (ui *Split) Move(dui *DUI, from, to int) {
+	kidsMove(ui.Kids, from, to, 1)
+	moveInt := func(l []int) {
+		if len(l) != len(ui.Kids) {
+			return
+		}
+		v := l[from]
+		if from < to {
+			copy(l[from:], l[from+1:to+1])
+		} else {
+			copy(l[to+1:], l[to:from])
+		}
+		l[to] = v
+	}
+	moveInt(ui.dims)
+	moveInt(ui.manual.dims)
+	dui.MarkLayout(ui)
+}
+
+// Replace replaces the kid at index with a new kid for newUI, and marks the split for layout.
+func (ui *Split) Replace(dui *DUI, index int, newUI UI) {
+	ui.Kids[index] = &Kid{UI: newUI}
+	dui.MarkLayout(ui)
+}
+
+func (ui *Split) resetDims() {
+	ui.dims = nil
+	ui.manual.dims = nil
+	ui.manual.uiDim = 0
+}
+
 func (ui *Split) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	KidsDraw(dui, self, ui.Kids, ui.size, ui.Background, img, orig, m, force)
 }
@@ -172,7 +334,13 @@ func (ui *Split) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig
 		gut := dui.Scale(ui.Gutter)
 		o := 0
 		slack := dui.Scale(1)
-		for i, dd := range ui.dims[:len(ui.dims)-1] {
+		for i, dd := range ui.dims {
+			if ui.Kids[i].Hidden {
+				continue
+			}
+			if ui.nextVisible(i) < 0 {
+				break
+			}
 			o += dd
 			if d >= o-slack && d < o+gut+slack {
 				return i
@@ -193,9 +361,14 @@ func (ui *Split) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig
 			delta := ui.dim(m.Point) - ui.dim(ui.m.Point)
 			if delta != 0 {
 				ui.ensureManual(dui)
-				if ui.manual.dims[ui.draggingIndex]+delta >= 0 && ui.manual.dims[ui.draggingIndex+1]-delta >= 0 {
+				// panes do not shrink below their minimum size, but can grow when they are smaller
+				next := ui.nextVisible(ui.draggingIndex)
+				ok := ui.manual.dims[ui.draggingIndex]+delta >= 0 && ui.manual.dims[next]-delta >= 0
+				ok = ok && (delta > 0 || ui.manual.dims[ui.draggingIndex]+delta >= ui.minDim(dui, ui.draggingIndex))
+				ok = ok && (delta < 0 || ui.manual.dims[next]-delta >= ui.minDim(dui, next))
+				if ok {
 					ui.manual.dims[ui.draggingIndex] += delta
-					ui.manual.dims[ui.draggingIndex+1] -= delta
+					ui.manual.dims[next] -= delta
 					dui.WriteSettings(self, ui.manual.dims)
 				}
 				r.Consumed = true
@@ -0,0 +1,305 @@
+package duit
+
+import (
+	"image"
+	"time"
+
+	"9fans.net/go/draw"
+)
+
+// NewStack returns a stack containing all uis in its Kids field, with the first UI active.
+func NewStack(uis ...UI) *Stack {
+	return &Stack{Kids: NewKids(uis...)}
+}
+
+// Stack shows only one of its Kids at a time, the one at index Active. For example for the pages of a wizard, or master/detail views.
+// Only the active kid is layed out and drawn. All kids are kept, so inactive kids retain their state, such as scroll offsets and field contents.
+// Use Select to change the active kid.
+type Stack struct {
+	Kids       []*Kid        // Kids that can be shown.
+	Active     int           // Index in Kids of the kid shown. Change with Select. If out of range, nothing is shown.
+	KeepFocus  bool          // If set, Select remembers where the mouse was in the kid being deactivated, and warps the mouse back there when that kid is selected again. Only when the mouse is in the stack at time of Select.
+	Background *draw.Image   `json:"-"` // Background for this stack, instead of default duit background.
+	Duration   time.Duration // Of Transition. If 0, a default of 200ms is used.
+
+	// Optional, draws one frame of an animation when Select changes the active kid, such as StackSlide.
+	// Img is to be drawn on in rectangle r, which has already been cleared with the background color.
+	// From and to hold the drawn old and new kid, with their origin at the top left of r.
+	// Progress goes from 0 to 1 over Duration.
+	Transition func(img *draw.Image, r image.Rectangle, from, to *draw.Image, progress float64) `json:"-"`
+
+	size       image.Point
+	orig       image.Point          // absolute origin of stack, as seen in last mouse or key event
+	focus      map[*Kid]image.Point // remembered mouse positions for KeepFocus, relative to the kid
+	transition *stackTransition
+}
+
+type stackTransition struct {
+	start     time.Time
+	from, to  *draw.Image
+	scheduled bool // whether a redraw for the next frame has been scheduled
+}
+
+var _ UI = &Stack{}
+
+// StackSlide is a Transition for Stack, sliding the new kid in from the right.
+func StackSlide(img *draw.Image, r image.Rectangle, from, to *draw.Image, progress float64) {
+	dx := int(float64(r.Dx()) * progress)
+	img.Draw(r, from, nil, image.Pt(dx, 0))
+	r.Min.X = r.Max.X - dx
+	img.Draw(r, to, nil, image.ZP)
+}
+
+func (ui *Stack) active() *Kid {
+	if ui.Active < 0 || ui.Active >= len(ui.Kids) {
+		return nil
+	}
+	return ui.Kids[ui.Active]
+}
+
+// activeKids returns the kids that are visible, for passing to the Kids* functions.
+func (ui *Stack) activeKids() []*Kid {
+	if k := ui.active(); k != nil {
+		return []*Kid{k}
+	}
+	return nil
+}
+
+// Select makes the kid at index active.
+// With KeepFocus set and the mouse in the stack, the mouse is warped to the newly active kid.
+func (ui *Stack) Select(dui *DUI, index int) {
+	if index == ui.Active {
+		return
+	}
+	old := ui.active()
+	p := dui.mouse.Point.Sub(ui.orig)
+	mouseIn := p.In(rect(ui.size))
+	if ui.KeepFocus && old != nil && mouseIn {
+		if ui.focus == nil {
+			ui.focus = map[*Kid]image.Point{}
+		}
+		ui.focus[old] = p.Sub(old.R.Min)
+	}
+
+	ui.stopTransition()
+	if ui.Transition != nil && old != nil && !old.R.Empty() {
+		from, err := dui.Display.AllocImage(rect(old.R.Size()), draw.ARGB32, false, dui.BackgroundColor)
+		if !dui.error(err, "allocimage") {
+			from.Draw(from.R, ui.background(dui), nil, image.ZP)
+			old.UI.Draw(dui, old, from, image.ZP, draw.Mouse{Point: image.Pt(-1, -1)}, true)
+			ui.transition = &stackTransition{start: time.Now(), from: from}
+		}
+	}
+
+	ui.Active = index
+	if k := ui.active(); k != nil {
+		k.Layout = Dirty
+	}
+	dui.MarkLayout(ui)
+	if ui.KeepFocus && mouseIn {
+		// warp after the new kid has been layed out, from the main loop
+		go func() {
+			dui.Call <- func() {
+				dui.Focus(ui)
+			}
+		}()
+	}
+}
+
+// Insert adds uis to Kids at index, and marks the stack for layout. Index -1 means at the end.
+// The active kid stays active.
+func (ui *Stack) Insert(dui *DUI, index int, uis ...UI) {
+	if index < 0 {
+		index = len(ui.Kids)
+	}
+	ui.Kids = kidsInsert(ui.Kids, index, uis...)
+	if index <= ui.Active {
+		ui.Active += len(uis)
+	}
+	dui.MarkLayout(ui)
+}
+
+// Remove removes the kid at index, and marks the stack for layout.
+// If the active kid is removed, the next kid becomes active, or the previous if there is no next kid.
+func (ui *Stack) Remove(dui *DUI, index int) {
+	delete(ui.focus, ui.Kids[index])
+	if index == ui.Active {
+		ui.stopTransition()
+	}
+	ui.Kids = kidsRemove(ui.Kids, index, 1)
+	if index < ui.Active || ui.Active >= len(ui.Kids) {
+		ui.Active = maximum(0, ui.Active-1)
+	}
+	if k := ui.active(); k != nil {
+		k.Layout = Dirty
+	}
+	dui.MarkLayout(ui)
+}
+
+// Move moves the kid at index from to index to, and marks the stack for layout.
+// The active kid stays active.
+func (ui *Stack) Move(dui *DUI, from, to int) {
+	kidsMove(ui.Kids, from, to, 1)
+	switch {
+	case ui.Active == from:
+		ui.Active = to
+	case from < ui.Active && to >= ui.Active:
+		ui.Active--
+	case from > ui.Active && to <= ui.Active:
+		ui.Active++
+	}
+	dui.MarkLayout(ui)
+}
+
+// Replace replaces the kid at index with a new kid for newUI, and marks the stack for layout.
+func (ui *Stack) Replace(dui *DUI, index int, newUI UI) {
+	delete(ui.focus, ui.Kids[index])
+	ui.Kids[index] = &Kid{UI: newUI}
+	dui.MarkLayout(ui)
+}
+
+func (ui *Stack) background(dui *DUI) *draw.Image {
+	if ui.Background != nil {
+		return ui.Background
+	}
+	return dui.Background
+}
+
+func (ui *Stack) duration() time.Duration {
+	if ui.Duration > 0 {
+		return ui.Duration
+	}
+	return 200 * time.Millisecond
+}
+
+func (ui *Stack) stopTransition() {
+	t := ui.transition
+	if t == nil {
+		return
+	}
+	t.from.Free()
+	if t.to != nil {
+		t.to.Free()
+	}
+	ui.transition = nil
+}
+
+func (ui *Stack) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+	kids := ui.activeKids()
+	if KidsLayout(dui, self, kids, force) {
+		return
+	}
+
+	ui.size = image.ZP
+	for _, k := range kids {
+		if k.Hidden {
+			continue
+		}
+		k.UI.Layout(dui, k, sizeAvail, true)
+		k.R = rect(k.R.Size())
+		ui.size = k.R.Size()
+	}
+	self.R = rect(ui.size)
+}
+
+// Measure measures the active kid.
+func (ui *Stack) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	k := ui.active()
+	if k == nil {
+		return Sizes{}
+	}
+	return KidMeasure(dui, k, sizeAvail)
+}
+
+func (ui *Stack) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	k := ui.active()
+	t := ui.transition
+	if t != nil && (k == nil || k.R.Empty() || time.Since(t.start) >= ui.duration()) {
+		ui.stopTransition()
+		t = nil
+		force = true
+	}
+	if t == nil {
+		KidsDraw(dui, self, ui.activeKids(), ui.size, ui.Background, img, orig, m, force)
+		return
+	}
+
+	dui.debugDraw(self)
+	bg := ui.background(dui)
+	if t.to == nil || !t.to.R.Size().Eq(k.R.Size()) {
+		if t.to != nil {
+			t.to.Free()
+			t.to = nil
+		}
+		var err error
+		t.to, err = dui.Display.AllocImage(rect(k.R.Size()), draw.ARGB32, false, dui.BackgroundColor)
+		if dui.error(err, "allocimage") {
+			ui.stopTransition()
+			return
+		}
+	}
+	t.to.Draw(t.to.R, bg, nil, image.ZP)
+	mm := m
+	mm.Point = mm.Point.Sub(k.R.Min)
+	k.UI.Draw(dui, k, t.to, image.ZP, mm, true)
+	k.Draw = Clean
+	self.Draw = Clean
+
+	r := rect(ui.size).Add(orig)
+	img.Draw(r, bg, nil, image.ZP)
+	progress := float64(time.Since(t.start)) / float64(ui.duration())
+	ui.Transition(img, r, t.from, t.to, progress)
+
+	if !t.scheduled {
+		t.scheduled = true
+		time.AfterFunc(time.Second/60, func() {
+			dui.Call <- func() {
+				t.scheduled = false
+				if ui.transition == t {
+					dui.MarkDraw(ui)
+				}
+			}
+		})
+	}
+}
+
+func (ui *Stack) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	ui.orig = orig
+	return KidsMouse(dui, self, ui.activeKids(), m, origM, orig)
+}
+
+func (ui *Stack) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	ui.orig = orig
+	return KidsKey(dui, self, ui.activeKids(), k, m, orig)
+}
+
+func (ui *Stack) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	return KidsFirstFocus(dui, self, ui.activeKids())
+}
+
+// Focus for the stack itself returns the location remembered for the active kid with KeepFocus, or the first focus of the active kid.
+func (ui *Stack) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if o != ui {
+		return KidsFocus(dui, self, ui.activeKids(), o)
+	}
+	k := ui.active()
+	if k == nil {
+		return nil
+	}
+	if p, ok := ui.focus[k]; ok {
+		p = p.Add(k.R.Min)
+		return &p
+	}
+	return ui.FirstFocus(dui, self)
+}
+
+// Mark looks in all kids, also inactive kids, so they can be marked before being selected.
+func (ui *Stack) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return KidsMark(self, ui.Kids, o, forLayout)
+}
+
+func (ui *Stack) Print(self *Kid, indent int) {
+	PrintUI("Stack", self, indent)
+	KidsPrint(ui.Kids, indent+1)
+}
@@ -5,38 +5,269 @@ import (
 	"image"
 )
 
-// tabs just stores the centered buttongroup and active UI in a box and lets that handle all the UI interface calls
+// tabs just stores the centered buttongroup and a stack with the UIs in a box and lets that handle all the UI interface calls
+
+// TabPlacement is the side of Tabs on which the buttons are shown.
+type TabPlacement byte
+
+const (
+	TabsTop    TabPlacement = iota // Buttons centered above the active UI.
+	TabsBottom                     // Buttons centered below the active UI.
+	TabsLeft                       // Buttons stacked vertically, at full height, left of the active UI.
+	TabsRight                      // Buttons stacked vertically, at full height, right of the active UI.
+)
 
 // Tabs has a Buttongroup and displays only the active selected UI.
+// Tabs can be added, removed, moved and selected while the UI is running, through the functions on Tabs.
+// Set Closable and Movable on the Buttongroup to let users close and reorder tabs.
+// For left and right placement, set Icons on the Buttongroup to show an icon with each tab.
 type Tabs struct {
-	Buttongroup *Buttongroup // Shown at top of Tabs.
-	UIs         []UI         // UIs selected by Buttongroup, must have same number of elements as buttons in Buttongroup.
+	Buttongroup *Buttongroup                 // Shown at the side of Tabs set by Placement. Tabs sets its Changed, Close, Moved and Vertical fields.
+	Placement   TabPlacement                 // Where the buttons are shown, at the top by default. Call MarkLayout after changing.
+	UIs         []UI                         // UIs selected by Buttongroup, must have same number of elements as buttons in Buttongroup.
+	Stack       *Stack                       // Optional, shows the selected UI. Set it to configure KeepFocus or Transition. Tabs manages its Kids and Active fields.
+	Changed     func(index int) (e Event)    `json:"-"` // Called after the user selected a different tab, or closed the active tab. Not called for changes through Tabs functions.
+	Close       func(index int) (e Event)    `json:"-"` // Called when the user clicks the close mark of a tab. Unless the event is consumed, the tab is removed.
+	Moved       func(from, to int) (e Event) `json:"-"` // Called after the user dragged a tab to a new position.
 	Box
+
+	placement TabPlacement // placement at which Box.Kids[0] was created
 }
 
 var _ UI = &Tabs{}
 
 // ensure Box is set up properly
 func (ui *Tabs) ensure(dui *DUI) {
+	if len(ui.UIs) != len(ui.Buttongroup.Texts) {
+		panic(fmt.Sprintf("bad Tabs, len(UIs) = %d must be equal to len(ui.Buttongroup.Texts) %d", len(ui.UIs), len(ui.Buttongroup.Texts)))
+	}
+	if ui.Box.Kids != nil && ui.placement != ui.Placement {
+		ui.Box.Kids[0] = &Kid{UI: ui.bar()}
+		ui.placement = ui.Placement
+	}
 	if ui.Box.Kids == nil {
-		if len(ui.UIs) != len(ui.Buttongroup.Texts) {
-			panic(fmt.Sprintf("bad Tabs, len(UIs) = %d must be equal to len(ui.Buttongroup.Texts) %d", len(ui.UIs), len(ui.Buttongroup.Texts)))
+		if ui.Stack == nil {
+			ui.Stack = &Stack{}
 		}
-		ui.Box.Kids = NewKids(CenterUI(SpaceXY(4, 4), ui.Buttongroup), ui.UIs[ui.Buttongroup.Selected])
+		ui.sync()
+		ui.Box.Kids = NewKids(ui.bar(), ui.Stack)
+		ui.placement = ui.Placement
 		ui.Buttongroup.Changed = func(index int) (e Event) {
-			k := ui.Box.Kids[1]
-			k.UI = ui.UIs[index]
+			ui.Stack.Select(dui, index)
+			if ui.Changed != nil {
+				e = ui.Changed(index)
+			}
 			e.Consumed = true
-			e.NeedLayout = true
-			dui.MarkLayout(k.UI)
 			return
 		}
+		ui.Buttongroup.Close = func(index int) (e Event) {
+			if ui.Close != nil {
+				e = ui.Close(index)
+				if e.Consumed {
+					return
+				}
+			}
+			active := index == ui.Buttongroup.selected()
+			ui.Remove(dui, index)
+			if active && len(ui.UIs) > 0 && ui.Changed != nil {
+				e = ui.Changed(ui.Buttongroup.selected())
+			}
+			e.Consumed = true
+			return
+		}
+		ui.Buttongroup.Moved = func(from, to int) (e Event) {
+			ui.moveUI(from, to)
+			ui.sync()
+			if ui.Moved != nil {
+				e = ui.Moved(from, to)
+			}
+			e.Consumed = true
+			return
+		}
+	}
+}
+
+func (ui *Tabs) vertical() bool {
+	return ui.Placement == TabsLeft || ui.Placement == TabsRight
+}
+
+// bar returns the UI holding the Buttongroup for the current placement.
+func (ui *Tabs) bar() UI {
+	ui.Buttongroup.Vertical = ui.vertical()
+	if ui.Buttongroup.Vertical {
+		return ui.Buttongroup
+	}
+	return CenterUI(SpaceXY(4, 4), ui.Buttongroup)
+}
+
+// sync makes the Kids of Stack match UIs, keeping the Kid of UIs that are still present, and activates the selected tab.
+func (ui *Tabs) sync() {
+	s := ui.Stack
+	kids := map[UI]*Kid{}
+	for _, k := range s.Kids {
+		kids[k.UI] = k
+	}
+	s.Kids = make([]*Kid, len(ui.UIs))
+	for i, tabUI := range ui.UIs {
+		k := kids[tabUI]
+		if k == nil {
+			k = &Kid{UI: tabUI}
+		}
+		s.Kids[i] = k
+	}
+	if len(ui.UIs) > 0 && s.Active != ui.Buttongroup.selected() {
+		s.Active = ui.Buttongroup.selected()
+		s.Kids[s.Active].Layout = Dirty
+	}
+}
+
+func (ui *Tabs) moveUI(from, to int) {
+	x := ui.UIs[from]
+	if from < to {
+		copy(ui.UIs[from:to], ui.UIs[from+1:to+1])
+	} else {
+		copy(ui.UIs[to+1:from+1], ui.UIs[to:from])
+	}
+	ui.UIs[to] = x
+}
+
+// Insert adds a tab with text and tabUI at index, and selects it if it is the first tab.
+// Index -1 means at the end.
+// If the Buttongroup has Icons, the new tab gets an empty icon, which can be set in Icons after the insert.
+func (ui *Tabs) Insert(dui *DUI, index int, text string, tabUI UI) {
+	ui.ensure(dui)
+	bg := ui.Buttongroup
+	if index < 0 {
+		index = len(ui.UIs)
+	}
+	bg.Texts = append(bg.Texts, "")
+	copy(bg.Texts[index+1:], bg.Texts[index:])
+	bg.Texts[index] = text
+	if bg.Icons != nil {
+		bg.Icons = append(bg.Icons, Icon{})
+		copy(bg.Icons[index+1:], bg.Icons[index:])
+		bg.Icons[index] = Icon{}
+	}
+	ui.UIs = append(ui.UIs, nil)
+	copy(ui.UIs[index+1:], ui.UIs[index:])
+	ui.UIs[index] = tabUI
+	if len(ui.UIs) > 1 && index <= bg.Selected {
+		bg.Selected++
+	}
+	ui.sync()
+	dui.MarkLayout(ui)
+}
+
+// Remove removes the tab at index.
+// If the active tab is removed, the next tab becomes active, or the previous if there is no next tab.
+func (ui *Tabs) Remove(dui *DUI, index int) {
+	ui.ensure(dui)
+	bg := ui.Buttongroup
+	bg.Texts = append(bg.Texts[:index], bg.Texts[index+1:]...)
+	if index < len(bg.Icons) {
+		bg.Icons = append(bg.Icons[:index], bg.Icons[index+1:]...)
+	}
+	n := len(ui.UIs) - 1
+	copy(ui.UIs[index:], ui.UIs[index+1:])
+	ui.UIs[n] = nil
+	ui.UIs = ui.UIs[:n]
+	if index < bg.Selected || bg.Selected >= len(ui.UIs) {
+		bg.Selected = maximum(0, bg.Selected-1)
+	}
+	ui.sync()
+	dui.MarkLayout(ui)
+}
+
+// Move moves the tab at index from to index to.
+func (ui *Tabs) Move(dui *DUI, from, to int) {
+	ui.ensure(dui)
+	ui.Buttongroup.move(from, to)
+	ui.moveUI(from, to)
+	ui.sync()
+	dui.MarkLayout(ui)
+}
+
+// Replace replaces the UI of the tab at index with tabUI, keeping its button.
+func (ui *Tabs) Replace(dui *DUI, index int, tabUI UI) {
+	ui.ensure(dui)
+	ui.UIs[index] = tabUI
+	ui.sync()
+	if index == ui.Stack.Active {
+		ui.Stack.Kids[index].Layout = Dirty
 	}
+	dui.MarkLayout(ui)
+}
+
+// Select makes the tab at index active.
+func (ui *Tabs) Select(dui *DUI, index int) {
+	ui.ensure(dui)
+	ui.Buttongroup.Selected = index
+	ui.Buttongroup.scrollVisible(dui, index)
+	dui.MarkDraw(ui.Buttongroup)
+	ui.Stack.Select(dui, index)
 }
 
 func (ui *Tabs) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 	ui.ensure(dui)
-	ui.Box.Layout(dui, self, sizeAvail, force)
+	if ui.Placement == TabsTop {
+		ui.Box.Layout(dui, self, sizeAvail, force)
+		return
+	}
+
+	dui.debugLayout(self)
+	if KidsLayout(dui, self, ui.Box.Kids, force) {
+		return
+	}
+
+	// the buttons stay at the far side of the available space, also when the active UI is smaller
+	bar, active := ui.Box.Kids[0], ui.Box.Kids[1]
+	bar.UI.Layout(dui, bar, sizeAvail, true)
+	barSize := bar.R.Size()
+	var size image.Point
+	switch ui.Placement {
+	case TabsBottom:
+		active.UI.Layout(dui, active, image.Pt(sizeAvail.X, sizeAvail.Y-barSize.Y), true)
+		active.R = rect(active.R.Size())
+		y := maximum(active.R.Dy(), sizeAvail.Y-barSize.Y)
+		bar.R = rect(barSize).Add(image.Pt(0, y))
+		size = image.Pt(maximum(barSize.X, active.R.Dx()), y+barSize.Y)
+	case TabsLeft:
+		active.UI.Layout(dui, active, image.Pt(sizeAvail.X-barSize.X, sizeAvail.Y), true)
+		bar.R = rect(barSize)
+		active.R = rect(active.R.Size()).Add(image.Pt(barSize.X, 0))
+		size = image.Pt(barSize.X+active.R.Dx(), maximum(barSize.Y, active.R.Dy()))
+	case TabsRight:
+		active.UI.Layout(dui, active, image.Pt(sizeAvail.X-barSize.X, sizeAvail.Y), true)
+		active.R = rect(active.R.Size())
+		x := maximum(active.R.Dx(), sizeAvail.X-barSize.X)
+		bar.R = rect(barSize).Add(image.Pt(x, 0))
+		size = image.Pt(x+barSize.X, maximum(barSize.Y, active.R.Dy()))
+	}
+	ui.Box.size = size
+	self.R = rect(size)
+}
+
+func (ui *Tabs) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	ui.ensure(dui)
+	if ui.Placement == TabsTop {
+		return ui.Box.Measure(dui, self, sizeAvail)
+	}
+
+	// like Layout, the buttons stay at the far side of the available space
+	bar := KidMeasure(dui, ui.Box.Kids[0], sizeAvail)
+	switch ui.Placement {
+	case TabsBottom:
+		active := KidMeasure(dui, ui.Box.Kids[1], image.Pt(sizeAvail.X, sizeAvail.Y-bar.Pref.Y))
+		active.Pref.Y = maximum(active.Pref.Y, sizeAvail.Y-bar.Pref.Y)
+		return active.below(bar)
+	case TabsLeft:
+		active := KidMeasure(dui, ui.Box.Kids[1], image.Pt(sizeAvail.X-bar.Pref.X, sizeAvail.Y))
+		return bar.beside(active)
+	default:
+		active := KidMeasure(dui, ui.Box.Kids[1], image.Pt(sizeAvail.X-bar.Pref.X, sizeAvail.Y))
+		active.Pref.X = maximum(active.Pref.X, sizeAvail.X-bar.Pref.X)
+		return active.beside(bar)
+	}
 }
 
 func (ui *Tabs) Print(self *Kid, indent int) {
@@ -0,0 +1,254 @@
+package duit
+
+import (
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// TextArea is a multi-line text input, for forms. It grows from MinLines to MaxLines lines as text is added, and scrolls beyond that.
+// The text is kept in memory. It is edited like in an Edit, with the same key shortcuts, unlimited undo and redo, and vi command and visual mode after escape.
+// Long lines are wrapped at word boundaries.
+// Tab is not inserted, it moves focus to the next UI, like in a Field.
+type TextArea struct {
+	Text        string                               // Current text.
+	Placeholder string                               // Text displayed in lighter color as example, while Text is empty.
+	Disabled    bool                                 // If disabled, mouse and key input have no effect.
+	MinLines    int                                  // Minimum number of lines, 3 if zero.
+	MaxLines    int                                  // Maximum number of lines, the text scrolls when it is longer. 10 if zero.
+	Font        *draw.Font                           `json:"-"` // Font to use for drawing text.
+	Changed     func(text string) (e Event)          `json:"-"` // Called after contents of the text area have changed.
+	Keys        func(k rune, m draw.Mouse) (e Event) `json:"-"` // Called before handling key. If you consume the event, Changed will not be called.
+
+	size     image.Point
+	edit     *Edit  // Text engine, drawing the text.
+	editText string // Text in edit, to detect changes to Text.
+	kid      Kid    // Holds edit, R is relative to the text area.
+	lines    int    // Number of lines shown, between MinLines and MaxLines.
+	scroll   bool   // Whether the text has more lines than MaxLines, and a scrollbar is shown.
+	colors   EditColors
+}
+
+var _ UI = &TextArea{}
+
+func (ui *TextArea) font(dui *DUI) *draw.Font {
+	return dui.Font(ui.Font)
+}
+
+func (ui *TextArea) lineLimits() (min, max int) {
+	min, max = ui.MinLines, ui.MaxLines
+	if min <= 0 {
+		min = 3
+	}
+	if max <= 0 {
+		max = 10
+	}
+	return min, maximum(min, max)
+}
+
+// inset is the room between the edge of the text area and the Edit, for the border and padding.
+func (ui *TextArea) inset(dui *DUI) image.Point {
+	return image.Pt(1, 1+ui.font(dui).Height/4)
+}
+
+// ensureEdit returns the Edit holding Text. If Text was changed since the last edit, the Edit is started anew, without history.
+func (ui *TextArea) ensureEdit(dui *DUI) *Edit {
+	if ui.edit == nil || ui.editText != ui.Text {
+		ui.edit = newTextEdit(ui.Text)
+		ui.editText = ui.Text
+		ui.kid = Kid{UI: ui.edit}
+	}
+	ui.edit.dui = dui
+	ui.edit.Font = ui.Font
+	ui.edit.Colors = &ui.colors
+	return ui.edit
+}
+
+// measureLines returns the number of lines to show for the text wrapped in a text area width wide, and whether the text needs to scroll.
+// Text is measured in a separate Edit, so measuring does not reset the cursor and history of the Edit that is shown.
+func (ui *TextArea) measureLines(dui *DUI, width int) (lines int, scroll bool) {
+	edit := newTextEdit(ui.Text)
+	edit.dui = dui
+	edit.Font = ui.Font
+	min, max := ui.lineLimits()
+	width -= 2*ui.inset(dui).X + dui.ScaleSpace(EditPadding).Dx()
+	offset := int64(0)
+	for lines <= max {
+		lines++
+		var eof bool
+		_, offset, eof = edit.nextLine(offset, width)
+		if eof {
+			break
+		}
+	}
+	if lines > max {
+		return max, true
+	}
+	return maximum(min, lines), false
+}
+
+func (ui *TextArea) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+
+	edit := ui.ensureEdit(dui)
+	ui.lines, ui.scroll = ui.measureLines(dui, sizeAvail.X)
+	edit.NoScrollbar = !ui.scroll
+	if !ui.scroll {
+		edit.offset = 0
+	}
+	inset := ui.inset(dui)
+	editSize := image.Pt(sizeAvail.X-2*inset.X, ui.lines*ui.font(dui).Height)
+	edit.Layout(dui, &ui.kid, editSize, force)
+	ui.kid.R = ui.kid.R.Add(inset)
+	ui.size = image.Pt(sizeAvail.X, editSize.Y+2*inset.Y)
+	self.R = rect(ui.size)
+}
+
+// Measure returns the available width as preferred size, and room for a few characters as minimum. The height is that of the lines of text, between MinLines and MaxLines.
+func (ui *TextArea) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	font := ui.font(dui)
+	inset := ui.inset(dui)
+	lines, scroll := ui.measureLines(dui, sizeAvail.X)
+	dy := lines*font.Height + 2*inset.Y
+	minX := font.StringWidth("mmm") + 2*inset.X + dui.ScaleSpace(EditPadding).Dx()
+	if scroll {
+		minX += dui.Scale(ScrollbarSize)
+	}
+	size := image.Pt(sizeAvail.X, dy)
+	return Sizes{image.Pt(minX, dy), size, size}
+}
+
+func (ui *TextArea) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+
+	if ui.size.X <= 0 || ui.size.Y <= 0 {
+		return
+	}
+	edit := ui.ensureEdit(dui)
+	r := rect(ui.size)
+	hover := m.In(r)
+	r = r.Add(orig)
+
+	colors := dui.Regular.Normal
+	if ui.Disabled {
+		colors = dui.Disabled
+	} else if hover {
+		colors = dui.Regular.Hover
+	}
+	ui.colors = EditColors{
+		Fg:             colors.Text,
+		Bg:             colors.Background,
+		SelFg:          dui.Inverse.Text,
+		SelBg:          dui.Inverse.Background,
+		ScrollVis:      dui.ScrollVisibleNormal,
+		ScrollBg:       dui.ScrollBGNormal,
+		HoverScrollVis: dui.ScrollVisibleHover,
+		HoverScrollBg:  dui.ScrollBGHover,
+		CommandBorder:  colors.Background, // vi modes are shown with the border of the text area
+		VisualBorder:   colors.Background,
+	}
+
+	border := colors.Border
+	if ui.Text == "" && !ui.Disabled && !hover {
+		border = dui.Placeholder.Border
+	}
+	switch edit.mode {
+	case modeCommand:
+		border = dui.CommandMode
+	case modeVisual, modeVisualLine:
+		border = dui.VisualMode
+	}
+	img.Draw(r, colors.Background, nil, image.ZP)
+	drawRoundedBorder(img, r, border)
+
+	mm := m
+	mm.Point = mm.Point.Sub(ui.kid.R.Min)
+	edit.Draw(dui, &ui.kid, img, orig.Add(ui.kid.R.Min), mm, true)
+	ui.kid.Draw = Clean
+
+	if ui.Text == "" && ui.Placeholder != "" {
+		font := ui.font(dui)
+		p := orig.Add(edit.textR.Min).Add(ui.kid.R.Min)
+		drawBidiString(dui, img, p, edit.textR.Dx(), dui.Placeholder.Text, font, ui.Placeholder)
+	}
+}
+
+// changed updates Text after the Edit handled input, calls Changed, and requests a layout if the number of lines changed.
+func (ui *TextArea) changed(dui *DUI, self *Kid, r *Result) {
+	if ui.kid.Draw != Clean {
+		self.Draw = Dirty
+	}
+	buf, err := ui.edit.Text()
+	if ui.edit.error(err, "text") || string(buf) == ui.Text {
+		return
+	}
+	ui.Text = string(buf)
+	ui.editText = ui.Text
+	self.Draw = Dirty
+	if lines, scroll := ui.measureLines(dui, ui.size.X); lines != ui.lines || scroll != ui.scroll {
+		self.Layout = Dirty
+	}
+	if ui.Changed != nil {
+		e := ui.Changed(ui.Text)
+		propagateEvent(self, r, e)
+	}
+}
+
+func (ui *TextArea) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	if ui.Disabled || !origM.In(rect(ui.size)) {
+		return
+	}
+	if !ui.scroll && (m.Buttons == Button4 || m.Buttons == Button5) {
+		// nothing to scroll, leave it to the UIs around us
+		return
+	}
+	edit := ui.ensureEdit(dui)
+	m.Point = m.Point.Sub(ui.kid.R.Min)
+	origM.Point = origM.Point.Sub(ui.kid.R.Min)
+	r = edit.Mouse(dui, &ui.kid, m, origM, orig.Add(ui.kid.R.Min))
+	ui.changed(dui, self, &r)
+	return
+}
+
+func (ui *TextArea) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	if ui.Disabled || !m.In(rect(ui.size)) {
+		return
+	}
+	if ui.Keys != nil {
+		e := ui.Keys(k, m)
+		propagateEvent(self, &r, e)
+		if r.Consumed {
+			return
+		}
+	}
+	if k == '\t' {
+		return
+	}
+	edit := ui.ensureEdit(dui)
+	edit.key(dui, &ui.kid, k, orig.Add(ui.kid.R.Min), &r)
+	ui.changed(dui, self, &r)
+	return
+}
+
+func (ui *TextArea) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	p := ui.inset(dui)
+	if ui.edit != nil && ui.edit.lastCursorPoint != image.ZP {
+		p = ui.edit.lastCursorPoint.Add(ui.kid.R.Min)
+	}
+	return &p
+}
+
+func (ui *TextArea) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if o != ui {
+		return nil
+	}
+	return ui.FirstFocus(dui, self)
+}
+
+func (ui *TextArea) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return self.Mark(o, forLayout)
+}
+
+func (ui *TextArea) Print(self *Kid, indent int) {
+	PrintUI("TextArea", self, indent)
+}
@@ -0,0 +1,112 @@
+package duit
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"9fans.net/go/draw"
+)
+
+// textSelect is a selection of text made with the mouse and keys, for Label and RichText.
+// Dragging with button1 selects characters, double-clicking a word, triple-clicking a line.
+type textSelect struct {
+	start, end int        // Byte offsets. Start is where the selection started, not necessarily before end.
+	clicks     int        // Consecutive button1 clicks: 1 selects characters, 2 a word, 3 a line.
+	prevB1     draw.Mouse // Last button1 press, to detect double and triple clicks.
+}
+
+// ordered returns the start and end of the selection, limited to text.
+func (ts *textSelect) ordered(text string) (int, int) {
+	s := minimum(ts.start, len(text))
+	e := minimum(ts.end, len(text))
+	if s > e {
+		s, e = e, s
+	}
+	return s, e
+}
+
+// mouse updates the selection for m, with om the previous mouse state and o the offset in text under the mouse.
+// It returns whether the selection changed.
+func (ts *textSelect) mouse(text string, om, m draw.Mouse, o int) bool {
+	if om.Buttons&Button1 == 0 && m.Buttons&Button1 != 0 {
+		if m.Msec-ts.prevB1.Msec < 400 && ts.clicks < 3 {
+			ts.clicks++
+		} else {
+			ts.clicks = 1
+		}
+		ts.prevB1 = m
+		switch ts.clicks {
+		case 1:
+			ts.start, ts.end = o, o
+		case 2:
+			ts.start, ts.end = expandWord(text, o)
+		case 3:
+			ts.start, ts.end = expandLine(text, o)
+		}
+		return true
+	}
+	if om.Buttons&Button1 != 0 && m.Buttons&Button1 != 0 && ts.clicks == 1 && o != ts.end {
+		ts.end = o
+		return true
+	}
+	return false
+}
+
+// key handles cmd-a, cmd-n and cmd-c. It returns whether k was handled, and whether the selection changed.
+// Without selection, cmd-c copies all text.
+func (ts *textSelect) key(dui *DUI, text string, k rune) (consumed, changed bool) {
+	switch k {
+	case draw.KeyCmd + 'a':
+		ts.start, ts.end = 0, len(text)
+		return true, true
+	case draw.KeyCmd + 'n':
+		ts.start, ts.end = 0, 0
+		return true, true
+	case draw.KeyCmd + 'c':
+		if s, e := ts.ordered(text); s < e {
+			text = text[s:e]
+		}
+		dui.WriteSnarf([]byte(text))
+		return true, false
+	}
+	return false, false
+}
+
+// expandWord returns the word in text around offset o, or the single character at o if it is not part of a word.
+func expandWord(text string, o int) (int, int) {
+	word := func(c rune) bool {
+		return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+	}
+	s := o
+	for s > 0 {
+		c, n := utf8.DecodeLastRuneInString(text[:s])
+		if !word(c) {
+			break
+		}
+		s -= n
+	}
+	e := o
+	for e < len(text) {
+		c, n := utf8.DecodeRuneInString(text[e:])
+		if !word(c) {
+			break
+		}
+		e += n
+	}
+	if s == e && e < len(text) {
+		_, n := utf8.DecodeRuneInString(text[e:])
+		e += n
+	}
+	return s, e
+}
+
+// expandLine returns the line in text around offset o, including its newline.
+func expandLine(text string, o int) (int, int) {
+	s := strings.LastIndexByte(text[:o], '\n') + 1
+	e := len(text)
+	if i := strings.IndexByte(text[o:], '\n'); i >= 0 {
+		e = o + i + 1
+	}
+	return s, e
+}
@@ -0,0 +1,439 @@
+package duit
+
+import (
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// TreeNode is a node in a Tree.
+type TreeNode struct {
+	Text     string      // Text shown, as single line.
+	Icon     Icon        `json:"-"` // Drawn before Text, if Icon.Font is not nil.
+	Value    interface{} `json:"-"` // Auxiliary data.
+	Selected bool
+	Expanded bool        // Whether the children are shown. Call MarkLayout after changing.
+	Leaf     bool        // If set, the node has no children, and Tree.Load is not called for it.
+	Children []*TreeNode // Child nodes. If nil and Tree.Load is set, Load is called when the node is expanded. Call MarkLayout after changing.
+
+	loading bool // Whether Tree.Load is running for this node.
+}
+
+// treeRow is a visible row in a tree.
+type treeRow struct {
+	node   *TreeNode // Nil for the row shown while children of parent are loading.
+	parent *TreeNode // Nil for top-level nodes.
+	depth  int
+}
+
+// Tree shows hierarchical values, such as a file system, with nodes that can be expanded to show their children.
+// Children can be loaded on demand by Load, when a node is expanded.
+// Nodes can be selected, single or multiple, with callbacks when the selection changes, like in a List.
+// Clicking the arrow before a node expands or collapses it.
+//
+// Keys:
+//	arrow up, move selection up
+//	arrow down, move selection down
+//	home, move selection to first node
+//	end, move selection to last node
+//	arrow left, collapse the selected node, or select its parent
+//	arrow right, expand the selected node, or select its first child
+type Tree struct {
+	Nodes    []*TreeNode                                  // Top-level nodes.
+	Multiple bool                                         // Whether multiple nodes can be selected at a time.
+	Font     *draw.Font                                   `json:"-"` // For drawing the nodes.
+	Load     func(node *TreeNode) (children []*TreeNode)  `json:"-"` // Called in a separate goroutine to load the children of an expanded node without children. Must not change UI state. While loading, an ellipsis is shown below the node.
+	Changed  func(node *TreeNode) (e Event)               `json:"-"` // Called after the selection changes, node being the node that was selected or unselected.
+	Click    func(node *TreeNode, m draw.Mouse) (e Event) `json:"-"` // Called on click at node, before handling selection change or expanding. If consumed, processing stops.
+	Keys     func(k rune, m draw.Mouse) (e Event)         `json:"-"` // Called on key. If consumed, processing stops.
+
+	m    draw.Mouse
+	size image.Point
+	rows []treeRow
+}
+
+var _ UI = &Tree{}
+
+func (ui *Tree) font(dui *DUI) *draw.Font {
+	return dui.Font(ui.Font)
+}
+
+func (ui *Tree) rowHeight(dui *DUI) int {
+	return 4 * ui.font(dui).Height / 3
+}
+
+// indent is the width of each level of nesting, and of the expand arrow.
+func (ui *Tree) indent(dui *DUI) int {
+	return ui.font(dui).Height
+}
+
+func (ui *Tree) padding(dui *DUI) int {
+	return ui.font(dui).Height / 4
+}
+
+// expandable returns whether node has, or may have, children.
+func (ui *Tree) expandable(node *TreeNode) bool {
+	return !node.Leaf && (len(node.Children) > 0 || node.Children == nil && ui.Load != nil)
+}
+
+// visible appends the rows for nodes at depth, and for the children of expanded nodes.
+func (ui *Tree) visible(rows []treeRow, parent *TreeNode, nodes []*TreeNode, depth int) []treeRow {
+	for _, n := range nodes {
+		rows = append(rows, treeRow{n, parent, depth})
+		if !n.Expanded || !ui.expandable(n) {
+			continue
+		}
+		if n.loading {
+			rows = append(rows, treeRow{nil, n, depth + 1})
+			continue
+		}
+		rows = ui.visible(rows, n, n.Children, depth+1)
+	}
+	return rows
+}
+
+// loadExpanded starts loading the children of expanded nodes that have none yet.
+func (ui *Tree) loadExpanded(dui *DUI, nodes []*TreeNode) {
+	for _, n := range nodes {
+		if !n.Expanded {
+			continue
+		}
+		ui.load(dui, n)
+		ui.loadExpanded(dui, n.Children)
+	}
+}
+
+// load starts loading the children of node with Load, if needed. The tree is laid out again when they have been loaded.
+func (ui *Tree) load(dui *DUI, node *TreeNode) {
+	if node.Leaf || node.Children != nil || ui.Load == nil || node.loading {
+		return
+	}
+	node.loading = true
+	go func() {
+		children := ui.Load(node)
+		dui.Call <- func() {
+			node.loading = false
+			if children == nil {
+				children = []*TreeNode{}
+			}
+			node.Children = children
+			ui.loadExpanded(dui, children)
+			dui.MarkLayout(ui)
+		}
+	}()
+}
+
+// iconWidth returns the room taken by the icon of node, including the space after it.
+func (ui *Tree) iconWidth(dui *DUI, node *TreeNode) int {
+	if node.Icon.Font == nil {
+		return 0
+	}
+	return node.Icon.Font.StringWidth(string(node.Icon.Rune)) + ui.font(dui).StringWidth(" ")
+}
+
+func (ui *Tree) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+
+	ui.loadExpanded(dui, ui.Nodes)
+	ui.rows = ui.visible(ui.rows[:0], nil, ui.Nodes, 0)
+	ui.size = image.Pt(sizeAvail.X, len(ui.rows)*ui.rowHeight(dui))
+	self.R = rect(ui.size)
+}
+
+// Measure returns the available width as preferred size, and the width of the widest visible node as minimum.
+func (ui *Tree) Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes {
+	font := ui.font(dui)
+	indent := ui.indent(dui)
+	rows := ui.visible(nil, nil, ui.Nodes, 0)
+	dx := 0
+	for _, row := range rows {
+		x := (row.depth + 1) * indent
+		if row.node == nil {
+			x += font.StringWidth("…")
+		} else {
+			x += ui.iconWidth(dui, row.node) + font.StringWidth(row.node.Text)
+		}
+		dx = maximum(dx, x)
+	}
+	dy := len(rows) * ui.rowHeight(dui)
+	size := image.Pt(sizeAvail.X, dy)
+	return Sizes{image.Pt(dx+2*ui.padding(dui), dy), size, size}
+}
+
+func (ui *Tree) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+
+	rowHeight := ui.rowHeight(dui)
+	font := ui.font(dui)
+	indent := ui.indent(dui)
+	pad := ui.padding(dui)
+	img.Draw(rect(ui.size).Add(orig), dui.Background, nil, image.ZP)
+	// x returns where to draw something w wide starting at x in left-to-right layout
+	x := func(x, w int) int {
+		if dui.RTL {
+			return orig.X + ui.size.X - x - w
+		}
+		return orig.X + x
+	}
+
+	for i, row := range ui.rows {
+		lineR := image.Rect(0, i*rowHeight, ui.size.X, (i+1)*rowHeight).Add(orig)
+		y := lineR.Min.Y + (rowHeight-font.Height)/2
+		rowX := pad + row.depth*indent
+		if row.node == nil {
+			s := "…"
+			img.String(image.Pt(x(rowX+indent, font.StringWidth(s)), y), dui.Placeholder.Text, image.ZP, font, s)
+			continue
+		}
+		node := row.node
+		colors := dui.Regular.Normal
+		if node.Selected {
+			colors = dui.Inverse
+			img.Draw(lineR, colors.Background, nil, image.ZP)
+		}
+		if ui.expandable(node) {
+			s := "▸"
+			if node.Expanded {
+				s = "▾"
+			} else if dui.RTL {
+				s = "◂"
+			}
+			img.String(image.Pt(x(rowX, font.StringWidth(s)), y), colors.Text, image.ZP, font, s)
+		}
+		rowX += indent
+		if icon := node.Icon; icon.Font != nil {
+			s := string(icon.Rune)
+			iconY := lineR.Min.Y + (rowHeight-icon.Font.Height)/2
+			img.String(image.Pt(x(rowX, icon.Font.StringWidth(s)), iconY), colors.Text, image.ZP, icon.Font, s)
+			rowX += ui.iconWidth(dui, node)
+		}
+		w := maximum(0, ui.size.X-rowX-pad)
+		drawBidiString(dui, img, image.Pt(x(rowX, w), y), w, colors.Text, font, node.Text)
+	}
+}
+
+// onArrow returns whether x, relative to the tree, is on the expand arrow of row.
+func (ui *Tree) onArrow(dui *DUI, row treeRow, x int) bool {
+	if dui.RTL {
+		x = ui.size.X - x
+	}
+	x0 := ui.padding(dui) + row.depth*ui.indent(dui)
+	return x >= x0 && x < x0+ui.indent(dui)
+}
+
+// toggle expands or collapses node, loading its children if needed.
+func (ui *Tree) toggle(dui *DUI, self *Kid, node *TreeNode) {
+	if !ui.expandable(node) {
+		return
+	}
+	node.Expanded = !node.Expanded
+	if node.Expanded {
+		ui.load(dui, node)
+	}
+	self.Layout = Dirty
+}
+
+func (ui *Tree) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	prevM := ui.m
+	ui.m = m
+	if !m.In(rect(ui.size)) {
+		return
+	}
+	index := m.Y / ui.rowHeight(dui)
+	if index >= len(ui.rows) || ui.rows[index].node == nil {
+		return
+	}
+	row := ui.rows[index]
+	if m.Buttons != 0 && prevM.Buttons^m.Buttons != 0 && ui.Click != nil {
+		e := ui.Click(row.node, m)
+		propagateEvent(self, &r, e)
+	}
+	if r.Consumed || prevM.Buttons != 0 || m.Buttons != Button1 {
+		return
+	}
+	r.Consumed = true
+	if ui.expandable(row.node) && ui.onArrow(dui, row, m.X) {
+		ui.toggle(dui, self, row.node)
+		return
+	}
+	node := row.node
+	node.Selected = !node.Selected
+	if node.Selected && !ui.Multiple {
+		ui.unselect(ui.Nodes, node)
+	}
+	if ui.Changed != nil {
+		e := ui.Changed(node)
+		propagateEvent(self, &r, e)
+	}
+	self.Draw = Dirty
+	return
+}
+
+// unselect unselects nodes and their descendants, except keep.
+func (ui *Tree) unselect(nodes []*TreeNode, keep *TreeNode) {
+	for _, n := range nodes {
+		if n != keep {
+			n.Selected = false
+		}
+		ui.unselect(n.Children, keep)
+	}
+}
+
+func (ui *Tree) selected(l []*TreeNode, nodes []*TreeNode) []*TreeNode {
+	for _, n := range nodes {
+		if n.Selected {
+			l = append(l, n)
+		}
+		l = ui.selected(l, n.Children)
+	}
+	return l
+}
+
+// Selected returns the selected nodes, including those in collapsed nodes, in depth-first order.
+func (ui *Tree) Selected() (nodes []*TreeNode) {
+	return ui.selected(nil, ui.Nodes)
+}
+
+// Unselect nodes, or if nodes is nil, unselects all.
+func (ui *Tree) Unselect(nodes []*TreeNode) {
+	if nodes == nil {
+		ui.unselect(ui.Nodes, nil)
+		return
+	}
+	for _, n := range nodes {
+		n.Selected = false
+	}
+}
+
+// selectedRows returns the indices of the visible rows with a selected node.
+func (ui *Tree) selectedRows() (l []int) {
+	for i, row := range ui.rows {
+		if row.node != nil && row.node.Selected {
+			l = append(l, i)
+		}
+	}
+	return
+}
+
+// nextRow returns the index of the first row with a node from index in direction delta, or -1.
+func (ui *Tree) nextRow(index, delta int) int {
+	for ; index >= 0 && index < len(ui.rows); index += delta {
+		if ui.rows[index].node != nil {
+			return index
+		}
+	}
+	return -1
+}
+
+func (ui *Tree) rowIndex(node *TreeNode) int {
+	for i, row := range ui.rows {
+		if row.node == node {
+			return i
+		}
+	}
+	return -1
+}
+
+func (ui *Tree) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	if !m.In(rect(ui.size)) {
+		return
+	}
+	if ui.Keys != nil {
+		e := ui.Keys(k, m)
+		propagateEvent(self, &r, e)
+		if r.Consumed {
+			return
+		}
+	}
+	if len(ui.rows) == 0 {
+		return
+	}
+	sel := ui.selectedRows()
+	oindex := -1
+	nindex := -1
+	switch k {
+	case draw.KeyUp:
+		if len(sel) == 0 {
+			nindex = ui.nextRow(len(ui.rows)-1, -1)
+		} else {
+			oindex = sel[0]
+			nindex = ui.nextRow(sel[0]-1, -1)
+		}
+	case draw.KeyDown:
+		if len(sel) == 0 {
+			nindex = ui.nextRow(0, 1)
+		} else {
+			oindex = sel[len(sel)-1]
+			nindex = ui.nextRow(sel[len(sel)-1]+1, 1)
+		}
+	case draw.KeyHome:
+		nindex = ui.nextRow(0, 1)
+	case draw.KeyEnd:
+		nindex = ui.nextRow(len(ui.rows)-1, -1)
+	case draw.KeyLeft, draw.KeyRight:
+		if len(sel) == 0 {
+			return
+		}
+		oindex = sel[0]
+		row := ui.rows[oindex]
+		expanded := row.node.Expanded && ui.expandable(row.node)
+		if k == draw.KeyLeft && expanded || k == draw.KeyRight && !expanded && ui.expandable(row.node) {
+			ui.toggle(dui, self, row.node)
+			r.Consumed = true
+			return
+		}
+		if k == draw.KeyLeft && row.parent != nil {
+			nindex = ui.rowIndex(row.parent)
+		} else if k == draw.KeyRight && expanded && oindex+1 < len(ui.rows) && ui.rows[oindex+1].depth > row.depth {
+			nindex = ui.nextRow(oindex+1, 1)
+		}
+	default:
+		return
+	}
+	if nindex < 0 {
+		return
+	}
+	r.Consumed = oindex != nindex
+	if !r.Consumed {
+		return
+	}
+	if oindex >= 0 {
+		ui.rows[oindex].node.Selected = false
+	}
+	node := ui.rows[nindex].node
+	node.Selected = true
+	self.Draw = Dirty
+	if ui.Changed != nil {
+		e := ui.Changed(node)
+		propagateEvent(self, &r, e)
+	}
+	font := ui.font(dui)
+	p := orig.Add(image.Pt(m.X, nindex*ui.rowHeight(dui)+font.Height/2))
+	r.Warp = &p
+	return
+}
+
+func (ui *Tree) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	rowHeight := ui.rowHeight(dui)
+	index := 0
+	if sel := ui.selectedRows(); len(sel) > 0 {
+		index = sel[0]
+	}
+	p := image.Pt(self.R.Dx()/2, index*rowHeight+rowHeight/2)
+	return &p
+}
+
+func (ui *Tree) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if o != ui {
+		return nil
+	}
+	return ui.FirstFocus(dui, self)
+}
+
+func (ui *Tree) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return self.Mark(o, forLayout)
+}
+
+func (ui *Tree) Print(self *Kid, indent int) {
+	PrintUI("Tree", self, indent)
+}
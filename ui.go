@@ -6,6 +6,34 @@ import (
 	"9fans.net/go/draw"
 )
 
+// Sizes is the result of measuring a UI, see UI.Measure.
+type Sizes struct {
+	Min  image.Point // Smallest usable size.
+	Pref image.Point // Preferred size, the size Layout would allocate.
+	Max  image.Point // Largest size the UI can make use of.
+}
+
+// fixedSizes returns Sizes for a UI that always has the same size.
+func fixedSizes(size image.Point) Sizes {
+	return Sizes{size, size, size}
+}
+
+// below returns the sizes of s with o placed below it.
+func (s Sizes) below(o Sizes) Sizes {
+	f := func(a, b image.Point) image.Point {
+		return image.Pt(maximum(a.X, b.X), a.Y+b.Y)
+	}
+	return Sizes{f(s.Min, o.Min), f(s.Pref, o.Pref), f(s.Max, o.Max)}
+}
+
+// beside returns the sizes of s with o placed to the right of it.
+func (s Sizes) beside(o Sizes) Sizes {
+	f := func(a, b image.Point) image.Point {
+		return image.Pt(a.X+b.X, maximum(a.Y, b.Y))
+	}
+	return Sizes{f(s.Min, o.Min), f(s.Pref, o.Pref), f(s.Max, o.Max)}
+}
+
 // UI is the interface implemented by a user interface element. For example Button, List, Grid, Scroll.
 // UIs must be able to layout themselves, draw themselves, handle mouse events, key presses, deal with focus requests.
 // UIs also help with propagating UI state and logging.
@@ -21,6 +49,12 @@ type UI interface {
 	// Layout must update self.R with a image.ZP-origin image.Rectangle of the size it allocated.
 	Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool)
 
+	// Measure returns the minimum, preferred and maximum size of the UI for `sizeAvail`, without laying out.
+	// Pref is the size Layout would allocate. Min is the smallest size at which the UI is still usable, Max the largest size it can make use of.
+	// Measure must not change the layout or draw state of the UI or its kids.
+	// Layout UIs measure their kids with KidMeasure, which caches the results.
+	Measure(dui *DUI, self *Kid, sizeAvail image.Point) Sizes
+
 	// Draw asks the UI to draw itself on `img`, with `orig` as offset and `m` as the current mouse (for hover states)
 	// as self.Kid indicates, and pass further Draw calls on to its children as necessary.
 	// If `force` is set, the UI must draw itself, overriding self.Draw.